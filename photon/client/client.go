@@ -10,56 +10,91 @@
 package client
 
 import (
+	"crypto/tls"
 	"errors"
 	"fmt"
-	"io"
-	"log"
+	"net/http"
 	"net/url"
 	"os"
+	"syscall"
 
 	"github.com/vmware/photon-controller-go-sdk/photon"
 
 	"github.com/urfave/cli"
+	"golang.org/x/crypto/ssh/terminal"
+
 	cf "github.com/vmware/photon-controller-cli/photon/configuration"
 )
 
 // Global variable pointing to photon client, can be assigned to mock client in tests
 var Photonclient *photon.Client
 
-var logger *log.Logger = nil
-var logFile *os.File = nil
-
+// Creates a client for the target of the configuration. An access token that has expired or
+// is about to is renewed first; if that fails, the request that gets rejected tries again.
 func NewClient(config *cf.Configuration) (*photon.Client, error) {
+	return newClient(config, true)
+}
+
+// Creates a client to log in with. The tokens of the configuration are left out, since they
+// are about to be replaced, so they are neither renewed nor a reason to prompt for a login.
+func NewLoginClient(config *cf.Configuration) (*photon.Client, error) {
+	return newClient(config, false)
+}
+
+func newClient(config *cf.Configuration, useTokens bool) (*photon.Client, error) {
 	if len(config.CloudTarget) == 0 {
 		return nil, errors.New("Specify a Photon Controller endpoint by running 'target set' command")
 	}
 
 	options := &photon.ClientOptions{
-		IgnoreCertificate: config.IgnoreCertificate,
-		TokenOptions: &photon.TokenOptions{
-			AccessToken:  config.Token,
-			RefreshToken: config.RefreshToken,
-		},
+		IgnoreCertificate:         config.IgnoreCertificate,
+		TokenOptions:              &photon.TokenOptions{},
 		UpdateAccessTokenCallback: updateToken,
 	}
+	if useTokens {
+		options.TokenOptions.AccessToken = config.Token
+		options.TokenOptions.RefreshToken = config.RefreshToken
+	}
 
 	//
 	// If target is https, check if we could ignore client side cert check
-	// If we can't ignore client side cert check, try setting the root certs
+	// If we can't ignore client side cert check, set the root certs used to reach the
+	// authentication server of the target, leaving out the ones accepted for other servers
+	// API calls only accept the certificates pinned for the target, or else the ones
+	// issued by the system's certificate authorities
 	//
+	tlsConfig := &tls.Config{InsecureSkipVerify: config.IgnoreCertificate}
 	u, err := url.Parse(config.CloudTarget)
 	if err == nil && u.Scheme == "https" {
 		if !config.IgnoreCertificate == true {
-			roots, err := cf.GetCertsFromLocalStore()
+			roots, err := config.AuthServerRoots()
 			if err == nil {
 				options.RootCAs = roots
 			} else {
 				return nil, err
 			}
+			pins := config.CertPins[cf.PinnedHost(config.CloudTarget)]
+			if len(pins) != 0 {
+				tlsConfig = pinnedTLSConfig(cf.PinnedHost(config.CloudTarget), pins)
+			}
 		}
 	}
 
-	esxclient := photon.NewClient(config.CloudTarget, options, logger)
+	// NewTestClient is the only way to give the SDK its own http.Client
+	transport := &apiTransport{base: &http.Transport{TLSClientConfig: tlsConfig}, tokens: options.TokenOptions}
+	esxclient := photon.NewTestClient(config.CloudTarget, options, &http.Client{Transport: transport})
+	if !useTokens {
+		return esxclient, nil
+	}
+	transport.renew = func() (string, error) {
+		return renewTokens(esxclient, options.TokenOptions, config.Login)
+	}
+
+	renewFailed := renewExpiringToken(esxclient, options.TokenOptions, config.Login)
+	err = warnIfTokenExpiring(os.Stderr, options.TokenOptions, config, renewFailed)
+	if err != nil {
+		return nil, err
+	}
 	return esxclient, nil
 }
 
@@ -67,6 +102,7 @@ func NewClient(config *cf.Configuration) (*photon.Client, error) {
 func GetClient(c *cli.Context) (*photon.Client, error) {
 	var err error
 	if Photonclient == nil {
+		canPromptForLogin = !c.GlobalIsSet("non-interactive") && terminal.IsTerminal(int(syscall.Stdin))
 		Photonclient, err = get()
 		if err != nil {
 			return nil, err
@@ -84,6 +120,19 @@ func GetClient(c *cli.Context) (*photon.Client, error) {
 	return Photonclient, nil
 }
 
+// Returns the photon client to log in with, if not set, it will read a config file. Unlike
+// GetClient, it neither uses nor renews the saved tokens.
+func GetLoginClient(c *cli.Context) (*photon.Client, error) {
+	if Photonclient != nil {
+		return Photonclient, nil
+	}
+	config, err := cf.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return NewLoginClient(config)
+}
+
 func printDetail() error {
 	config, err := cf.LoadConfig()
 	if err != nil {
@@ -91,18 +140,33 @@ func printDetail() error {
 	}
 
 	if config != nil {
-		fmt.Printf("Target: '%s'\n", Photonclient.Endpoint)
+		if len(cf.ProfileName) != 0 {
+			fmt.Printf("Profile: '%s'\n", cf.ProfileName)
+		} else if len(config.CurrentProfile) != 0 {
+			fmt.Printf("Profile: '%s'\n", config.CurrentProfile)
+		}
+		fmt.Printf("Target: '%s' (from %s)\n", Photonclient.Endpoint, config.Source(cf.TargetSetting))
+
+		if len(config.Token) == 0 {
+			fmt.Printf("Token: <not-set> \n")
+		} else {
+			fmt.Printf("Token: <set> (from %s)\n", config.Source(cf.TokenSetting))
+		}
+
+		if config.IgnoreCertificate {
+			fmt.Printf("Certificate check: disabled (from %s)\n", config.Source(cf.IgnoreCertSetting))
+		}
 
 		if config.Tenant == nil {
 			fmt.Printf("Tenant: <not-set> \n")
 		} else {
-			fmt.Printf("Tenant: '%s'\n", config.Tenant.Name)
+			fmt.Printf("Tenant: '%s' (from %s)\n", config.Tenant.Name, config.Source(cf.TenantSetting))
 		}
 
 		if config.Project == nil {
 			fmt.Printf("Project: <not-set> \n")
 		} else {
-			fmt.Printf("Project: '%s'\n", config.Project.Name)
+			fmt.Printf("Project: '%s' (from %s)\n", config.Project.Name, config.Source(cf.ProjectSetting))
 		}
 	}
 	fmt.Printf("\n")
@@ -119,52 +183,6 @@ func get() (*photon.Client, error) {
 	return NewClient(config)
 }
 
-func InitializeLogging(logFileName string) error {
-	var output io.Writer
-	var err error
-	if logFileName != "" {
-		logFile, err = os.OpenFile(
-			logFileName, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
-		if err != nil {
-			panic(err)
-		}
-		output = logFile
-		logger = log.New(
-			output,
-			"[photon-cli] ", // prefix for each log statement
-			log.LstdFlags)   // standard flags. prints date and time for each log statement
-	}
-
-	return nil
-}
-
-func CleanupLogging() error {
-	// Close the logging file if it was created
-	// for Verbose logging
-	if logFile != nil {
-		err := logFile.Close()
-		if err != nil {
-			fmt.Println(err)
-		}
-		logFile = nil
-		logger = nil
-	}
-	return nil
-}
-
 func updateToken(newToken string) {
-	config, err := cf.LoadConfig()
-	if err != nil {
-		fmt.Printf("Could not load current config in order to update token: %s", err)
-		return
-	}
-	config.Token = newToken
-	err = cf.SaveConfig(config)
-	if err != nil {
-		fmt.Printf("Could not save new config with refreshed token: %s", err)
-		return
-	}
-	if logger != nil {
-		logger.Printf("Access token has been refreshed\n")
-	}
+	saveTokens(newToken, "", nil)
 }
@@ -10,8 +10,17 @@
 package client
 
 import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"regexp"
 	"testing"
+	"time"
+
+	"github.com/vmware/photon-controller-go-sdk/photon"
 
 	cf "github.com/vmware/photon-controller-cli/photon/configuration"
 )
@@ -52,6 +61,71 @@ func TestGet(t *testing.T) {
 	}
 }
 
+func TestNewClientWithExpiredToken(t *testing.T) {
+	var authorization string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		fmt.Fprint(w, `{"fullVersion":"1.2.0"}`)
+	}))
+	defer ts.Close()
+
+	token := testToken(time.Now().Add(-time.Hour))
+	config := &cf.Configuration{CloudTarget: ts.URL, Token: token, TokenWarning: "0"}
+
+	// Without a refresh token the expired token cannot be renewed, the server gets to reject it
+	api, err := NewClient(config)
+	if err != nil {
+		t.Fatal("Not expecting error creating a client with an expired token: ", err)
+	}
+	_, err = api.Info.Get()
+	if err != nil || authorization != "Bearer "+token {
+		t.Errorf("Expecting the expired token to be sent, got %q: %v", authorization, err)
+	}
+
+	// Logging in does not use the token that is about to be replaced
+	api, err = NewLoginClient(config)
+	if err != nil {
+		t.Fatal("Not expecting error creating a client to log in with: ", err)
+	}
+	_, err = api.Info.Get()
+	if err != nil || len(authorization) != 0 {
+		t.Errorf("Expecting no token to be sent, got %q: %v", authorization, err)
+	}
+}
+
+func TestTokenExpiryWarning(t *testing.T) {
+	config := &cf.Configuration{}
+	cases := []struct {
+		expires     time.Duration
+		refresh     string
+		renewFailed bool
+		expected    string
+	}{
+		{5 * time.Minute, "refresh-token", false, "expires in \\d+m\\d+s, .* It will be renewed when it expires"},
+		{5 * time.Minute, "", false, "expires in \\d+m\\d+s, .* Run 'photon target login'"},
+		{-12 * time.Minute, "refresh-token", true, "has expired, .* It could not be renewed, run 'photon target login'"},
+		{time.Hour, "", false, "^$"},
+	}
+	for _, c := range cases {
+		tokens := &photon.TokenOptions{AccessToken: testToken(time.Now().Add(c.expires)), RefreshToken: c.refresh}
+		var buf bytes.Buffer
+		err := warnIfTokenExpiring(&buf, tokens, config, c.renewFailed)
+		if err != nil {
+			t.Error("Not expecting error warning about the token: ", err)
+		}
+		if !regexp.MustCompile(c.expected).MatchString(buf.String()) {
+			t.Errorf("Expecting a warning matching %q, got %q", c.expected, buf.String())
+		}
+	}
+}
+
+// Returns an unsigned JSON web token expiring at the given time
+func testToken(expires time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString(
+		[]byte(fmt.Sprintf(`{"sub":"user@tenant","exp":%d}`, expires.Unix())))
+	return base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256"}`)) + "." + payload + ".signature"
+}
+
 func TestLoggingFunctions(t *testing.T) {
 	defer loggingTestCleanup(test_log_file, t)
 	err := InitializeLogging(test_log_file)
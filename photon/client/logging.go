@@ -0,0 +1,236 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Severity of a log message, messages below the level in use are dropped
+type LogLevel int
+
+const (
+	DebugLevel LogLevel = iota
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+)
+
+var logLevelNames = []string{"debug", "info", "warn", "error"}
+
+func (level LogLevel) String() string {
+	if level < DebugLevel || level > ErrorLevel {
+		return fmt.Sprintf("level(%d)", int(level))
+	}
+	return logLevelNames[level]
+}
+
+// Parses the value of the --log-level flag
+func ParseLogLevel(name string) (LogLevel, error) {
+	for i, levelName := range logLevelNames {
+		if strings.EqualFold(name, levelName) {
+			return LogLevel(i), nil
+		}
+	}
+	return InfoLevel, fmt.Errorf("Invalid log level '%s', expecting debug, info, warn or error", name)
+}
+
+// Set from the global flags before InitializeLogging is called. With TraceHTTP every API
+// request is logged at debug level with its latency and bodies.
+var (
+	Level      = InfoLevel
+	TraceHTTP  bool
+	LogMaxSize int64 = 10 * 1024 * 1024
+)
+
+// Number of rotated log files kept next to the log file, as <file>.1 to <file>.<n>
+const logMaxBackups = 5
+
+var logger *Logger = nil
+var logFile *rotatingFile = nil
+
+// Writes log messages as lines of JSON, e.g.
+// {"time":"2017-06-12T09:41:07.123Z","level":"info","msg":"Access token has been refreshed"}
+type Logger struct {
+	out   io.Writer
+	level LogLevel
+	mutex sync.Mutex
+}
+
+// Extra fields of a log message, written after time, level and msg in the order of their names
+type LogFields map[string]interface{}
+
+func (l *Logger) Debug(msg string, fields LogFields) { l.log(DebugLevel, msg, fields) }
+func (l *Logger) Info(msg string, fields LogFields)  { l.log(InfoLevel, msg, fields) }
+func (l *Logger) Warn(msg string, fields LogFields)  { l.log(WarnLevel, msg, fields) }
+func (l *Logger) Error(msg string, fields LogFields) { l.log(ErrorLevel, msg, fields) }
+
+// Tells whether messages of the level are written, to skip building costly fields
+func (l *Logger) Enabled(level LogLevel) bool {
+	return l != nil && level >= l.level
+}
+
+func (l *Logger) log(level LogLevel, msg string, fields LogFields) {
+	if !l.Enabled(level) {
+		return
+	}
+	var line bytes.Buffer
+	line.WriteString(`{"time":`)
+	writeJSONValue(&line, time.Now().UTC().Format("2006-01-02T15:04:05.000Z07:00"))
+	line.WriteString(`,"level":`)
+	writeJSONValue(&line, level.String())
+	line.WriteString(`,"msg":`)
+	writeJSONValue(&line, msg)
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		line.WriteString(",")
+		writeJSONValue(&line, name)
+		line.WriteString(":")
+		writeJSONValue(&line, fields[name])
+	}
+	line.WriteString("}\n")
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	_, _ = l.out.Write(line.Bytes())
+}
+
+func writeJSONValue(buf *bytes.Buffer, value interface{}) {
+	if err, ok := value.(error); ok {
+		value = err.Error()
+	}
+	data, err := marshalJSON(value)
+	if err != nil {
+		data, _ = marshalJSON(fmt.Sprint(value))
+	}
+	buf.Write(data)
+}
+
+// Like json.Marshal, without escaping <, > and & so that logs stay readable
+func marshalJSON(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	err := encoder.Encode(value)
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), err
+}
+
+// Starts logging to the file, rotated when it grows beyond LogMaxSize, or to stderr
+// when no file is given
+func InitializeLogging(logFileName string) error {
+	var output io.Writer = os.Stderr
+	if logFileName != "" {
+		file, err := openRotatingFile(logFileName, LogMaxSize, logMaxBackups)
+		if err != nil {
+			return fmt.Errorf("Could not open log file: %v", err)
+		}
+		logFile = file
+		output = file
+	}
+	level := Level
+	if TraceHTTP && level > DebugLevel {
+		level = DebugLevel
+	}
+	logger = &Logger{out: output, level: level}
+	return nil
+}
+
+func CleanupLogging() error {
+	// Close the logging file if it was created
+	// for Verbose logging
+	if logFile != nil {
+		err := logFile.Close()
+		if err != nil {
+			fmt.Println(err)
+		}
+		logFile = nil
+	}
+	logger = nil
+	return nil
+}
+
+// A log file renamed to <file>.1 once it reaches its maximum size, older ones being
+// shifted to <file>.2 and so on. A maximum size of 0 disables rotation.
+type rotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func openRotatingFile(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	f := &rotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	return f, f.open()
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		err := f.rotate()
+		if err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *rotatingFile) rotate() error {
+	err := f.file.Close()
+	if err != nil {
+		return err
+	}
+	_ = os.Remove(fmt.Sprintf("%s.%d", f.path, f.maxBackups))
+	for i := f.maxBackups - 1; i > 0; i-- {
+		_ = os.Rename(fmt.Sprintf("%s.%d", f.path, i), fmt.Sprintf("%s.%d", f.path, i+1))
+	}
+	if f.maxBackups > 0 {
+		err = os.Rename(f.path, f.path+".1")
+	} else {
+		err = os.Remove(f.path)
+	}
+	if err != nil {
+		return err
+	}
+	return f.open()
+}
+
+func (f *rotatingFile) Close() error {
+	return f.file.Close()
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestLogLevels(t *testing.T) {
+	var out bytes.Buffer
+	l := &Logger{out: &out, level: WarnLevel}
+	l.Info("Not written", nil)
+	l.Warn("Request failed", LogFields{"status": 500, "error": errors.New("boom")})
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Expecting only the warning to be logged, got %q", out.String())
+	}
+	var entry map[string]interface{}
+	err := json.Unmarshal([]byte(lines[0]), &entry)
+	if err != nil {
+		t.Error("Not expecting error parsing log line: ", err)
+	}
+	if entry["level"] != "warn" || entry["msg"] != "Request failed" || entry["status"] != 500.0 || entry["error"] != "boom" {
+		t.Errorf("Unexpected log line %s", lines[0])
+	}
+	if !strings.HasPrefix(lines[0], `{"time":`) {
+		t.Errorf("Expecting the time first in the log line, got %s", lines[0])
+	}
+
+	_, err = ParseLogLevel("verbose")
+	if err == nil {
+		t.Error("Expecting error parsing an unknown log level")
+	}
+
+	// Logging without a logger does nothing
+	var none *Logger
+	none.Error("Not written", nil)
+}
+
+func TestLogRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log-test-")
+	if err != nil {
+		t.Error("Not expecting error creating log directory")
+	}
+	defer os.RemoveAll(dir)
+	logPath := path.Join(dir, "photon.log")
+
+	file, err := openRotatingFile(logPath, 100, 2)
+	if err != nil {
+		t.Error("Not expecting error opening log file: ", err)
+	}
+	line := []byte(strings.Repeat("x", 59) + "\n")
+	for i := 0; i < 4; i++ {
+		_, err = file.Write(line)
+		if err != nil {
+			t.Error("Not expecting error writing log file: ", err)
+		}
+	}
+	_ = file.Close()
+
+	// Each file holds one line, the oldest one was dropped
+	for _, name := range []string{logPath, logPath + ".1", logPath + ".2"} {
+		data, err := ioutil.ReadFile(name)
+		if err != nil || !bytes.Equal(data, line) {
+			t.Errorf("Expecting one line in %s, got %q, %v", name, data, err)
+		}
+	}
+	_, err = os.Stat(logPath + ".3")
+	if !os.IsNotExist(err) {
+		t.Error("Expecting no more than 2 rotated log files")
+	}
+}
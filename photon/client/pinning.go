@@ -0,0 +1,55 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+
+	cf "github.com/vmware/photon-controller-cli/photon/configuration"
+)
+
+// Returned when a server presents another certificate than the one pinned for it
+type CertificateChangedError struct {
+	Host        string
+	Fingerprint string
+}
+
+func (e CertificateChangedError) Error() string {
+	return fmt.Sprintf("The certificate presented by %s has changed (SHA-256 %s) and is not trusted.\n"+
+		"If the change is expected, trust the new certificate with 'photon target cert add %s'",
+		e.Host, e.Fingerprint, e.Host)
+}
+
+// Returns a TLS configuration that only accepts the certificates pinned for the host.
+// The chain is not verified against any CA: the fingerprint of the server certificate is.
+func pinnedTLSConfig(host string, pins []string) *tls.Config {
+	return &tls.Config{
+		InsecureSkipVerify:    true,
+		VerifyPeerCertificate: verifyPinnedCert(host, pins),
+	}
+}
+
+func verifyPinnedCert(host string, pins []string) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return fmt.Errorf("%s presented no certificate", host)
+		}
+		cert, err := x509.ParseCertificate(rawCerts[0])
+		if err != nil {
+			return err
+		}
+		if !cf.IsCertPinned(pins, cert) {
+			return CertificateChangedError{Host: host, Fingerprint: cf.CertFingerprint(cert)}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	cf "github.com/vmware/photon-controller-cli/photon/configuration"
+)
+
+func TestPinnedTLSConfig(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "Hello, client")
+	}))
+	defer ts.Close()
+
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Error("Failed to parse URL")
+	}
+	host := cf.PinnedHost(ts.URL)
+	if host != u.Host {
+		t.Errorf("Expecting pinned host %s, got %s", u.Host, host)
+	}
+
+	// The pinned certificate is accepted although no CA vouches for it
+	pins := []string{cf.CertFingerprint(ts.Certificate())}
+	resp, err := newPinnedTestClient(host, pins).Get(ts.URL)
+	if err != nil {
+		t.Error("Not expecting error connecting with the pinned certificate: ", err)
+	} else {
+		_ = resp.Body.Close()
+	}
+
+	// Any other certificate is reported as changed
+	pins = []string{strings.Repeat("00:", 31) + "00"}
+	_, err = newPinnedTestClient(host, pins).Get(ts.URL)
+	if err == nil || !strings.Contains(err.Error(), "has changed") {
+		t.Errorf("Expecting the certificate to be reported as changed, got %v", err)
+	}
+}
+
+func newPinnedTestClient(host string, pins []string) *http.Client {
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: pinnedTLSConfig(host, pins)}}
+}
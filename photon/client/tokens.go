@@ -0,0 +1,178 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package client
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/vmware/photon-controller-go-sdk/photon"
+	"github.com/vmware/photon-controller-go-sdk/photon/lightwave"
+	"golang.org/x/crypto/ssh/terminal"
+
+	cf "github.com/vmware/photon-controller-cli/photon/configuration"
+)
+
+// Access tokens expiring within this margin are renewed before running a command
+const tokenRenewalMargin = time.Minute
+
+// Commands warn on stderr when the access token expires within this duration, unless
+// the TokenWarning setting says otherwise
+const defaultTokenWarning = 10 * time.Minute
+
+// Whether the user can be asked to log in again when the tokens cannot be refreshed.
+// Set by GetClient; the user is asked at most once per command.
+var (
+	canPromptForLogin bool
+	loginPrompted     bool
+)
+
+// Returns the expiry time in the exp claim of a JSON web token, if there is one
+func TokenExpiry(token string) (time.Time, bool) {
+	jwtToken := lightwave.ParseTokenDetails(token)
+	if jwtToken.Expires == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(jwtToken.Expires, 0), true
+}
+
+// Renews the access token up front if it has expired or is about to, and tells if that
+// failed. The token is then used as it is: the transport renews it, or reports why it
+// could not, when the server rejects it.
+func renewExpiringToken(api *photon.Client, tokens *photon.TokenOptions, login *cf.LoginConfiguration) bool {
+	expires, ok := TokenExpiry(tokens.AccessToken)
+	if !ok || expires.Sub(time.Now()) > tokenRenewalMargin {
+		return false
+	}
+	_, err := renewTokens(api, tokens, login)
+	return err != nil
+}
+
+// Warns when the access token has expired or expires soon, so that long running scripts
+// know before they lose access. renewFailed tells that renewing the token was just tried
+// and failed.
+func warnIfTokenExpiring(w io.Writer, tokens *photon.TokenOptions, config *cf.Configuration, renewFailed bool) error {
+	warning := defaultTokenWarning
+	if len(config.TokenWarning) != 0 {
+		var err error
+		warning, err = time.ParseDuration(config.TokenWarning)
+		if err != nil {
+			return fmt.Errorf("Invalid TokenWarning in configuration: %s", err)
+		}
+	}
+	expires, ok := TokenExpiry(tokens.AccessToken)
+	remaining := expires.Sub(time.Now())
+	if !ok || warning <= 0 || remaining > warning {
+		return nil
+	}
+
+	renewal := "Run 'photon target login' to get a new one."
+	if renewFailed {
+		renewal = "It could not be renewed, run 'photon target login' to get a new one."
+	} else if len(tokens.RefreshToken) != 0 || (config.Login != nil && config.Login.Flow == cf.ClientCredentialsLogin) {
+		renewal = "It will be renewed when it expires."
+	}
+	if remaining <= 0 {
+		fmt.Fprintf(w, "Warning: the access token has expired, at %s. %s\n", expires.Format("15:04:05"), renewal)
+	} else {
+		fmt.Fprintf(w, "Warning: the access token expires in %s, at %s. %s\n",
+			(remaining/time.Second)*time.Second, expires.Format("15:04:05"), renewal)
+	}
+	return nil
+}
+
+// Gets new tokens the way they were obtained: with the client credentials for automation
+// accounts, otherwise with the refresh token or, failing that, by asking the user to log
+// in again. The new tokens are saved and replace the ones in use.
+func renewTokens(api *photon.Client, tokens *photon.TokenOptions, login *cf.LoginConfiguration) (string, error) {
+	var renewed *photon.TokenOptions
+	err := errors.New("no refresh token available")
+	if login != nil && login.Flow == cf.ClientCredentialsLogin {
+		renewed, err = LoginWithClientCredentials(api, login)
+	} else if len(tokens.RefreshToken) != 0 {
+		renewed, err = api.Auth.GetTokensByRefreshToken(tokens.RefreshToken)
+	}
+	if err != nil {
+		logger.Warn("Could not renew the access token", LogFields{"error": err})
+	}
+	if err != nil && canPromptForLogin && !loginPrompted {
+		loginPrompted = true
+		renewed, err = promptForLogin(api)
+		login = &cf.LoginConfiguration{Flow: cf.PasswordLogin}
+	}
+	if err != nil {
+		return "", fmt.Errorf("The access token has expired and could not be renewed: %v\n"+
+			"Run 'photon target login' to log in again", err)
+	}
+
+	tokens.AccessToken = renewed.AccessToken
+	if len(renewed.RefreshToken) != 0 {
+		tokens.RefreshToken = renewed.RefreshToken
+	}
+	saveTokens(tokens.AccessToken, tokens.RefreshToken, login)
+	return tokens.AccessToken, nil
+}
+
+// Gets tokens for an automation account, reading its secret from the file of the login
+// configuration
+func LoginWithClientCredentials(api *photon.Client, login *cf.LoginConfiguration) (*photon.TokenOptions, error) {
+	secret, err := ioutil.ReadFile(login.ClientSecretFile)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read the client secret: %v", err)
+	}
+	return api.Auth.GetClientTokensByPassword(login.Username, strings.TrimSpace(string(secret)), login.ClientID)
+}
+
+func promptForLogin(api *photon.Client) (*photon.TokenOptions, error) {
+	fmt.Fprintf(os.Stderr, "Your login has expired, please log in again.\nUser name (username@tenant): ")
+	username, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(os.Stderr, "Password: ")
+	// Casting syscall.Stdin to int because during
+	// Windows cross-compilation syscall.Stdin is incorrectly
+	// treated as a String.
+	password, err := terminal.ReadPassword(int(syscall.Stdin))
+	fmt.Fprintf(os.Stderr, "\n")
+	if err != nil {
+		return nil, err
+	}
+	return api.Auth.GetTokensByPassword(strings.TrimSpace(username), string(password))
+}
+
+// Saves new tokens, and how they were obtained unless login is nil
+func saveTokens(accessToken string, refreshToken string, login *cf.LoginConfiguration) {
+	config, err := cf.LoadConfig()
+	if err != nil {
+		fmt.Printf("Could not load current config in order to update token: %s", err)
+		return
+	}
+	config.Token = accessToken
+	if len(refreshToken) != 0 {
+		config.RefreshToken = refreshToken
+	}
+	if login != nil {
+		config.Login = login
+	}
+	err = cf.SaveConfig(config)
+	if err != nil {
+		fmt.Printf("Could not save new config with refreshed token: %s", err)
+		return
+	}
+	logger.Info("Access token has been refreshed", nil)
+}
@@ -0,0 +1,240 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vmware/photon-controller-go-sdk/photon"
+)
+
+// Sends the requests of the SDK. When the server reports that the access token expired,
+// the tokens are renewed and the request is sent again, once.
+type apiTransport struct {
+	base http.RoundTripper
+	// Renews the tokens and returns the new access token
+	renew func() (string, error)
+	// The access token in use, shared with the SDK
+	tokens *photon.TokenOptions
+	mutex  sync.Mutex
+}
+
+func (t *apiTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.send(req)
+	if err != nil || res.StatusCode != http.StatusUnauthorized || t.renew == nil {
+		return res, err
+	}
+	sentToken := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if len(sentToken) == 0 || (req.Body != nil && req.GetBody == nil) {
+		// Nothing to renew, or the request cannot be sent again: the SDK handles it
+		return res, nil
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+	var apiError photon.ApiError
+	if json.Unmarshal(body, &apiError) != nil || apiError.Code != "ExpiredAuthToken" {
+		return res, nil
+	}
+
+	token, err := t.renewOnce(sentToken)
+	if err != nil {
+		return nil, err
+	}
+
+	retry := new(http.Request)
+	*retry = *req
+	retry.Header = http.Header{}
+	for key, values := range req.Header {
+		retry.Header[key] = values
+	}
+	retry.Header.Set("Authorization", "Bearer "+token)
+	if req.GetBody != nil {
+		retry.Body, err = req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return t.send(retry)
+}
+
+// Renews the tokens unless another request already did since the given token was sent
+func (t *apiTransport) renewOnce(sentToken string) (string, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.tokens != nil && t.tokens.AccessToken != sentToken {
+		return t.tokens.AccessToken, nil
+	}
+	return t.renew()
+}
+
+// Sends a request and logs it, with its bodies and latency when tracing HTTP
+func (t *apiTransport) send(req *http.Request) (*http.Response, error) {
+	trace := TraceHTTP && logger.Enabled(DebugLevel)
+	var requestBody string
+	if trace {
+		requestBody = traceRequestBody(req)
+	}
+	start := time.Now()
+	res, err := t.base.RoundTrip(req)
+	latency := time.Since(start)
+
+	fields := LogFields{"method": req.Method, "url": req.URL.String()}
+	if err != nil {
+		fields["error"] = err
+		logger.Error("API request failed", fields)
+		return res, err
+	}
+	fields["status"] = res.StatusCode
+	fields["requestId"] = res.Header.Get("request-id")
+	if !trace {
+		logger.Info("API request", fields)
+		return res, err
+	}
+	fields["latencyMs"] = latency.Nanoseconds() / int64(time.Millisecond)
+	if len(requestBody) != 0 {
+		fields["requestBody"] = requestBody
+	}
+	responseBody, err := traceResponseBody(res)
+	if err != nil {
+		return nil, err
+	}
+	if len(responseBody) != 0 {
+		fields["responseBody"] = responseBody
+	}
+	logger.Debug("API request", fields)
+	return res, nil
+}
+
+// Bodies larger than this, or not JSON, form or text, are only described in traces
+const maxTracedBody = 64 * 1024
+
+// Fields of JSON and form bodies whose values are left out of traces, and what replaces them
+var secretFields = []string{"password", "secret", "token"}
+
+const redactedValue = "<redacted>"
+
+func traceRequestBody(req *http.Request) string {
+	if req.Body == nil || req.GetBody == nil {
+		return ""
+	}
+	contentType := req.Header.Get("Content-Type")
+	if !isTraceable(contentType, req.ContentLength) {
+		return describeBody(contentType, req.ContentLength)
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return ""
+	}
+	data, err := ioutil.ReadAll(body)
+	_ = body.Close()
+	if err != nil {
+		return ""
+	}
+	return redactBody(contentType, data)
+}
+
+// Reads the response body for the trace and puts it back for the SDK
+func traceResponseBody(res *http.Response) (string, error) {
+	contentType := res.Header.Get("Content-Type")
+	if res.Body == nil || !isTraceable(contentType, res.ContentLength) {
+		return describeBody(contentType, res.ContentLength), nil
+	}
+	data, err := ioutil.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if err != nil {
+		return "", err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(data))
+	if len(data) > maxTracedBody {
+		return describeBody(contentType, int64(len(data))), nil
+	}
+	return redactBody(contentType, data), nil
+}
+
+func isTraceable(contentType string, length int64) bool {
+	if length > maxTracedBody {
+		return false
+	}
+	return len(contentType) == 0 || strings.Contains(contentType, "json") ||
+		strings.HasPrefix(contentType, "application/x-www-form-urlencoded") ||
+		strings.HasPrefix(contentType, "text/")
+}
+
+func describeBody(contentType string, length int64) string {
+	if length <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("<%d bytes of %s>", length, contentType)
+}
+
+// Replaces the values of secret fields in JSON and form bodies
+func redactBody(contentType string, data []byte) string {
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		values, err := url.ParseQuery(string(data))
+		if err == nil {
+			for name := range values {
+				if isSecretField(name) {
+					values.Set(name, redactedValue)
+				}
+			}
+			return values.Encode()
+		}
+	}
+	var value interface{}
+	if json.Unmarshal(data, &value) != nil {
+		return string(data)
+	}
+	redacted, err := marshalJSON(redactJSON(value))
+	if err != nil {
+		return string(data)
+	}
+	return string(redacted)
+}
+
+func redactJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for name, field := range v {
+			if isSecretField(name) {
+				v[name] = redactedValue
+			} else {
+				v[name] = redactJSON(field)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactJSON(item)
+		}
+	}
+	return value
+}
+
+func isSecretField(name string) bool {
+	name = strings.ToLower(name)
+	for _, secret := range secretFields {
+		if strings.Contains(name, secret) {
+			return true
+		}
+	}
+	return false
+}
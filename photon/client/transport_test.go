@@ -0,0 +1,146 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package client
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vmware/photon-controller-go-sdk/photon"
+)
+
+func TestExpiredTokenRenewal(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer new-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"code":"ExpiredAuthToken","message":"Token expired"}`)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		fmt.Fprint(w, string(body))
+	}))
+	defer ts.Close()
+
+	renewals := 0
+	tokens := &photon.TokenOptions{AccessToken: "old-token"}
+	transport := &apiTransport{base: http.DefaultTransport, tokens: tokens}
+	transport.renew = func() (string, error) {
+		renewals++
+		tokens.AccessToken = "new-token"
+		return tokens.AccessToken, nil
+	}
+
+	// The request is sent again, body included, with the new token
+	req, _ := http.NewRequest("POST", ts.URL, strings.NewReader("payload"))
+	req.Header.Set("Authorization", "Bearer old-token")
+	res, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Error("Not expecting error sending request: ", err)
+	} else {
+		body, _ := ioutil.ReadAll(res.Body)
+		_ = res.Body.Close()
+		if res.StatusCode != http.StatusOK || string(body) != "payload" {
+			t.Errorf("Expecting the request to be sent again, got %s: %s", res.Status, body)
+		}
+	}
+
+	// Another request sent with the old token does not renew it again
+	req, _ = http.NewRequest("GET", ts.URL, nil)
+	req.Header.Set("Authorization", "Bearer old-token")
+	res, err = transport.RoundTrip(req)
+	if err != nil || res.StatusCode != http.StatusOK {
+		t.Errorf("Expecting the renewed token to be used, got %v", err)
+	}
+	if renewals != 1 {
+		t.Errorf("Expecting the tokens to be renewed once, got %d renewals", renewals)
+	}
+
+	transport.renew = func() (string, error) {
+		return "", errors.New("refresh token expired")
+	}
+	tokens.AccessToken = "stale-token"
+	req, _ = http.NewRequest("GET", ts.URL, nil)
+	req.Header.Set("Authorization", "Bearer stale-token")
+	_, err = transport.RoundTrip(req)
+	if err == nil {
+		t.Error("Expecting error when the tokens cannot be renewed")
+	}
+}
+
+func TestTokenExpiry(t *testing.T) {
+	expires := time.Now().Add(30 * time.Second).Unix()
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"sub":"user@tenant","exp":%d}`, expires)))
+	token := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256"}`)) + "." + payload + ".signature"
+
+	expiry, ok := TokenExpiry(token)
+	if !ok || expiry.Unix() != expires {
+		t.Errorf("Expecting the token to expire at %d, got %v", expires, expiry)
+	}
+
+	_, ok = TokenExpiry("fake-token")
+	if ok {
+		t.Error("Expecting no expiry for a token that is not a JSON web token")
+	}
+}
+
+func TestTraceHTTP(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("request-id", "request-1")
+		fmt.Fprint(w, `{"id":"host-1","credentials":{"password":"host-password"}}`)
+	}))
+	defer ts.Close()
+
+	var out bytes.Buffer
+	logger = &Logger{out: &out, level: DebugLevel}
+	TraceHTTP = true
+	defer func() {
+		logger = nil
+		TraceHTTP = false
+	}()
+
+	transport := &apiTransport{base: http.DefaultTransport}
+	req, _ := http.NewRequest("POST", ts.URL+"/hosts", strings.NewReader(`{"username":"root","password":"secret"}`))
+	req.Header.Set("Content-Type", "application/json")
+	res, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatal("Not expecting error sending request: ", err)
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if !strings.Contains(string(body), "host-password") {
+		t.Errorf("Expecting the response body to be left intact, got %s", body)
+	}
+
+	var entry map[string]interface{}
+	err = json.Unmarshal(out.Bytes(), &entry)
+	if err != nil {
+		t.Fatal("Not expecting error parsing trace: ", err)
+	}
+	if entry["method"] != "POST" || entry["status"] != 200.0 || entry["requestId"] != "request-1" {
+		t.Errorf("Unexpected trace %s", out.String())
+	}
+	if _, ok := entry["latencyMs"]; !ok {
+		t.Errorf("Expecting the latency in the trace, got %s", out.String())
+	}
+	if entry["requestBody"] != `{"password":"<redacted>","username":"root"}` ||
+		strings.Contains(out.String(), "host-password") {
+		t.Errorf("Expecting passwords to be redacted, got %s", out.String())
+	}
+}
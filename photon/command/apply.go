@@ -0,0 +1,556 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package command
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli"
+	"github.com/vmware/photon-controller-go-sdk/photon"
+
+	"github.com/vmware/photon-controller-cli/photon/client"
+	"github.com/vmware/photon-controller-cli/photon/manifest"
+)
+
+// Creates a cli.Command for apply
+// Usage: apply --file <manifest> [--dry-run]
+func GetApplyCommand() cli.Command {
+	command := cli.Command{
+		Name:      "apply",
+		Usage:     "Create or update tenants, projects, flavors and zones from a manifest",
+		ArgsUsage: " ",
+		Description: "Reads a YAML manifest and changes the deployment to match it. Entities that are missing\n" +
+			"   are created, security groups and IAM policies that differ are replaced, and quota limits\n" +
+			"   that differ are updated. Nothing is deleted, and settings left out of the manifest,\n" +
+			"   including quota keys it does not list, are not changed.\n" +
+			"   Flavors cannot be updated, so a flavor whose cost differs is only reported, and so is\n" +
+			"   a missing host. 'photon export' writes a manifest of an existing deployment.\n\n" +
+			"   Example manifest:\n" +
+			"     zones:\n" +
+			"     - name: zone1\n" +
+			"     flavors:\n" +
+			"     - name: small\n" +
+			"       kind: vm\n" +
+			"       cost:\n" +
+			"       - {key: vm.cpu, value: 1, unit: COUNT}\n" +
+			"       - {key: vm.memory, value: 2, unit: GB}\n" +
+			"     tenants:\n" +
+			"     - name: cloud-dev\n" +
+			"       security_groups: [admins]\n" +
+			"       quota:\n" +
+			"         vm.count: {limit: 100, unit: COUNT}\n" +
+			"       projects:\n" +
+			"       - name: web\n" +
+			"         quota:\n" +
+			"           vm.count: {limit: 10, unit: COUNT}\n" +
+			"         iam:\n" +
+			"         - principal: dev@example.com\n" +
+			"           roles: [contributor]\n\n" +
+			"   Example:\n" +
+			"     photon apply -f env.yaml --dry-run",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "file, f",
+				Usage: "manifest describing the tenants, projects, flavors and zones",
+			},
+			cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "print the changes without making them",
+			},
+		},
+		Action: func(c *cli.Context) {
+			err := applyEnvironment(c, os.Stdout)
+			if err != nil {
+				log.Fatal("Error: ", err)
+			}
+		},
+	}
+	return command
+}
+
+// A single change needed to bring the deployment in line with the manifest.
+// run returns the ID of the entity it created or changed.
+type applyStep struct {
+	description string
+	run         func() (string, error)
+}
+
+// The changes needed, plus differences that cannot be fixed
+type applyPlan struct {
+	steps    []applyStep
+	warnings []string
+}
+
+func (plan *applyPlan) add(description string, run func() (string, error)) {
+	plan.steps = append(plan.steps, applyStep{description: description, run: run})
+}
+
+func applyEnvironment(c *cli.Context, w io.Writer) error {
+	err := checkArgCount(c, 0)
+	if err != nil {
+		return err
+	}
+	file := c.String("file")
+	if len(file) == 0 {
+		return fmt.Errorf("Please provide a manifest with --file")
+	}
+	if isAsync(c) {
+		return fmt.Errorf("apply waits for each step before starting the next one and cannot be used with --async")
+	}
+
+	env, err := manifest.LoadEnvironment(file)
+	if err != nil {
+		return err
+	}
+
+	client.Photonclient, err = client.GetClient(c)
+	if err != nil {
+		return err
+	}
+
+	plan, err := planEnvironment(env, c)
+	if err != nil {
+		return err
+	}
+
+	for _, warning := range plan.warnings {
+		fmt.Fprintf(w, "Warning: %s\n", warning)
+	}
+	if len(plan.steps) == 0 {
+		fmt.Fprintf(w, "Deployment already matches the manifest\n")
+		return nil
+	}
+
+	fmt.Fprintf(w, "Changes:\n")
+	for _, step := range plan.steps {
+		fmt.Fprintf(w, "  %s\n", step.description)
+	}
+	if c.Bool("dry-run") {
+		return nil
+	}
+
+	if !confirmed(c) {
+		fmt.Fprintf(w, "OK. Canceled\n")
+		return nil
+	}
+	for _, step := range plan.steps {
+		fmt.Fprintf(w, "%s\n", strings.ToUpper(step.description[:1])+step.description[1:])
+		_, err = step.run()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Compares the manifest with the live deployment and returns the changes needed
+func planEnvironment(env *manifest.Environment, c *cli.Context) (*applyPlan, error) {
+	plan := &applyPlan{}
+
+	err := planZones(plan, env.Zones, c)
+	if err != nil {
+		return nil, err
+	}
+
+	err = planFlavors(plan, env.Flavors, c)
+	if err != nil {
+		return nil, err
+	}
+
+	err = planHosts(plan, env.Hosts)
+	if err != nil {
+		return nil, err
+	}
+
+	tenants, err := client.Photonclient.Tenants.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, tenant := range env.Tenants {
+		err = planTenant(plan, tenant, tenants.Items, c)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return plan, nil
+}
+
+// Hosts need credentials to be added, so missing ones are only reported
+func planHosts(plan *applyPlan, hosts []manifest.Host) error {
+	if len(hosts) == 0 {
+		return nil
+	}
+	liveHosts, err := client.Photonclient.InfraHosts.GetHosts()
+	if err != nil {
+		return err
+	}
+
+	for _, host := range hosts {
+		found := false
+		for _, liveHost := range liveHosts.Items {
+			if liveHost.Address == host.Address {
+				found = true
+				break
+			}
+		}
+		if !found {
+			plan.warnings = append(plan.warnings, fmt.Sprintf(
+				"host '%s' is not part of the deployment, add it with 'system add-hosts'", host.Address))
+		}
+	}
+	return nil
+}
+
+func planZones(plan *applyPlan, zones []manifest.Zone, c *cli.Context) error {
+	if len(zones) == 0 {
+		return nil
+	}
+	liveZones, err := client.Photonclient.Zones.GetAll()
+	if err != nil {
+		return err
+	}
+
+	for _, zone := range zones {
+		found := false
+		for _, liveZone := range liveZones.Items {
+			if liveZone.Name == zone.Name {
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+
+		spec := &photon.ZoneCreateSpec{Name: zone.Name}
+		plan.add(fmt.Sprintf("create zone '%s'", zone.Name), func() (string, error) {
+			task, err := client.Photonclient.Zones.Create(spec)
+			if err != nil {
+				return "", err
+			}
+			return waitOnTaskOperation(task.ID, c)
+		})
+	}
+	return nil
+}
+
+func planFlavors(plan *applyPlan, flavors []manifest.Flavor, c *cli.Context) error {
+	if len(flavors) == 0 {
+		return nil
+	}
+	liveFlavors, err := client.Photonclient.Flavors.GetAll(&photon.FlavorGetOptions{})
+	if err != nil {
+		return err
+	}
+
+	for _, flavor := range flavors {
+		var liveFlavor *photon.Flavor
+		for i := range liveFlavors.Items {
+			if liveFlavors.Items[i].Name == flavor.Name && liveFlavors.Items[i].Kind == flavor.Kind {
+				liveFlavor = &liveFlavors.Items[i]
+				break
+			}
+		}
+
+		if liveFlavor != nil {
+			if !costEqual(flavor.Cost, liveFlavor.Cost) {
+				plan.warnings = append(plan.warnings, fmt.Sprintf(
+					"cost of %s flavor '%s' differs from the manifest, flavors cannot be updated",
+					flavor.Kind, flavor.Name))
+			}
+			continue
+		}
+
+		spec := &photon.FlavorCreateSpec{Name: flavor.Name, Kind: flavor.Kind}
+		for _, item := range flavor.Cost {
+			spec.Cost = append(spec.Cost, photon.QuotaLineItem{Key: item.Key, Value: item.Value, Unit: item.Unit})
+		}
+		plan.add(fmt.Sprintf("create %s flavor '%s'", flavor.Kind, flavor.Name), func() (string, error) {
+			task, err := client.Photonclient.Flavors.Create(spec)
+			if err != nil {
+				return "", err
+			}
+			return waitOnTaskOperation(task.ID, c)
+		})
+	}
+	return nil
+}
+
+func planTenant(plan *applyPlan, tenant manifest.Tenant, liveTenants []photon.Tenant, c *cli.Context) error {
+	var liveTenant *photon.Tenant
+	for i := range liveTenants {
+		if liveTenants[i].Name == tenant.Name {
+			liveTenant = &liveTenants[i]
+			break
+		}
+	}
+
+	// Projects of a tenant created by this plan only learn the tenant ID when it runs
+	tenantID := new(string)
+	name := fmt.Sprintf("tenant '%s'", tenant.Name)
+	if liveTenant == nil {
+		spec := &photon.TenantCreateSpec{
+			Name:           tenant.Name,
+			SecurityGroups: tenant.SecurityGroups,
+			ResourceQuota:  photon.Quota{QuotaLineItems: quotaSpecFromManifest(tenant.Quota)},
+		}
+		plan.add("create "+name, func() (string, error) {
+			task, err := client.Photonclient.Tenants.Create(spec)
+			if err != nil {
+				return "", err
+			}
+			*tenantID, err = waitOnTaskOperation(task.ID, c)
+			return *tenantID, err
+		})
+		if tenant.Iam != nil {
+			planIam(plan, name, tenant.Iam, nil, func(policy *[]photon.PolicyEntry) (*photon.Task, error) {
+				return client.Photonclient.Tenants.SetIam(*tenantID, policy)
+			}, c)
+		}
+	} else {
+		*tenantID = liveTenant.ID
+		planSecurityGroups(plan, name, tenant.SecurityGroups, liveTenant.SecurityGroups,
+			func(spec *photon.SecurityGroupsSpec) (*photon.Task, error) {
+				return client.Photonclient.Tenants.SetSecurityGroups(*tenantID, spec)
+			}, c)
+
+		if tenant.Quota != nil {
+			quota, err := client.Photonclient.Tenants.GetQuota(*tenantID)
+			if err != nil {
+				return err
+			}
+			planQuota(plan, name, tenant.Quota, quota, func(spec *photon.QuotaSpec) (*photon.Task, error) {
+				return client.Photonclient.Tenants.UpdateQuota(*tenantID, spec)
+			}, c)
+		}
+
+		if tenant.Iam != nil {
+			policy, err := client.Photonclient.Tenants.GetIam(*tenantID)
+			if err != nil {
+				return err
+			}
+			planIam(plan, name, tenant.Iam, policy, func(policy *[]photon.PolicyEntry) (*photon.Task, error) {
+				return client.Photonclient.Tenants.SetIam(*tenantID, policy)
+			}, c)
+		}
+	}
+
+	for _, project := range tenant.Projects {
+		var liveProject *photon.ProjectCompact
+		if liveTenant != nil {
+			projects, err := client.Photonclient.Tenants.GetProjects(*tenantID, &photon.ProjectGetOptions{Name: project.Name})
+			if err != nil {
+				return err
+			}
+			if len(projects.Items) > 0 {
+				liveProject = &projects.Items[0]
+			}
+		}
+		err := planProject(plan, tenant.Name, tenantID, project, liveProject, c)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func planProject(plan *applyPlan, tenantName string, tenantID *string, project manifest.Project,
+	liveProject *photon.ProjectCompact, c *cli.Context) error {
+
+	projectID := new(string)
+	name := fmt.Sprintf("project '%s/%s'", tenantName, project.Name)
+	if liveProject == nil {
+		spec := &photon.ProjectCreateSpec{
+			Name:           project.Name,
+			SecurityGroups: project.SecurityGroups,
+			ResourceQuota:  photon.Quota{QuotaLineItems: quotaSpecFromManifest(project.Quota)},
+		}
+		plan.add("create "+name, func() (string, error) {
+			task, err := client.Photonclient.Tenants.CreateProject(*tenantID, spec)
+			if err != nil {
+				return "", err
+			}
+			*projectID, err = waitOnTaskOperation(task.ID, c)
+			return *projectID, err
+		})
+		if project.Iam != nil {
+			planIam(plan, name, project.Iam, nil, func(policy *[]photon.PolicyEntry) (*photon.Task, error) {
+				return client.Photonclient.Projects.SetIam(*projectID, policy)
+			}, c)
+		}
+		return nil
+	}
+
+	*projectID = liveProject.ID
+	planSecurityGroups(plan, name, project.SecurityGroups, liveProject.SecurityGroups,
+		func(spec *photon.SecurityGroupsSpec) (*photon.Task, error) {
+			return client.Photonclient.Projects.SetSecurityGroups(*projectID, spec)
+		}, c)
+
+	if project.Quota != nil {
+		quota, err := client.Photonclient.Projects.GetQuota(*projectID)
+		if err != nil {
+			return err
+		}
+		planQuota(plan, name, project.Quota, quota, func(spec *photon.QuotaSpec) (*photon.Task, error) {
+			return client.Photonclient.Projects.UpdateQuota(*projectID, spec)
+		}, c)
+	}
+
+	if project.Iam != nil {
+		policy, err := client.Photonclient.Projects.GetIam(*projectID)
+		if err != nil {
+			return err
+		}
+		planIam(plan, name, project.Iam, policy, func(policy *[]photon.PolicyEntry) (*photon.Task, error) {
+			return client.Photonclient.Projects.SetIam(*projectID, policy)
+		}, c)
+	}
+	return nil
+}
+
+// Adds a step to replace the security groups if they differ from the ones set directly on the
+// entity. Groups inherited from the tenant are not compared.
+func planSecurityGroups(plan *applyPlan, name string, securityGroups []string, live []photon.SecurityGroup,
+	set func(*photon.SecurityGroupsSpec) (*photon.Task, error), c *cli.Context) {
+
+	if securityGroups == nil {
+		return
+	}
+	liveGroups := []string{}
+	for _, group := range live {
+		if !group.Inherited {
+			liveGroups = append(liveGroups, group.Name)
+		}
+	}
+	if stringSetsEqual(securityGroups, liveGroups) {
+		return
+	}
+
+	spec := &photon.SecurityGroupsSpec{Items: securityGroups}
+	plan.add("set security groups of "+name, func() (string, error) {
+		task, err := set(spec)
+		if err != nil {
+			return "", err
+		}
+		return waitOnTaskOperation(task.ID, c)
+	})
+}
+
+// Adds a step to update the quota if any limit listed in the manifest differs from the live one.
+// Keys that the manifest does not list are left as they are.
+func planQuota(plan *applyPlan, name string, quota manifest.Quota, live *photon.Quota,
+	set func(*photon.QuotaSpec) (*photon.Task, error), c *cli.Context) {
+
+	if live != nil && quotaEqual(quota, live.QuotaLineItems) {
+		return
+	}
+
+	spec := quotaSpecFromManifest(quota)
+	plan.add("set quota of "+name, func() (string, error) {
+		task, err := set(&spec)
+		if err != nil {
+			return "", err
+		}
+		return waitOnTaskOperation(task.ID, c)
+	})
+}
+
+// Adds a step to replace the IAM policy if it differs from the manifest
+func planIam(plan *applyPlan, name string, policy []manifest.PolicyEntry, live *[]photon.PolicyEntry,
+	set func(*[]photon.PolicyEntry) (*photon.Task, error), c *cli.Context) {
+
+	livePolicy := []photon.PolicyEntry{}
+	if live != nil {
+		livePolicy = *live
+	}
+	newPolicy := []photon.PolicyEntry{}
+	for _, entry := range policy {
+		newPolicy = append(newPolicy, photon.PolicyEntry{Principal: entry.Principal, Roles: entry.Roles})
+	}
+	if live != nil && policyEqual(newPolicy, livePolicy) {
+		return
+	}
+
+	plan.add("set IAM policy of "+name, func() (string, error) {
+		task, err := set(&newPolicy)
+		if err != nil {
+			return "", err
+		}
+		return waitOnTaskOperation(task.ID, c)
+	})
+}
+
+func quotaSpecFromManifest(quota manifest.Quota) photon.QuotaSpec {
+	if quota == nil {
+		return nil
+	}
+	spec := photon.QuotaSpec{}
+	for key, limit := range quota {
+		spec[key] = photon.QuotaStatusLineItem{Limit: limit.Limit, Unit: limit.Unit}
+	}
+	return spec
+}
+
+func quotaEqual(quota manifest.Quota, live map[string]photon.QuotaStatusLineItem) bool {
+	for key, limit := range quota {
+		liveLimit, ok := live[key]
+		if !ok || liveLimit.Limit != limit.Limit || liveLimit.Unit != limit.Unit {
+			return false
+		}
+	}
+	return true
+}
+
+func costEqual(cost []manifest.CostItem, live []photon.QuotaLineItem) bool {
+	if len(cost) != len(live) {
+		return false
+	}
+	for _, item := range cost {
+		found := false
+		for _, liveItem := range live {
+			if liveItem.Key == item.Key && liveItem.Value == item.Value && liveItem.Unit == item.Unit {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Compares IAM policies regardless of the order of entries and roles
+func policyEqual(policy []photon.PolicyEntry, live []photon.PolicyEntry) bool {
+	toSet := func(entries []photon.PolicyEntry) []string {
+		set := []string{}
+		for _, entry := range entries {
+			for _, role := range entry.Roles {
+				set = append(set, entry.Principal+"\x00"+role)
+			}
+		}
+		return set
+	}
+	return stringSetsEqual(toSet(policy), toSet(live))
+}
+
+func stringSetsEqual(a []string, b []string) bool {
+	sortedA := append([]string{}, a...)
+	sortedB := append([]string{}, b...)
+	sort.Strings(sortedA)
+	sort.Strings(sortedB)
+	return strings.Join(sortedA, "\x00\x00") == strings.Join(sortedB, "\x00\x00")
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/vmware/photon-controller-cli/photon/client"
+	"github.com/vmware/photon-controller-cli/photon/mocks"
+
+	"github.com/urfave/cli"
+	"github.com/vmware/photon-controller-go-sdk/photon"
+)
+
+const testEnvironmentManifest = `---
+zones:
+- name: zone1
+- name: zone2
+flavors:
+- name: small
+  kind: vm
+  cost:
+  - {key: vm.cpu, value: 1, unit: COUNT}
+- name: big
+  kind: vm
+  cost:
+  - {key: vm.cpu, value: 8, unit: COUNT}
+tenants:
+- name: cloud-dev
+  security_groups: [admins]
+  quota:
+    vm.count: {limit: 100, unit: COUNT}
+  projects:
+  - name: web
+    quota:
+      vm.count: {limit: 10, unit: COUNT}
+  - name: db
+    security_groups: [dba]
+    iam:
+    - principal: dba@example.com
+      roles: [contributor]
+- name: cloud-test
+`
+
+func registerJSONResponder(t *testing.T, method string, url string, o interface{}) {
+	response, err := json.Marshal(o)
+	if err != nil {
+		t.Error("Not expecting error serializing expected response")
+	}
+	mocks.RegisterResponder(method, url, mocks.CreateResponder(200, string(response[:])))
+}
+
+func TestApplyEnvironment(t *testing.T) {
+	file, err := ioutil.TempFile("", "environment_")
+	if err != nil {
+		t.Error("Not expecting error creating manifest file")
+	}
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(testEnvironmentManifest)
+	if err != nil {
+		t.Error("Not expecting error writing manifest file")
+	}
+	_ = file.Close()
+
+	server := mocks.NewTestServer()
+	defer server.Close()
+
+	registerJSONResponder(t, "GET", server.URL+rootUrl+"/zones",
+		&photon.Zones{Items: []photon.Zone{{Name: "zone1", ID: "zone1-id"}}})
+	registerJSONResponder(t, "GET", server.URL+rootUrl+"/flavors",
+		&photon.FlavorList{Items: []photon.Flavor{{Name: "small", Kind: "vm", ID: "small-id",
+			Cost: []photon.QuotaLineItem{{Key: "vm.cpu", Value: 2, Unit: "COUNT"}}}}})
+	registerJSONResponder(t, "GET", server.URL+rootUrl+"/tenants",
+		&photon.Tenants{Items: []photon.Tenant{{Name: "cloud-dev", ID: "dev-id",
+			SecurityGroups: []photon.SecurityGroup{{Name: "admins"}, {Name: "inherited", Inherited: true}}}}})
+	registerJSONResponder(t, "GET", server.URL+rootUrl+"/tenants/dev-id/quota",
+		&photon.Quota{QuotaLineItems: map[string]photon.QuotaStatusLineItem{
+			"vm.count": {Limit: 50, Unit: "COUNT"}}})
+	registerJSONResponder(t, "GET", server.URL+rootUrl+"/tenants/dev-id/projects?name=web",
+		&photon.ProjectList{Items: []photon.ProjectCompact{{Name: "web", ID: "web-id"}}})
+	// Quota keys that the manifest does not list are not managed, so they don't cause a change
+	registerJSONResponder(t, "GET", server.URL+rootUrl+"/projects/web-id/quota",
+		&photon.Quota{QuotaLineItems: map[string]photon.QuotaStatusLineItem{
+			"vm.count": {Limit: 10, Unit: "COUNT"}, "vm.memory": {Limit: 64, Unit: "GB"}}})
+	registerJSONResponder(t, "GET", server.URL+rootUrl+"/tenants/dev-id/projects?name=db",
+		&photon.ProjectList{Items: []photon.ProjectCompact{}})
+
+	mocks.Activate(true)
+	httpClient := &http.Client{Transport: mocks.DefaultMockTransport}
+	client.Photonclient = photon.NewTestClient(server.URL, nil, httpClient)
+
+	set := flag.NewFlagSet("test", 0)
+	set.String("file", file.Name(), "")
+	set.Bool("dry-run", true, "")
+	cxt := cli.NewContext(nil, set, nil)
+
+	var buf bytes.Buffer
+	err = applyEnvironment(cxt, &buf)
+	if err != nil {
+		t.Error("Not expecting error planning changes: ", err)
+	}
+
+	expected := "Warning: cost of vm flavor 'small' differs from the manifest, flavors cannot be updated\n" +
+		"Changes:\n" +
+		"  create zone 'zone2'\n" +
+		"  create vm flavor 'big'\n" +
+		"  set quota of tenant 'cloud-dev'\n" +
+		"  create project 'cloud-dev/db'\n" +
+		"  set IAM policy of project 'cloud-dev/db'\n" +
+		"  create tenant 'cloud-test'\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected plan, expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+
+	// Apply the changes needed for a manifest with a single zone
+	err = ioutil.WriteFile(file.Name(), []byte("zones:\n- name: zone2\n"), 0644)
+	if err != nil {
+		t.Error("Not expecting error writing manifest file")
+	}
+	registerJSONResponder(t, "POST", server.URL+rootUrl+"/zones",
+		&photon.Task{ID: "zone-task", Operation: "CREATE_ZONE", State: "QUEUED", Entity: photon.Entity{ID: "zone2-id"}})
+	registerJSONResponder(t, "GET", server.URL+rootUrl+"/tasks/zone-task",
+		&photon.Task{ID: "zone-task", Operation: "CREATE_ZONE", State: "COMPLETED", Entity: photon.Entity{ID: "zone2-id"}})
+
+	globalSet := flag.NewFlagSet("global", 0)
+	globalSet.Bool("non-interactive", true, "")
+	err = globalSet.Parse([]string{"--non-interactive"})
+	if err != nil {
+		t.Error("Not expecting arguments parsing to fail")
+	}
+	globalCtx := cli.NewContext(nil, globalSet, nil)
+	set = flag.NewFlagSet("test", 0)
+	set.String("file", file.Name(), "")
+	cxt = cli.NewContext(nil, set, globalCtx)
+
+	buf.Reset()
+	err = applyEnvironment(cxt, &buf)
+	if err != nil {
+		t.Error("Not expecting error applying changes: ", err)
+	}
+	err = checkRegExp(`Create zone 'zone2'`, buf)
+	if err != nil {
+		t.Errorf("Zone creation not reported: %s", err)
+	}
+}
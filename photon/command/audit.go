@@ -0,0 +1,221 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/urfave/cli"
+	"github.com/vmware/photon-controller-go-sdk/photon"
+	"github.com/vmware/photon-controller-go-sdk/photon/lightwave"
+
+	cf "github.com/vmware/photon-controller-cli/photon/configuration"
+)
+
+// Replaces the values of passwords, secrets and tokens on the audited command line
+const redactedValue = "<redacted>"
+
+// State recorded for tasks the command did not wait for, e.g. with --async
+const submittedState = "SUBMITTED"
+
+// One line of the audit log, written for each task submitted by a command
+type auditRecord struct {
+	Time       string `json:"time"`
+	Target     string `json:"target"`
+	User       string `json:"user,omitempty"`
+	Tenant     string `json:"tenant,omitempty"`
+	Project    string `json:"project,omitempty"`
+	Command    string `json:"command"`
+	Task       string `json:"task"`
+	Operation  string `json:"operation,omitempty"`
+	EntityKind string `json:"entityKind,omitempty"`
+	EntityID   string `json:"entityId,omitempty"`
+	State      string `json:"state"`
+	Error      string `json:"error,omitempty"`
+}
+
+// The command line being run, with secrets redacted. Set by SetAuditCommandLine.
+var auditCommandLine string
+
+// The tenant and project the command resolved from its flags, or from the configuration,
+// which the audit records are about. Set by verifyTenant and verifyProject; records of
+// commands that resolve neither fall back to the ones of the configuration.
+var auditTenant string
+var auditProject string
+
+// Serializes writes to the audit log, bulk operations wait for their tasks concurrently
+var auditLock sync.Mutex
+var auditWarned bool
+
+// Records the command line to write in the audit log, redacting the values of the
+// flags holding passwords, secrets or tokens
+func SetAuditCommandLine(app *cli.App, args []string) {
+	words := make([]string, len(args))
+	for i, arg := range redactCommandLine(app, args) {
+		if strings.ContainsAny(arg, " \t\n\"'") {
+			arg = strconv.Quote(arg)
+		}
+		words[i] = arg
+	}
+	auditCommandLine = strings.Join(words, " ")
+}
+
+func redactCommandLine(app *cli.App, args []string) []string {
+	secrets := map[string]bool{}
+	addSecretFlags(secrets, app.Flags)
+	commands := app.Commands
+
+	redacted := make([]string, len(args))
+	copy(redacted, args)
+	redactNext := false
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		if redactNext {
+			redacted[i] = redactedValue
+			redactNext = false
+			continue
+		}
+		if arg == "--" {
+			break
+		}
+		if strings.HasPrefix(arg, "-") {
+			name := strings.TrimLeft(arg, "-")
+			if equal := strings.Index(name, "="); equal >= 0 {
+				if secrets[name[:equal]] {
+					redacted[i] = arg[:len(arg)-len(name)+equal+1] + redactedValue
+				}
+			} else {
+				redactNext = secrets[name]
+			}
+			continue
+		}
+		// The flags of each subcommand are known once its name is seen
+		for _, command := range commands {
+			if command.HasName(arg) {
+				addSecretFlags(secrets, command.Flags)
+				commands = command.Subcommands
+				break
+			}
+		}
+	}
+	return redacted
+}
+
+// Adds the names of the flags holding secrets, file paths excepted
+func addSecretFlags(secrets map[string]bool, flags []cli.Flag) {
+	for _, flag := range flags {
+		names := strings.Split(flag.GetName(), ",")
+		long := strings.ToLower(strings.TrimSpace(names[0]))
+		if strings.HasSuffix(long, "-file") {
+			continue
+		}
+		if strings.Contains(long, "password") || strings.Contains(long, "secret") || strings.Contains(long, "token") {
+			for _, name := range names {
+				secrets[strings.TrimSpace(name)] = true
+			}
+		}
+	}
+}
+
+func setAuditTenant(name string) {
+	auditLock.Lock()
+	defer auditLock.Unlock()
+	auditTenant = name
+}
+
+func setAuditProject(name string) {
+	auditLock.Lock()
+	defer auditLock.Unlock()
+	auditProject = name
+}
+
+// Appends a line about a task submitted by the command to the audit log. The task is
+// nil when the command did not wait for it; a failure to write the log is reported
+// on stderr but does not fail the command.
+func auditTask(taskID string, task *photon.Task, taskErr error) {
+	err := writeAuditRecord(newAuditRecord(taskID, task, taskErr))
+	if err != nil {
+		auditLock.Lock()
+		defer auditLock.Unlock()
+		if !auditWarned {
+			auditWarned = true
+			fmt.Fprintf(os.Stderr, "Warning: could not write the audit log: %s\n", err)
+		}
+	}
+}
+
+func newAuditRecord(taskID string, task *photon.Task, taskErr error) *auditRecord {
+	record := &auditRecord{
+		Time:    time.Now().UTC().Format(time.RFC3339),
+		Command: auditCommandLine,
+		Task:    taskID,
+		State:   submittedState,
+	}
+	if len(record.Command) == 0 {
+		record.Command = strings.Join(os.Args, " ")
+	}
+	if task != nil {
+		record.Operation = task.Operation
+		record.EntityKind = task.Entity.Kind
+		record.EntityID = task.Entity.ID
+		record.State = task.State
+	}
+	if taskErr != nil {
+		record.Error = taskErr.Error()
+		if task == nil {
+			record.State = "ERROR"
+		}
+	}
+	return record
+}
+
+func writeAuditRecord(record *auditRecord) error {
+	config, err := cf.LoadConfig()
+	if err != nil {
+		return err
+	}
+	record.Target = config.CloudTarget
+	if len(config.Token) != 0 {
+		record.User = lightwave.ParseTokenDetails(config.Token).Subject
+	}
+	path, err := cf.GetAuditLogPath(config)
+	if err != nil {
+		return err
+	}
+
+	auditLock.Lock()
+	defer auditLock.Unlock()
+	record.Tenant, record.Project = auditTenant, auditProject
+	if len(record.Tenant) == 0 && config.Tenant != nil {
+		record.Tenant = config.Tenant.Name
+	}
+	if len(record.Project) == 0 && config.Project != nil {
+		record.Project = config.Project.Name
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(append(line, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
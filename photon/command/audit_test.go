@@ -0,0 +1,135 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package command
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli"
+	"github.com/vmware/photon-controller-go-sdk/photon"
+
+	cf "github.com/vmware/photon-controller-cli/photon/configuration"
+)
+
+func TestRedactCommandLine(t *testing.T) {
+	app := cli.NewApp()
+	app.Flags = []cli.Flag{cli.StringFlag{Name: "token"}}
+	app.Commands = []cli.Command{GetTargetCommand(), GetHostsCommand(), GetVMCommand()}
+
+	cases := []struct {
+		args     []string
+		expected []string
+	}{
+		{
+			[]string{"photon", "--token", "abc", "target", "login", "-u", "admin", "-p", "pwd"},
+			[]string{"photon", "--token", redactedValue, "target", "login", "-u", "admin", "-p", redactedValue},
+		},
+		{
+			[]string{"photon", "host", "create", "--password=pwd", "--address", "10.0.0.1"},
+			[]string{"photon", "host", "create", "--password=" + redactedValue, "--address", "10.0.0.1"},
+		},
+		// -p is the project of VM commands, not a password
+		{
+			[]string{"photon", "vm", "create", "-n", "vm-1", "-p", "prod"},
+			[]string{"photon", "vm", "create", "-n", "vm-1", "-p", "prod"},
+		},
+	}
+	for _, c := range cases {
+		redacted := redactCommandLine(app, c.args)
+		if !reflect.DeepEqual(redacted, c.expected) {
+			t.Errorf("Expecting %v, got %v", c.expected, redacted)
+		}
+	}
+}
+
+func TestAuditTask(t *testing.T) {
+	configDir, err := ioutil.TempDir("", "audit-test-")
+	if err != nil {
+		t.Error("Not expecting error creating config directory")
+	}
+	defer os.RemoveAll(configDir)
+	cf.UserConfigDir = configDir
+	defer func() { cf.UserConfigDir = "" }()
+
+	config := &cf.Configuration{
+		CloudTarget: "https://photon.example.com:443",
+		Tenant:      &cf.TenantConfiguration{Name: "tenant1", ID: "tenant-id"},
+	}
+	err = cf.SaveConfig(config)
+	if err != nil {
+		t.Error("Not expecting error saving config")
+	}
+	auditCommandLine = "photon vm delete vm-id"
+	auditTenant, auditProject = "", ""
+	defer func() {
+		auditCommandLine = ""
+		auditTenant, auditProject = "", ""
+	}()
+
+	task := &photon.Task{
+		ID:        "task-id",
+		Operation: "DELETE_VM",
+		State:     "ERROR",
+		Entity:    photon.Entity{ID: "vm-id", Kind: "vm"},
+	}
+	auditTask(task.ID, task, errors.New("VM is powered on"))
+	auditTask("other-task-id", nil, nil)
+	// A tenant and project given to the command take precedence over the configured ones
+	setAuditTenant("prod")
+	setAuditProject("web")
+	auditTask("scoped-task-id", nil, nil)
+
+	data, err := ioutil.ReadFile(path.Join(configDir, "audit.log"))
+	if err != nil {
+		t.Error("Not expecting error reading audit log: ", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expecting 3 lines in the audit log, got %d", len(lines))
+	}
+
+	var record auditRecord
+	err = json.Unmarshal([]byte(lines[0]), &record)
+	if err != nil {
+		t.Error("Not expecting error parsing audit record: ", err)
+	}
+	record.Time = ""
+	expected := auditRecord{
+		Target:     config.CloudTarget,
+		Tenant:     "tenant1",
+		Command:    "photon vm delete vm-id",
+		Task:       "task-id",
+		Operation:  "DELETE_VM",
+		EntityKind: "vm",
+		EntityID:   "vm-id",
+		State:      "ERROR",
+		Error:      "VM is powered on",
+	}
+	if record != expected {
+		t.Errorf("Expecting audit record %+v, got %+v", expected, record)
+	}
+
+	err = json.Unmarshal([]byte(lines[1]), &record)
+	if err != nil || record.Task != "other-task-id" || record.State != submittedState {
+		t.Errorf("Expecting the task submitted without waiting to be recorded, got %+v", record)
+	}
+
+	err = json.Unmarshal([]byte(lines[2]), &record)
+	if err != nil || record.Tenant != "prod" || record.Project != "web" {
+		t.Errorf("Expecting the tenant and project of the command to be recorded, got %+v", record)
+	}
+}
@@ -11,6 +11,7 @@ package command
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -19,6 +20,7 @@ import (
 	"strings"
 	"syscall"
 	"text/tabwriter"
+	"time"
 
 	"github.com/vmware/photon-controller-cli/photon/client"
 	"github.com/vmware/photon-controller-cli/photon/configuration"
@@ -56,7 +58,8 @@ func GetAuthCommand() cli.Command {
 				Usage:     "Show login token",
 				ArgsUsage: " ",
 				Description: "Show information about the current token being used to authenticate with \n" +
-					"   Photon Controller. The token is created by doing 'photon target login' \n" +
+					"   Photon Controller: its subject, tenant, groups and roles, and how long until it \n" +
+					"   expires. The token is created by doing 'photon target login' \n" +
 					"   Using the --detail flag will print the decoded token to stdout.",
 				Action: func(c *cli.Context) {
 					err := showLoginToken(c)
@@ -108,6 +111,26 @@ func GetAuthCommand() cli.Command {
 					}
 				},
 			},
+			{
+				Name:      "credential-store",
+				Usage:     "Show or change where login tokens are stored",
+				ArgsUsage: "[<store>]",
+				Description: "Without argument, shows where the access and refresh tokens are stored. With an\n" +
+					"   argument, moves the tokens of the current settings and of all profiles to that store:\n" +
+					"      config          the config file, in plain text (default)\n" +
+					"      secret-service  the OS keyring through the freedesktop Secret Service (needs secret-tool)\n" +
+					"      file            a local file encrypted with a passphrase, read from\n" +
+					"                      " + configuration.CredentialsPassphraseEnv + " or asked for\n" +
+					"      helper:<prog>   a credential helper following the docker credential helper protocol\n" +
+					"   Example:\n" +
+					"      photon auth credential-store helper:docker-credential-pass",
+				Action: func(c *cli.Context) {
+					err := credentialStore(c, os.Stdout)
+					if err != nil {
+						log.Fatal("Error: ", err)
+					}
+				},
+			},
 		},
 	}
 	return command
@@ -168,8 +191,7 @@ func showLoginTokenWriter(c *cli.Context, w io.Writer, config *configuration.Con
 	} else if c.GlobalIsSet("non-interactive") {
 		fmt.Fprintf(w, "%s\n", config.Token)
 	} else if utils.NeedsFormatting(c) {
-		mytoken := photon.TokenOptions{AccessToken: config.Token}
-		utils.FormatObject(mytoken, w, c)
+		utils.FormatObject(introspectToken(config.Token), w, c)
 	} else {
 		// General mode
 		dumpTokenDetails(w, "Login Access Token", config.Token)
@@ -177,6 +199,68 @@ func showLoginTokenWriter(c *cli.Context, w io.Writer, config *configuration.Con
 	return nil
 }
 
+// Shows the credential store in use, or moves the tokens to another one
+func credentialStore(c *cli.Context, w io.Writer) error {
+	if len(c.Args()) > 1 {
+		return fmt.Errorf("Unknown argument: %v", c.Args()[1:])
+	}
+	config, err := configuration.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	if len(c.Args()) == 0 {
+		if c.GlobalIsSet("non-interactive") {
+			name := config.CredentialStore
+			if name == configuration.ConfigFileCredentialStore {
+				name = "config"
+			}
+			fmt.Fprintf(w, "%s\n", name)
+		} else {
+			fmt.Fprintf(w, "Tokens are stored in the %s\n",
+				configuration.DescribeCredentialStore(config.CredentialStore))
+		}
+		return nil
+	}
+
+	name := c.Args()[0]
+	if name == "config" {
+		name = configuration.ConfigFileCredentialStore
+	}
+	newStore, err := configuration.GetCredentialStore(name)
+	if err != nil {
+		return err
+	}
+	if name == config.CredentialStore {
+		fmt.Fprintf(w, "Tokens are already stored in the %s\n", configuration.DescribeCredentialStore(name))
+		return nil
+	}
+	oldStore, err := configuration.GetCredentialStore(config.CredentialStore)
+	if err != nil {
+		return err
+	}
+
+	// The tokens were read from the old store and are written to the new one on save
+	config.CredentialStore = name
+	err = configuration.SaveConfig(config)
+	if err != nil {
+		return err
+	}
+	if oldStore != nil {
+		err = oldStore.Erase()
+		if err != nil {
+			return fmt.Errorf("Tokens were moved but could not be erased from the %s: %v", oldStore, err)
+		}
+	}
+
+	description := "config file"
+	if newStore != nil {
+		description = newStore.String()
+	}
+	fmt.Fprintf(w, "Tokens are now stored in the %s\n", description)
+	return nil
+}
+
 // Get lightwave CA certificates
 func getLightwaveCACert(c *cli.Context, w io.Writer) error {
 	err := checkArgCount(c, 0)
@@ -312,23 +396,98 @@ func printAuthInfo(auth *photon.AuthInfo, isScripting bool) error {
 	return nil
 }
 
+// What a login token tells about the session
+type tokenIntrospection struct {
+	AccessToken string   `json:"access_token"`
+	Subject     string   `json:"subject"`
+	Tenant      string   `json:"tenant"`
+	Groups      []string `json:"groups"`
+	Roles       []string `json:"roles"`
+	IssuedAt    string   `json:"issued_at"`
+	ExpiresAt   string   `json:"expires_at"`
+	// Seconds until the token expires, negative once it has
+	ExpiresIn int64 `json:"expires_in"`
+	Expired   bool  `json:"expired"`
+}
+
+// Claims of Photon Controller tokens not covered by lightwave.JWTToken
+type tokenRoles struct {
+	Roles []string `json:"roles"`
+}
+
+func introspectToken(encodedToken string) *tokenIntrospection {
+	jwtToken := lightwave.ParseTokenDetails(encodedToken)
+	info := &tokenIntrospection{
+		AccessToken: encodedToken,
+		Subject:     jwtToken.Subject,
+		Tenant:      jwtToken.Tenant,
+		Groups:      jwtToken.Groups,
+		Roles:       []string{},
+	}
+	if len(info.Tenant) == 0 {
+		// Subjects are user@tenant
+		if i := strings.LastIndex(jwtToken.Subject, "@"); i >= 0 {
+			info.Tenant = jwtToken.Subject[i+1:]
+		}
+	}
+	if info.Groups == nil {
+		info.Groups = []string{}
+	}
+
+	// Like lightwave.ParseTokenDetails, ignore the chunks that are not JSON
+	for _, chunk := range strings.Split(encodedToken, ".") {
+		decoded, err := base64.RawURLEncoding.DecodeString(chunk)
+		var roles tokenRoles
+		if err == nil && json.Unmarshal(decoded, &roles) == nil && roles.Roles != nil {
+			info.Roles = roles.Roles
+		}
+	}
+
+	if jwtToken.IssuedAt != 0 {
+		info.IssuedAt = time.Unix(jwtToken.IssuedAt, 0).Format(time.RFC3339)
+	}
+	if jwtToken.Expires != 0 {
+		expires := time.Unix(jwtToken.Expires, 0)
+		info.ExpiresAt = expires.Format(time.RFC3339)
+		info.ExpiresIn = int64(expires.Sub(time.Now()) / time.Second)
+		info.Expired = info.ExpiresIn <= 0
+	}
+	return info
+}
+
 // A JSON web token is a set of Base64 encoded strings separated by a period (.)
 // When decoded, it will either be JSON text or a signature
 // Here we decode the strings into a single token structure and print the most
 // useful fields. We do not print the signature.
 func dumpTokenDetails(w io.Writer, name string, encodedToken string) {
 	jwtToken := lightwave.ParseTokenDetails(encodedToken)
+	info := introspectToken(encodedToken)
 
 	fmt.Fprintf(w, "%s:\n", name)
 	fmt.Fprintf(w, "\tSubject: %s\n", jwtToken.Subject)
+	fmt.Fprintf(w, "\tTenant: %s\n", info.Tenant)
 	fmt.Fprintf(w, "\tGroups: ")
 	if jwtToken.Groups == nil {
 		fmt.Fprintf(w, "<none>\n")
 	} else {
 		fmt.Fprintf(w, "%s\n", strings.Join(jwtToken.Groups, ", "))
 	}
+	fmt.Fprintf(w, "\tRoles: ")
+	if len(info.Roles) == 0 {
+		fmt.Fprintf(w, "<none>\n")
+	} else {
+		fmt.Fprintf(w, "%s\n", strings.Join(info.Roles, ", "))
+	}
 	fmt.Fprintf(w, "\tIssued: %s\n", timestampToString(jwtToken.IssuedAt*1000))
 	fmt.Fprintf(w, "\tExpires: %s\n", timestampToString(jwtToken.Expires*1000))
+	if len(info.ExpiresAt) != 0 {
+		lifetime := time.Duration(info.ExpiresIn) * time.Second
+		if info.Expired {
+			fmt.Fprintf(w, "\tExpired: %s ago\n", -lifetime)
+		} else {
+			fmt.Fprintf(w, "\tExpires in: %s\n", lifetime)
+		}
+	}
 	fmt.Fprintf(w, "\tToken: %s\n", encodedToken)
 }
 
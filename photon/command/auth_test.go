@@ -11,12 +11,14 @@ package command
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"net/http"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/vmware/photon-controller-cli/photon/client"
 	"github.com/vmware/photon-controller-cli/photon/configuration"
@@ -278,6 +280,32 @@ func TestShowLoginTokenNonInteractive(t *testing.T) {
 	}
 }
 
+func TestIntrospectToken(t *testing.T) {
+	expires := time.Now().Add(time.Hour).Unix()
+	claims := fmt.Sprintf(`{"sub":"ci@cloud-dev","groups":["cloud-dev\\Admins"],"roles":["TENANT_ADMIN"],"exp":%d}`, expires)
+	token := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(claims)) + ".signature"
+
+	info := introspectToken(token)
+	if info.Tenant != "cloud-dev" || len(info.Roles) != 1 || info.Roles[0] != "TENANT_ADMIN" {
+		t.Errorf("Expecting the tenant and roles of the token, got %+v", info)
+	}
+	if info.Expired || info.ExpiresIn <= 3500 || info.ExpiresIn > 3600 {
+		t.Errorf("Expecting the token to expire in an hour, got %d seconds", info.ExpiresIn)
+	}
+
+	var output bytes.Buffer
+	dumpTokenDetails(&output, "Login Access Token", token)
+	err := checkRegExp(`Expires in:\s+59m`, output)
+	if err != nil {
+		t.Error(err)
+	}
+	err = checkRegExp(`Roles:\s+TENANT_ADMIN`, output)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
 func checkRegExp(pattern string, output bytes.Buffer) error {
 	matched, err := regexp.MatchString(pattern, output.String())
 	if !matched {
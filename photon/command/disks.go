@@ -237,7 +237,7 @@ func createDisk(c *cli.Context, w io.Writer) error {
 		}
 
 		diskID, err := waitOnTaskOperation(createTask.ID, c)
-		if err != nil {
+		if err != nil || isAsync(c) {
 			return err
 		}
 
@@ -276,7 +276,7 @@ func deleteDisk(c *cli.Context) error {
 	}
 
 	_, err = waitOnTaskOperation(deleteTask.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -0,0 +1,244 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package command
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/urfave/cli"
+	"github.com/vmware/photon-controller-go-sdk/photon"
+
+	"github.com/vmware/photon-controller-cli/photon/client"
+	"github.com/vmware/photon-controller-cli/photon/manifest"
+)
+
+type exportedZoneSorter []manifest.Zone
+
+func (z exportedZoneSorter) Len() int           { return len(z) }
+func (z exportedZoneSorter) Swap(i, j int)      { z[i], z[j] = z[j], z[i] }
+func (z exportedZoneSorter) Less(i, j int) bool { return z[i].Name < z[j].Name }
+
+type exportedHostSorter []manifest.Host
+
+func (h exportedHostSorter) Len() int           { return len(h) }
+func (h exportedHostSorter) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h exportedHostSorter) Less(i, j int) bool { return h[i].Address < h[j].Address }
+
+type exportedFlavorSorter []manifest.Flavor
+
+func (f exportedFlavorSorter) Len() int      { return len(f) }
+func (f exportedFlavorSorter) Swap(i, j int) { f[i], f[j] = f[j], f[i] }
+func (f exportedFlavorSorter) Less(i, j int) bool {
+	if f[i].Kind != f[j].Kind {
+		return f[i].Kind < f[j].Kind
+	}
+	return f[i].Name < f[j].Name
+}
+
+type exportedTenantSorter []manifest.Tenant
+
+func (t exportedTenantSorter) Len() int           { return len(t) }
+func (t exportedTenantSorter) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
+func (t exportedTenantSorter) Less(i, j int) bool { return t[i].Name < t[j].Name }
+
+type exportedProjectSorter []manifest.Project
+
+func (p exportedProjectSorter) Len() int           { return len(p) }
+func (p exportedProjectSorter) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+func (p exportedProjectSorter) Less(i, j int) bool { return p[i].Name < p[j].Name }
+
+// Creates a cli.Command for export
+// Usage: export [--file <manifest>]
+func GetExportCommand() cli.Command {
+	command := cli.Command{
+		Name:      "export",
+		Usage:     "Write the tenants, projects, flavors, zones and hosts of the deployment to a manifest",
+		ArgsUsage: " ",
+		Description: "Writes the logical configuration of the deployment as a YAML manifest that can be\n" +
+			"   given to 'photon apply' to rebuild it. Host credentials are not exported.\n" +
+			"   The manifest is printed if no file is given.\n\n" +
+			"   Example:\n" +
+			"     photon export -f env.yaml",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "file, f",
+				Usage: "file to write the manifest to",
+			},
+		},
+		Action: func(c *cli.Context) {
+			err := exportEnvironment(c, os.Stdout)
+			if err != nil {
+				log.Fatal("Error: ", err)
+			}
+		},
+	}
+	return command
+}
+
+func exportEnvironment(c *cli.Context, w io.Writer) error {
+	err := checkArgCount(c, 0)
+	if err != nil {
+		return err
+	}
+	file := c.String("file")
+
+	client.Photonclient, err = client.GetClient(c)
+	if err != nil {
+		return err
+	}
+
+	env := &manifest.Environment{}
+
+	zoneNames := map[string]string{}
+	zones, err := client.Photonclient.Zones.GetAll()
+	if err != nil {
+		return err
+	}
+	for _, zone := range zones.Items {
+		zoneNames[zone.ID] = zone.Name
+		env.Zones = append(env.Zones, manifest.Zone{Name: zone.Name})
+	}
+	sort.Sort(exportedZoneSorter(env.Zones))
+
+	hosts, err := client.Photonclient.InfraHosts.GetHosts()
+	if err != nil {
+		return err
+	}
+	for _, host := range hosts.Items {
+		env.Hosts = append(env.Hosts, manifest.Host{
+			Address:          host.Address,
+			AvailabilityZone: zoneNames[host.Zone],
+			Tags:             host.Tags,
+			Metadata:         host.Metadata,
+		})
+	}
+	sort.Sort(exportedHostSorter(env.Hosts))
+
+	flavors, err := client.Photonclient.Flavors.GetAll(&photon.FlavorGetOptions{})
+	if err != nil {
+		return err
+	}
+	for _, flavor := range flavors.Items {
+		exported := manifest.Flavor{Name: flavor.Name, Kind: flavor.Kind}
+		for _, item := range flavor.Cost {
+			exported.Cost = append(exported.Cost, manifest.CostItem{Key: item.Key, Value: item.Value, Unit: item.Unit})
+		}
+		env.Flavors = append(env.Flavors, exported)
+	}
+	sort.Sort(exportedFlavorSorter(env.Flavors))
+
+	tenants, err := client.Photonclient.Tenants.GetAll()
+	if err != nil {
+		return err
+	}
+	for _, tenant := range tenants.Items {
+		exported, err := exportTenant(tenant)
+		if err != nil {
+			return err
+		}
+		env.Tenants = append(env.Tenants, *exported)
+	}
+	sort.Sort(exportedTenantSorter(env.Tenants))
+
+	if len(file) == 0 {
+		return manifest.WriteEnvironment(env, w)
+	}
+	err = manifest.SaveEnvironment(env, file)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Exported %d tenants, %d flavors, %d zones and %d hosts to '%s'\n",
+		len(env.Tenants), len(env.Flavors), len(env.Zones), len(env.Hosts), file)
+	return nil
+}
+
+func exportTenant(tenant photon.Tenant) (*manifest.Tenant, error) {
+	exported := &manifest.Tenant{
+		Name:           tenant.Name,
+		SecurityGroups: exportSecurityGroups(tenant.SecurityGroups),
+	}
+
+	quota, err := client.Photonclient.Tenants.GetQuota(tenant.ID)
+	if err != nil {
+		return nil, err
+	}
+	exported.Quota = exportQuota(quota)
+
+	policy, err := client.Photonclient.Tenants.GetIam(tenant.ID)
+	if err != nil {
+		return nil, err
+	}
+	exported.Iam = exportIam(policy)
+
+	projects, err := client.Photonclient.Tenants.GetProjects(tenant.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, project := range projects.Items {
+		exportedProject := manifest.Project{
+			Name:           project.Name,
+			SecurityGroups: exportSecurityGroups(project.SecurityGroups),
+		}
+
+		quota, err := client.Photonclient.Projects.GetQuota(project.ID)
+		if err != nil {
+			return nil, err
+		}
+		exportedProject.Quota = exportQuota(quota)
+
+		policy, err := client.Photonclient.Projects.GetIam(project.ID)
+		if err != nil {
+			return nil, err
+		}
+		exportedProject.Iam = exportIam(policy)
+
+		exported.Projects = append(exported.Projects, exportedProject)
+	}
+	sort.Sort(exportedProjectSorter(exported.Projects))
+
+	return exported, nil
+}
+
+// Only security groups set on the entity itself are exported, inherited ones come from its parent
+func exportSecurityGroups(securityGroups []photon.SecurityGroup) []string {
+	var exported []string
+	for _, group := range securityGroups {
+		if !group.Inherited {
+			exported = append(exported, group.Name)
+		}
+	}
+	return exported
+}
+
+func exportQuota(quota *photon.Quota) manifest.Quota {
+	if quota == nil || len(quota.QuotaLineItems) == 0 {
+		return nil
+	}
+	exported := manifest.Quota{}
+	for key, item := range quota.QuotaLineItems {
+		exported[key] = manifest.QuotaLimit{Limit: item.Limit, Unit: item.Unit}
+	}
+	return exported
+}
+
+func exportIam(policy *[]photon.PolicyEntry) []manifest.PolicyEntry {
+	if policy == nil {
+		return nil
+	}
+	var exported []manifest.PolicyEntry
+	for _, entry := range *policy {
+		exported = append(exported, manifest.PolicyEntry{Principal: entry.Principal, Roles: entry.Roles})
+	}
+	return exported
+}
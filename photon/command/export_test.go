@@ -0,0 +1,105 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package command
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/vmware/photon-controller-cli/photon/client"
+	"github.com/vmware/photon-controller-cli/photon/manifest"
+	"github.com/vmware/photon-controller-cli/photon/mocks"
+
+	"github.com/urfave/cli"
+	"github.com/vmware/photon-controller-go-sdk/photon"
+)
+
+func TestExportEnvironment(t *testing.T) {
+	server := mocks.NewTestServer()
+	defer server.Close()
+
+	registerJSONResponder(t, "GET", server.URL+rootUrl+"/zones",
+		&photon.Zones{Items: []photon.Zone{{Name: "zone2", ID: "zone2-id"}, {Name: "zone1", ID: "zone1-id"}}})
+	registerJSONResponder(t, "GET", server.URL+rootUrl+"/infrastructure/hosts",
+		&photon.Hosts{Items: []photon.Host{{Address: "198.51.100.41", Zone: "zone1-id", Tags: []string{"CLOUD"},
+			Password: "secret"}}})
+	registerJSONResponder(t, "GET", server.URL+rootUrl+"/flavors",
+		&photon.FlavorList{Items: []photon.Flavor{{Name: "small", Kind: "vm", ID: "small-id",
+			Cost: []photon.QuotaLineItem{{Key: "vm.cpu", Value: 1, Unit: "COUNT"}}}}})
+	registerJSONResponder(t, "GET", server.URL+rootUrl+"/tenants",
+		&photon.Tenants{Items: []photon.Tenant{{Name: "cloud-dev", ID: "dev-id",
+			SecurityGroups: []photon.SecurityGroup{{Name: "admins"}, {Name: "inherited", Inherited: true}}}}})
+	registerJSONResponder(t, "GET", server.URL+rootUrl+"/tenants/dev-id/quota",
+		&photon.Quota{QuotaLineItems: map[string]photon.QuotaStatusLineItem{
+			"vm.count": {Limit: 100, Usage: 5, Unit: "COUNT"}}})
+	registerJSONResponder(t, "GET", server.URL+rootUrl+"/tenants/dev-id/iam",
+		&[]photon.PolicyEntry{})
+	registerJSONResponder(t, "GET", server.URL+rootUrl+"/tenants/dev-id/projects",
+		&photon.ProjectList{Items: []photon.ProjectCompact{{Name: "web", ID: "web-id"}}})
+	registerJSONResponder(t, "GET", server.URL+rootUrl+"/projects/web-id/quota",
+		&photon.Quota{})
+	registerJSONResponder(t, "GET", server.URL+rootUrl+"/projects/web-id/iam",
+		&[]photon.PolicyEntry{{Principal: "dev@example.com", Roles: []string{"contributor"}}})
+
+	mocks.Activate(true)
+	httpClient := &http.Client{Transport: mocks.DefaultMockTransport}
+	client.Photonclient = photon.NewTestClient(server.URL, nil, httpClient)
+
+	file, err := ioutil.TempFile("", "environment_")
+	if err != nil {
+		t.Error("Not expecting error creating manifest file")
+	}
+	_ = file.Close()
+	defer os.Remove(file.Name())
+
+	set := flag.NewFlagSet("test", 0)
+	set.String("file", file.Name(), "")
+	cxt := cli.NewContext(nil, set, nil)
+
+	var buf bytes.Buffer
+	err = exportEnvironment(cxt, &buf)
+	if err != nil {
+		t.Error("Not expecting error exporting: ", err)
+	}
+
+	env, err := manifest.LoadEnvironment(file.Name())
+	if err != nil {
+		t.Error("Not expecting error loading the exported manifest: ", err)
+	}
+
+	expected := &manifest.Environment{
+		Tenants: []manifest.Tenant{
+			{
+				Name:           "cloud-dev",
+				SecurityGroups: []string{"admins"},
+				Quota:          manifest.Quota{"vm.count": {Limit: 100, Unit: "COUNT"}},
+				Projects: []manifest.Project{
+					{
+						Name: "web",
+						Iam:  []manifest.PolicyEntry{{Principal: "dev@example.com", Roles: []string{"contributor"}}},
+					},
+				},
+			},
+		},
+		Flavors: []manifest.Flavor{
+			{Name: "small", Kind: "vm", Cost: []manifest.CostItem{{Key: "vm.cpu", Value: 1, Unit: "COUNT"}}},
+		},
+		Zones: []manifest.Zone{{Name: "zone1"}, {Name: "zone2"}},
+		Hosts: []manifest.Host{{Address: "198.51.100.41", AvailabilityZone: "zone1", Tags: []string{"CLOUD"}}},
+	}
+	if !reflect.DeepEqual(env, expected) {
+		t.Errorf("Exported manifest does not match, expected %+v, got %+v", expected, env)
+	}
+}
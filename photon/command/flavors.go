@@ -205,7 +205,7 @@ func createFlavor(c *cli.Context, w io.Writer) error {
 			return err
 		}
 		flavorId, err := waitOnTaskOperation(createTask.ID, c)
-		if err != nil {
+		if err != nil || isAsync(c) {
 			return err
 		}
 		if utils.NeedsFormatting(c) {
@@ -241,7 +241,7 @@ func deleteFlavor(c *cli.Context, w io.Writer) error {
 	}
 
 	_, err = waitOnTaskOperation(deleteTask.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -306,7 +306,7 @@ func createHost(c *cli.Context, w io.Writer) error {
 		return err
 	}
 	id, err := waitOnTaskOperation(createTask.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -340,7 +340,7 @@ func deleteHost(c *cli.Context, w io.Writer) error {
 		return err
 	}
 	_, err = waitOnTaskOperation(deleteTask.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -437,7 +437,7 @@ func setHostAvailabilityZone(c *cli.Context, w io.Writer) error {
 		return err
 	}
 	id, err = waitOnTaskOperation(setTask.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 	if utils.NeedsFormatting(c) {
@@ -524,7 +524,7 @@ func provisionHost(c *cli.Context, w io.Writer) error {
 		return err
 	}
 	_, err = waitOnTaskOperation(resumeTask.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -550,7 +550,7 @@ func suspendHost(c *cli.Context, w io.Writer) error {
 		return err
 	}
 	_, err = waitOnTaskOperation(suspendTask.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -576,7 +576,7 @@ func resumeHost(c *cli.Context, w io.Writer) error {
 		return err
 	}
 	_, err = waitOnTaskOperation(resumeTask.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -602,7 +602,7 @@ func enterMaintenanceMode(c *cli.Context, w io.Writer) error {
 		return err
 	}
 	_, err = waitOnTaskOperation(enterTask.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -628,7 +628,7 @@ func exitMaintenanceMode(c *cli.Context, w io.Writer) error {
 		return err
 	}
 	_, err = waitOnTaskOperation(exitTask.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -273,26 +273,27 @@ func createImage(c *cli.Context, w io.Writer) error {
 		if scope != "infrastructure" && scope != "infra" && scope != "project" {
 			return fmt.Errorf(scope + " is not a supported scope. Enter infrastructure, infra or project.")
 		}
+	}
+
+	client.Photonclient, err = client.GetClient(c)
+	if err != nil {
+		return err
+	}
 
-		if scope == "project" && len(projectID) == 0 {
-			// Try loading default Project from configuration
-			config, err := configuration.LoadConfig()
+	// Without a scope the image is an infrastructure image, even when a project is set
+	if len(projectID) == 0 && scope == "project" {
+		projectID, err = currentProjectID()
+		if err != nil {
+			return err
+		}
+		if len(projectID) == 0 && !c.GlobalIsSet("non-interactive") {
+			projectID, err = askForInput("Project ID: ", projectID)
 			if err != nil {
 				return err
 			}
-			if config != nil && config.Project != nil {
-				projectID = config.Project.ID
-			}
-			// If no default Project, prompt for Project ID
-			if len(projectID) == 0 {
-				projectID, err = askForInput("Project ID: ", projectID)
-				if err != nil {
-					return err
-				}
-			}
-			if len(projectID) == 0 {
-				return fmt.Errorf("Please provide project ID")
-			}
+		}
+		if len(projectID) == 0 {
+			return fmt.Errorf("Please provide project ID")
 		}
 	}
 
@@ -301,11 +302,6 @@ func createImage(c *cli.Context, w io.Writer) error {
 		return err
 	}
 
-	client.Photonclient, err = client.GetClient(c)
-	if err != nil {
-		return err
-	}
-
 	options := &photon.ImageCreateOptions{
 		ReplicationType: replicationType,
 	}
@@ -324,7 +320,7 @@ func createImage(c *cli.Context, w io.Writer) error {
 	}
 
 	imageID, err := waitOnTaskOperation(uploadTask.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -344,6 +340,27 @@ func createImage(c *cli.Context, w io.Writer) error {
 	return nil
 }
 
+// Returns the ID of the current project, looked up by name when it comes from
+// PHOTON_PROJECT, or an empty string if no project is set
+func currentProjectID() (string, error) {
+	config, err := configuration.LoadConfig()
+	if err != nil {
+		return "", err
+	}
+	if config.Tenant == nil || config.Project == nil {
+		return "", nil
+	}
+	tenant, err := verifyTenant("")
+	if err != nil {
+		return "", err
+	}
+	project, err := verifyProject(tenant.ID, "")
+	if err != nil {
+		return "", err
+	}
+	return project.ID, nil
+}
+
 // Deletes an image by id
 func deleteImage(c *cli.Context) error {
 	err := checkArgCount(c, 1)
@@ -364,7 +381,7 @@ func deleteImage(c *cli.Context) error {
 		}
 
 		_, err = waitOnTaskOperation(deleteTask.ID, c)
-		if err != nil {
+		if err != nil || isAsync(c) {
 			return err
 		}
 	} else {
@@ -591,7 +608,7 @@ func modifyImageIamPolicy(c *cli.Context, w io.Writer, action string) error {
 	}
 
 	_, err = waitOnTaskOperation(task.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
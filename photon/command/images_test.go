@@ -18,6 +18,7 @@ import (
 	"testing"
 
 	"github.com/vmware/photon-controller-cli/photon/client"
+	cf "github.com/vmware/photon-controller-cli/photon/configuration"
 	"github.com/vmware/photon-controller-cli/photon/mocks"
 
 	"github.com/urfave/cli"
@@ -132,6 +133,118 @@ func TestCreateDeleteImage(t *testing.T) {
 	}
 }
 
+func TestCreateImageInEnvProject(t *testing.T) {
+	configOri, err := cf.LoadConfig()
+	if err != nil {
+		t.Error("Not expecting error loading config file")
+	}
+	defer func() {
+		_ = os.Unsetenv(cf.TenantEnv)
+		_ = os.Unsetenv(cf.ProjectEnv)
+		err = cf.SaveConfig(configOri)
+		if err != nil {
+			t.Error("Not expecting error when saving config file")
+		}
+	}()
+	err = cf.SaveConfig(&cf.Configuration{CloudTarget: "test-image"})
+	if err != nil {
+		t.Error("Not expecting error when saving config file")
+	}
+	// Only the names are known, the IDs are looked up
+	_ = os.Setenv(cf.TenantEnv, "env-tenant")
+	_ = os.Setenv(cf.ProjectEnv, "env-project")
+
+	server := mocks.NewTestServer()
+	defer server.Close()
+	registerJSONResponder(t, "GET", server.URL+rootUrl+"/tenants",
+		&photon.Tenants{Items: []photon.Tenant{{Name: "env-tenant", ID: "env-tenant-id"}}})
+	registerJSONResponder(t, "GET", server.URL+rootUrl+"/tenants/env-tenant-id/projects?name=env-project",
+		&photon.ProjectList{Items: []photon.ProjectCompact{{Name: "env-project", ID: "env-project-id"}}})
+	task := &photon.Task{ID: "image-task-id", Operation: "CREATE_IMAGE", State: "COMPLETED",
+		Entity: photon.Entity{ID: "image-id"}}
+	registerJSONResponder(t, "POST", server.URL+rootUrl+"/projects/env-project-id/images", task)
+	registerJSONResponder(t, "GET", server.URL+rootUrl+"/tasks/"+task.ID, task)
+
+	mocks.Activate(true)
+	httpClient := &http.Client{Transport: mocks.DefaultMockTransport}
+	client.Photonclient = photon.NewTestClient(server.URL, nil, httpClient)
+
+	globalSet := flag.NewFlagSet("test", 0)
+	globalSet.Bool("non-interactive", true, "doc")
+	err = globalSet.Parse([]string{"--non-interactive"})
+	if err != nil {
+		t.Error("Not expecting global argument parsing to fail")
+	}
+	set := flag.NewFlagSet("test", 0)
+	set.String("name", "n", "testname")
+	set.String("scope", "project", "image scope")
+	set.String("project", "", "project id")
+	err = set.Parse([]string{"../../testdata/ttylinux-pc_i486-16.1.iso"})
+	if err != nil {
+		t.Error("Not expecting arguments parsing to fail")
+	}
+	cxt := cli.NewContext(nil, set, cli.NewContext(nil, globalSet, nil))
+
+	var buf bytes.Buffer
+	err = createImage(cxt, &buf)
+	if err != nil {
+		t.Error("Not expecting error creating the image in the project of PHOTON_PROJECT: ", err)
+	}
+}
+
+func TestCreateImageWithoutScope(t *testing.T) {
+	configOri, err := cf.LoadConfig()
+	if err != nil {
+		t.Error("Not expecting error loading config file")
+	}
+	defer func() {
+		err = cf.SaveConfig(configOri)
+		if err != nil {
+			t.Error("Not expecting error when saving config file")
+		}
+	}()
+	err = cf.SaveConfig(&cf.Configuration{CloudTarget: "test-image",
+		Tenant:  &cf.TenantConfiguration{Name: "config-tenant", ID: "config-tenant-id"},
+		Project: &cf.ProjectConfiguration{Name: "config-project", ID: "config-project-id"}})
+	if err != nil {
+		t.Error("Not expecting error when saving config file")
+	}
+
+	server := mocks.NewTestServer()
+	defer server.Close()
+	// Only the infrastructure image endpoint is mocked, a project image would fail
+	task := &photon.Task{ID: "image-task-id", Operation: "CREATE_IMAGE", State: "COMPLETED",
+		Entity: photon.Entity{ID: "image-id"}}
+	registerJSONResponder(t, "POST", server.URL+rootUrl+"/images", task)
+	registerJSONResponder(t, "GET", server.URL+rootUrl+"/tasks/"+task.ID, task)
+
+	mocks.Activate(true)
+	httpClient := &http.Client{Transport: mocks.DefaultMockTransport}
+	client.Photonclient = photon.NewTestClient(server.URL, nil, httpClient)
+
+	globalSet := flag.NewFlagSet("test", 0)
+	globalSet.Bool("non-interactive", true, "doc")
+	err = globalSet.Parse([]string{"--non-interactive"})
+	if err != nil {
+		t.Error("Not expecting global argument parsing to fail")
+	}
+	set := flag.NewFlagSet("test", 0)
+	set.String("name", "n", "testname")
+	set.String("scope", "", "image scope")
+	set.String("project", "", "project id")
+	err = set.Parse([]string{"../../testdata/ttylinux-pc_i486-16.1.iso"})
+	if err != nil {
+		t.Error("Not expecting arguments parsing to fail")
+	}
+	cxt := cli.NewContext(nil, set, cli.NewContext(nil, globalSet, nil))
+
+	var buf bytes.Buffer
+	err = createImage(cxt, &buf)
+	if err != nil {
+		t.Error("Not expecting error creating an infrastructure image without scope: ", err)
+	}
+}
+
 func TestFindImagesByName(t *testing.T) {
 	expectedImageList := MockImagesPage{
 		Items: []photon.Image{
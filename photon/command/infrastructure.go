@@ -141,7 +141,7 @@ func updateInfrastructureImageDatastores(c *cli.Context) error {
 	}
 
 	_, err = waitOnTaskOperation(task.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -171,7 +171,7 @@ func syncInfrastructureHostsConfig(c *cli.Context) error {
 	}
 
 	_, err = waitOnTaskOperation(task.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -491,24 +491,13 @@ func printServiceList(serviceList []photon.Service, w io.Writer, c *cli.Context,
 func printServiceVMs(vms []photon.VM, w io.Writer, c *cli.Context) (err error) {
 	serviceVMs := []ServiceVM{}
 	for _, vm := range vms {
-		ipAddr := "-"
 		networks, err := getVMNetworks(vm.ID, c)
 		if err != nil {
 			continue
 		}
-		for _, nt := range networks {
-			network := nt.(map[string]interface{})
-			if val, ok := network["network"]; !ok || val == nil {
-				continue
-			}
-			if val, ok := network["ipAddress"]; ok && val != nil {
-				ipAddr = val.(string)
-				break
-			}
-		}
 		serviceVM := ServiceVM{
 			vm,
-			ipAddr,
+			vmIPAddress(networks),
 		}
 		serviceVMs = append(serviceVMs, serviceVM)
 
@@ -538,22 +527,33 @@ func printServiceVMs(vms []photon.VM, w io.Writer, c *cli.Context) (err error) {
 	return nil
 }
 
+// Returns the IP address of the first network connection that has one, or "-"
+func vmIPAddress(networks []interface{}) string {
+	for _, nt := range networks {
+		network, ok := nt.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if val, ok := network["network"]; !ok || val == nil {
+			continue
+		}
+		if val, ok := network["ipAddress"].(string); ok {
+			return val
+		}
+	}
+	return "-"
+}
+
 func getVMNetworks(id string, c *cli.Context) (networks []interface{}, err error) {
 	task, err := client.Photonclient.VMs.GetNetworks(id)
 	if err != nil {
 		return nil, err
 	}
 
-	if c.GlobalIsSet("non-interactive") {
-		task, err = client.Photonclient.Tasks.Wait(task.ID)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		task, err = pollTask(task.ID)
-		if err != nil {
-			return nil, err
-		}
+	// Looking up the networks changes nothing, so the task is not audited
+	task, err = followTask(task.ID, !c.GlobalIsSet("non-interactive"))
+	if err != nil {
+		return nil, err
 	}
 	networkConnections := task.ResourceProperties.(map[string]interface{})
 	networks = networkConnections["networkConnections"].([]interface{})
@@ -659,6 +659,7 @@ func verifyTenant(name string) (*cf.TenantConfiguration, error) {
 		if len(tenantID) == 0 || err != nil {
 			return nil, err
 		}
+		setAuditTenant(name)
 		return &cf.TenantConfiguration{Name: name, ID: tenantID}, nil
 	}
 
@@ -669,7 +670,12 @@ func verifyTenant(name string) (*cf.TenantConfiguration, error) {
 	if config.Tenant == nil {
 		return nil, fmt.Errorf("Error: Set tenant first using 'tenant set <name>' or '-t <name>' option")
 	}
+	if len(config.Tenant.ID) == 0 {
+		// Only the name is known when the tenant comes from PHOTON_TENANT
+		return verifyTenant(config.Tenant.Name)
+	}
 
+	setAuditTenant(config.Tenant.Name)
 	return config.Tenant, nil
 }
 
@@ -720,6 +726,7 @@ func verifyProject(tenantID string, name string) (*cf.ProjectConfiguration, erro
 		if err != nil {
 			return nil, err
 		}
+		setAuditProject(name)
 		return &cf.ProjectConfiguration{Name: name, ID: project.ID}, nil
 	}
 
@@ -730,7 +737,12 @@ func verifyProject(tenantID string, name string) (*cf.ProjectConfiguration, erro
 	if config.Project == nil {
 		return nil, fmt.Errorf("Error: Set project first using 'project set <name>' or '-p <name>' option")
 	}
+	if len(config.Project.ID) == 0 {
+		// Only the name is known when the project comes from PHOTON_PROJECT
+		return verifyProject(tenantID, config.Project.Name)
+	}
 
+	setAuditProject(config.Project.Name)
 	return config.Project, nil
 }
 
@@ -743,63 +755,127 @@ func getTaskAPIErrorList(task *photon.Task) []photon.ApiError {
 	return apiErrorList
 }
 
-var taskInProgress *photon.Task
-var endAnimation bool
+// Progress of the task being waited for, displayed on the terminal until finish is called
+type taskProgressDisplay struct {
+	start time.Time
+	mutex sync.Mutex
+	task  *photon.Task
+	stop  chan struct{}
+	wg    sync.WaitGroup
+}
 
-// Display state of taskInProgress while endAnimation is not true
+func startTaskProgress() *taskProgressDisplay {
+	display := &taskProgressDisplay{start: time.Now(), stop: make(chan struct{})}
+	display.wg.Add(1)
+	go func() {
+		defer display.wg.Done()
+		display.run()
+	}()
+	return display
+}
+
+func (display *taskProgressDisplay) update(task *photon.Task) {
+	display.mutex.Lock()
+	defer display.mutex.Unlock()
+	display.task = task
+}
+
+// Stops the display and waits for the line to be cleared
+func (display *taskProgressDisplay) finish() {
+	close(display.stop)
+	display.wg.Wait()
+}
+
+// Display state of the task until the display is stopped
 // Print format:
 // e.g:  0h: 0m: 0s [  ] CREATE_HOST : QUEUED
 //       0h: 0m: 0s [= ] CREATE_HOST : CREATE_HOST | Step 1/1
 //       0h: 0m: 1s [==] CREATE_HOST : COMPLETED
-func displayTaskProgress(start time.Time) {
+func (display *taskProgressDisplay) run() {
 	cursor := 0
-	displayInterval := 500 * time.Millisecond
-	for !endAnimation {
-		if taskInProgress != nil {
-			var taskStatus string
-			startedStep := findStartedStep(taskInProgress)
-
-			if startedStep == nil {
-				taskStatus = taskInProgress.State
-			} else {
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		display.mutex.Lock()
+		task := display.task
+		display.mutex.Unlock()
+		if task != nil {
+			taskStatus := taskProgress(task)
+			startedStep := findStartedStep(task)
+			if startedStep != nil {
 				cursor = startedStep.Sequence + 1
-				taskStatus = fmt.Sprintf("%s | Step %d/%d",
-					startedStep.Operation, startedStep.Sequence+1, len(taskInProgress.Steps))
 			}
 
 			fmt.Printf("\r%s\r", strings.Repeat(" ", 100))
 
-			elapsed := int(time.Since(start).Seconds())
+			elapsed := int(time.Since(display.start).Seconds())
 			fmt.Printf("%2dh%2dm%2ds ", elapsed/3600, (elapsed/60)%60, elapsed%60)
-			fmt.Printf("[%s] ", getProgressBar(cursor, len(taskInProgress.Steps)+1))
-			fmt.Printf("%s : %s", taskInProgress.Operation, taskStatus)
+			fmt.Printf("[%s] ", getProgressBar(cursor, len(task.Steps)+1))
+			fmt.Printf("%s : %s", task.Operation, taskStatus)
+		}
+		select {
+		case <-display.stop:
+			fmt.Printf("\r%s\r", strings.Repeat(" ", 100))
+			return
+		case <-ticker.C:
 		}
-		time.Sleep(displayInterval)
 	}
-	fmt.Printf("\r%s\r", strings.Repeat(" ", 100))
 }
 
 // Wait for task to finish and display task progress
 func pollTask(id string) (task *photon.Task, err error) {
-	return pollTaskWithTimeout(client.Photonclient, id, 30*time.Minute)
+	options, err := getTaskPollOptions()
+	if err != nil {
+		auditTask(id, nil, err)
+		return nil, err
+	}
+	return waitForTaskWithOptions(id, options, true)
+}
+
+// Wait for task to finish without displaying progress, for scripting and formatted output
+func waitForTask(id string) (task *photon.Task, err error) {
+	options, err := getTaskPollOptions()
+	if err != nil {
+		auditTask(id, nil, err)
+		return nil, err
+	}
+	return waitForTaskWithOptions(id, options, false)
 }
 
-func pollTaskWithTimeout(api *photon.Client, id string, pollTimeout time.Duration) (task *photon.Task, err error) {
+// Wait for task to finish with the poll options the command resolved once, for commands
+// waiting for many tasks. Tasks waited for at the same time must not display progress.
+func waitForTaskWithOptions(id string, options *taskPollOptions, showProgress bool) (task *photon.Task, err error) {
+	task, err = pollTaskWithOptions(client.Photonclient, id, options, showProgress)
+	auditTask(id, task, err)
+	return task, err
+}
+
+// Wait for a task without recording it in the audit log, for tasks the command did not submit
+// or that only look something up
+func followTask(id string, showProgress bool) (task *photon.Task, err error) {
+	options, err := getTaskPollOptions()
+	if err != nil {
+		return nil, err
+	}
+	return pollTaskWithOptions(client.Photonclient, id, options, showProgress)
+}
+
+// Polls the task until it completes, fails or times out. Several tasks can be polled at the
+// same time, as long as only one of them displays its progress.
+func pollTaskWithOptions(api *photon.Client, id string, options *taskPollOptions,
+	showProgress bool) (task *photon.Task, err error) {
+
 	start := time.Now()
 	numErr := 0
+	taskPollDelay := options.Interval
 
-	taskPollDelay := 500 * time.Millisecond
-	taskRetryCount := 3
-
-	endAnimation = false
-	var wg sync.WaitGroup
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		displayTaskProgress(start)
-	}()
+	var display *taskProgressDisplay
+	if showProgress {
+		display = startTaskProgress()
+		defer display.finish()
+	}
 
-	for time.Since(start) < pollTimeout {
+	for time.Since(start) < options.Timeout {
 		task, err = api.Tasks.Get(id)
 
 		if err != nil {
@@ -809,8 +885,6 @@ func pollTaskWithTimeout(api *photon.Client, id string, pollTimeout time.Duratio
 				if len(apiErrorList) != 0 {
 					err = fmt.Errorf("%s\nAPI Errors: %s", err.Error(), apiErrorList)
 				}
-				endAnimation = true
-				wg.Wait()
 				return
 			default:
 				apiErrorList := getTaskAPIErrorList(task)
@@ -819,34 +893,33 @@ func pollTaskWithTimeout(api *photon.Client, id string, pollTimeout time.Duratio
 				}
 
 				if task != nil && task.State == "ERROR" {
-					endAnimation = true
-					wg.Wait()
 					return
 				}
 
 				numErr++
 				if numErr > taskRetryCount {
-					endAnimation = true
-					wg.Wait()
 					return
 				}
 			}
 		} else {
 			numErr = 0
-			taskInProgress = task
+			if display != nil {
+				display.update(task)
+			}
 			if task.State == "COMPLETED" {
-				endAnimation = true
-				wg.Wait()
 				return
 			}
 		}
 
-		time.Sleep(taskPollDelay)
+		delay := withJitter(taskPollDelay)
+		if remaining := options.Timeout - time.Since(start); delay > remaining && remaining > 0 {
+			delay = remaining
+		}
+		time.Sleep(delay)
+		taskPollDelay = nextPollInterval(taskPollDelay)
 	}
 
-	endAnimation = true
-	wg.Wait()
-	err = fmt.Errorf("Timed out while waiting for task to complete")
+	err = fmt.Errorf("Timed out after %s while waiting for task %s to complete", options.Timeout, id)
 	return
 }
 
@@ -859,16 +932,55 @@ func findStartedStep(task *photon.Task) *photon.Step {
 	return nil
 }
 
+// Returns the state of the task, or the step it is running and how many steps it has
+func taskProgress(task *photon.Task) string {
+	startedStep := findStartedStep(task)
+	if startedStep == nil {
+		return task.State
+	}
+	return fmt.Sprintf("%s | Step %d/%d", startedStep.Operation, startedStep.Sequence+1, len(task.Steps))
+}
+
 func getProgressBar(cursor int, len int) string {
 	return strings.Repeat("=", cursor) + strings.Repeat(" ", len-cursor)
 }
 
+// Tells if the global --async flag asks commands not to wait for their tasks
+func isAsync(c *cli.Context) bool {
+	return c.GlobalIsSet("async")
+}
+
+// Prints a task that was submitted without waiting for it: its ID when scripting,
+// the task itself under --output, or a hint on how to follow it
+func printSubmittedTask(taskId string, c *cli.Context, w io.Writer) error {
+	if c.GlobalIsSet("non-interactive") {
+		fmt.Fprintln(w, taskId)
+	} else if utils.NeedsFormatting(c) {
+		task, err := client.Photonclient.Tasks.Get(taskId)
+		if err != nil {
+			return err
+		}
+		utils.FormatObject(task, w, c)
+	} else {
+		fmt.Fprintf(w, "Task %s submitted. Run 'task monitor %s' to follow it.\n", taskId, taskId)
+	}
+	return nil
+}
+
+// Waits for the task to complete and returns the ID of its entity. With --async the
+// task is only printed and an empty ID is returned: callers then stop without
+// looking at the entity, as the operation may not have happened yet.
 func waitOnTaskOperation(taskId string, c *cli.Context) (string, error) {
+	if isAsync(c) {
+		auditTask(taskId, nil, nil)
+		return "", printSubmittedTask(taskId, c, os.Stdout)
+	}
+
 	var task *photon.Task
 	var err error
 	needsFormatting := utils.NeedsFormatting(c)
 	if c.GlobalIsSet("non-interactive") || needsFormatting {
-		task, err = client.Photonclient.Tasks.Wait(taskId)
+		task, err = waitForTask(taskId)
 		if err != nil {
 			return "", err
 		}
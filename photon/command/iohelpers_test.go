@@ -10,8 +10,17 @@
 package command
 
 import (
+	"bytes"
+	"flag"
+	"net/http"
 	"regexp"
 	"testing"
+
+	"github.com/vmware/photon-controller-cli/photon/client"
+	"github.com/vmware/photon-controller-cli/photon/mocks"
+
+	"github.com/urfave/cli"
+	"github.com/vmware/photon-controller-go-sdk/photon"
 )
 
 func TestTimestampToString(t *testing.T) {
@@ -31,3 +40,55 @@ func TestTimestampToString(t *testing.T) {
 		//("2006-01-02 03:04:05.00")
 	}
 }
+
+func TestPrintSubmittedTask(t *testing.T) {
+	server := mocks.NewTestServer()
+	defer server.Close()
+
+	registerJSONResponder(t, "GET", server.URL+rootUrl+"/tasks/async-task-id",
+		&photon.Task{ID: "async-task-id", Operation: "CREATE_ZONE", State: "QUEUED"})
+
+	mocks.Activate(true)
+	httpClient := &http.Client{Transport: mocks.DefaultMockTransport}
+	client.Photonclient = photon.NewTestClient(server.URL, nil, httpClient)
+
+	globalSet := flag.NewFlagSet("test", 0)
+	globalSet.Bool("async", false, "")
+	globalSet.Bool("non-interactive", false, "")
+	err := globalSet.Parse([]string{"--async", "--non-interactive"})
+	if err != nil {
+		t.Error("Not expecting arguments parsing to fail")
+	}
+	cxt := cli.NewContext(nil, flag.NewFlagSet("test", 0), cli.NewContext(nil, globalSet, nil))
+	if !isAsync(cxt) {
+		t.Error("Expecting --async to be detected")
+	}
+
+	var buf bytes.Buffer
+	err = printSubmittedTask("async-task-id", cxt, &buf)
+	if err != nil {
+		t.Error("Not expecting error printing task: ", err)
+	}
+	if buf.String() != "async-task-id\n" {
+		t.Errorf("Expecting only the task ID, got '%s'", buf.String())
+	}
+
+	globalSet = flag.NewFlagSet("test", 0)
+	globalSet.Bool("async", false, "")
+	globalSet.String("output", "", "")
+	err = globalSet.Parse([]string{"--async", "--output", "json"})
+	if err != nil {
+		t.Error("Not expecting arguments parsing to fail")
+	}
+	cxt = cli.NewContext(nil, flag.NewFlagSet("test", 0), cli.NewContext(nil, globalSet, nil))
+
+	buf.Reset()
+	err = printSubmittedTask("async-task-id", cxt, &buf)
+	if err != nil {
+		t.Error("Not expecting error printing task: ", err)
+	}
+	err = checkRegExp(`"state":\s*"QUEUED"`, buf)
+	if err != nil {
+		t.Errorf("Expecting the task as JSON: %s", err)
+	}
+}
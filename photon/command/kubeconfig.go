@@ -0,0 +1,185 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package command
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// The cluster, user and context of a Kubernetes service in a kubeconfig file
+type kubeconfigEntries struct {
+	ClusterName string
+	Server      string
+	// PEM certificates the API server is verified with, it is not verified when empty
+	ClusterCA []byte
+
+	UserName string
+	// Configuration of the oidc auth-provider of the user
+	AuthProvider yaml.MapSlice
+
+	ContextName string
+}
+
+func (e *kubeconfigEntries) cluster() yaml.MapSlice {
+	cluster := yaml.MapSlice{{Key: "server", Value: e.Server}}
+	if len(e.ClusterCA) != 0 {
+		cluster = append(cluster, yaml.MapItem{
+			Key: "certificate-authority-data", Value: base64.StdEncoding.EncodeToString(e.ClusterCA)})
+	} else {
+		cluster = append(cluster, yaml.MapItem{Key: "insecure-skip-tls-verify", Value: true})
+	}
+	return yaml.MapSlice{{Key: "name", Value: e.ClusterName}, {Key: "cluster", Value: cluster}}
+}
+
+// Warns that kubectl will not verify the API server of the cluster when no CA was given for it
+func printKubeconfigWarning(e *kubeconfigEntries, w io.Writer) {
+	if len(e.ClusterCA) == 0 {
+		fmt.Fprintf(w, "Warning: cluster %s is added with insecure-skip-tls-verify, kubectl will not verify "+
+			"its API server. Use --certificate-authority to give the CA of the API server.\n", e.ClusterName)
+	}
+}
+
+func (e *kubeconfigEntries) user() yaml.MapSlice {
+	authProvider := yaml.MapSlice{{Key: "name", Value: "oidc"}, {Key: "config", Value: e.AuthProvider}}
+	return yaml.MapSlice{
+		{Key: "name", Value: e.UserName},
+		{Key: "user", Value: yaml.MapSlice{{Key: "auth-provider", Value: authProvider}}},
+	}
+}
+
+func (e *kubeconfigEntries) context() yaml.MapSlice {
+	context := yaml.MapSlice{{Key: "cluster", Value: e.ClusterName}, {Key: "user", Value: e.UserName}}
+	return yaml.MapSlice{{Key: "name", Value: e.ContextName}, {Key: "context", Value: context}}
+}
+
+// Adds the entries to the kubeconfig file, creating it if needed. Entries of the file with
+// the same names are replaced, everything else in it is kept as is. The current context is
+// switched to the new one when useContext is set or when the file has none.
+func mergeKubeconfig(path string, entries *kubeconfigEntries, useContext bool) error {
+	config := yaml.MapSlice{}
+	data, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil {
+		err = yaml.Unmarshal(data, &config)
+		if err != nil {
+			return fmt.Errorf("Could not parse kubeconfig file %s: %v", path, err)
+		}
+	}
+	if len(config) == 0 {
+		config = yaml.MapSlice{
+			{Key: "apiVersion", Value: "v1"},
+			{Key: "kind", Value: "Config"},
+			{Key: "preferences", Value: yaml.MapSlice{}},
+		}
+	}
+
+	for _, entry := range []struct {
+		key   string
+		value yaml.MapSlice
+	}{
+		{"clusters", entries.cluster()},
+		{"users", entries.user()},
+		{"contexts", entries.context()},
+	} {
+		list, err := upsertNamed(config, entry.key, entry.value)
+		if err != nil {
+			return fmt.Errorf("Could not update kubeconfig file %s: %v", path, err)
+		}
+		config = setMapItem(config, entry.key, list)
+	}
+	if current, _ := getMapItem(config, "current-context"); useContext || current == nil || current == "" {
+		config = setMapItem(config, "current-context", entries.ContextName)
+	}
+
+	data, err = yaml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomically(path, data, 0600)
+}
+
+// Returns the list under the key with the entry replacing the one of the same name, or
+// appended to it
+func upsertNamed(config yaml.MapSlice, key string, entry yaml.MapSlice) ([]interface{}, error) {
+	value, _ := getMapItem(config, key)
+	if value == nil {
+		return []interface{}{entry}, nil
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s is not a list", key)
+	}
+	name, _ := getMapItem(entry, "name")
+	for i, item := range list {
+		if existing, ok := item.(yaml.MapSlice); ok {
+			if existingName, _ := getMapItem(existing, "name"); existingName == name {
+				list[i] = entry
+				return list, nil
+			}
+		}
+	}
+	return append(list, entry), nil
+}
+
+func getMapItem(m yaml.MapSlice, key string) (interface{}, bool) {
+	for _, item := range m {
+		if item.Key == key {
+			return item.Value, true
+		}
+	}
+	return nil, false
+}
+
+func setMapItem(m yaml.MapSlice, key string, value interface{}) yaml.MapSlice {
+	for i, item := range m {
+		if item.Key == key {
+			m[i].Value = value
+			return m
+		}
+	}
+	return append(m, yaml.MapItem{Key: key, Value: value})
+}
+
+// Writes to a temporary file next to the file and renames it, so that the file is never
+// left half written
+func writeFileAtomically(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return err
+	}
+	file, err := ioutil.TempFile(dir, filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(file.Name(), perm)
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(file.Name())
+	}
+	return err
+}
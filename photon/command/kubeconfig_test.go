@@ -0,0 +1,184 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package command
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+const existingKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: other
+  cluster:
+    server: https://10.0.0.1:6443
+    certificate-authority: /etc/other-ca.pem
+- name: k8s
+  cluster:
+    server: https://10.0.0.2:6443
+users:
+- name: other-user
+  user:
+    token: abc
+contexts:
+- name: other-context
+  context:
+    cluster: other
+    user: other-user
+    namespace: dev
+current-context: other-context
+`
+
+type testKubeconfig struct {
+	Clusters []struct {
+		Name    string
+		Cluster map[string]interface{}
+	}
+	Users []struct {
+		Name string
+		User struct {
+			Token        string
+			AuthProvider struct {
+				Name   string
+				Config map[string]string
+			} `yaml:"auth-provider"`
+		}
+	}
+	Contexts []struct {
+		Name    string
+		Context map[string]string
+	}
+	CurrentContext string `yaml:"current-context"`
+}
+
+func readTestKubeconfig(t *testing.T, path string) *testKubeconfig {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal("Not expecting error reading kubeconfig: ", err)
+	}
+	config := &testKubeconfig{}
+	err = yaml.Unmarshal(data, config)
+	if err != nil {
+		t.Fatal("Not expecting error parsing kubeconfig: ", err)
+	}
+	return config
+}
+
+func TestMergeKubeconfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubeconfig")
+	if err != nil {
+		t.Fatal("Not expecting error creating temp dir: ", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config")
+	err = ioutil.WriteFile(path, []byte(existingKubeconfig), 0600)
+	if err != nil {
+		t.Fatal("Not expecting error writing kubeconfig: ", err)
+	}
+
+	entries := &kubeconfigEntries{
+		ClusterName:  "k8s",
+		Server:       "https://10.0.0.3:6443",
+		ClusterCA:    []byte("ca"),
+		UserName:     "k8s-admin@tenant",
+		AuthProvider: yaml.MapSlice{{Key: "id-token", Value: "token"}},
+		ContextName:  "k8s-context",
+	}
+	err = mergeKubeconfig(path, entries, false)
+	if err != nil {
+		t.Fatal("Not expecting error merging kubeconfig: ", err)
+	}
+	config := readTestKubeconfig(t, path)
+	if len(config.Clusters) != 2 || config.Clusters[0].Cluster["certificate-authority"] != "/etc/other-ca.pem" {
+		t.Errorf("Expecting the other cluster to be kept, got %+v", config.Clusters)
+	}
+	cluster := config.Clusters[1].Cluster
+	if cluster["server"] != entries.Server || cluster["certificate-authority-data"] != "Y2E=" ||
+		cluster["insecure-skip-tls-verify"] != nil {
+		t.Errorf("Expecting the k8s cluster to be replaced, got %+v", cluster)
+	}
+	if len(config.Users) != 2 || config.Users[0].User.Token != "abc" ||
+		config.Users[1].User.AuthProvider.Name != "oidc" ||
+		config.Users[1].User.AuthProvider.Config["id-token"] != "token" {
+		t.Errorf("Expecting the oidc user to be added, got %+v", config.Users)
+	}
+	if len(config.Contexts) != 2 || config.Contexts[0].Context["namespace"] != "dev" ||
+		config.Contexts[1].Context["user"] != entries.UserName {
+		t.Errorf("Expecting the context to be added, got %+v", config.Contexts)
+	}
+	if config.CurrentContext != "other-context" {
+		t.Errorf("Expecting the current context to be kept, got %s", config.CurrentContext)
+	}
+
+	// Merging again replaces the entries and switches the current context
+	entries.ClusterCA = nil
+	err = mergeKubeconfig(path, entries, true)
+	if err != nil {
+		t.Fatal("Not expecting error merging kubeconfig: ", err)
+	}
+	config = readTestKubeconfig(t, path)
+	if len(config.Clusters) != 2 || len(config.Users) != 2 || len(config.Contexts) != 2 {
+		t.Errorf("Expecting the entries to be replaced, got %+v", config)
+	}
+	if config.Clusters[1].Cluster["insecure-skip-tls-verify"] != true {
+		t.Errorf("Expecting the cluster not to be verified without CA, got %+v", config.Clusters[1])
+	}
+	if config.CurrentContext != "k8s-context" {
+		t.Errorf("Expecting the current context to be switched, got %s", config.CurrentContext)
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expecting the kubeconfig to be private, got %v, %v", info, err)
+	}
+}
+
+func TestMergeNewKubeconfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubeconfig")
+	if err != nil {
+		t.Fatal("Not expecting error creating temp dir: ", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, ".kube", "config")
+
+	entries := &kubeconfigEntries{ClusterName: "k8s", Server: "https://10.0.0.3:6443",
+		UserName: "k8s-admin", ContextName: "k8s-context"}
+	err = mergeKubeconfig(path, entries, false)
+	if err != nil {
+		t.Fatal("Not expecting error creating kubeconfig: ", err)
+	}
+	config := readTestKubeconfig(t, path)
+	if len(config.Clusters) != 1 || len(config.Users) != 1 || len(config.Contexts) != 1 ||
+		config.CurrentContext != "k8s-context" {
+		t.Errorf("Expecting a kubeconfig with the service entries, got %+v", config)
+	}
+}
+
+func TestKubeconfigWarning(t *testing.T) {
+	entries := &kubeconfigEntries{ClusterName: "k8s", Server: "https://10.0.0.3:6443"}
+	var buf bytes.Buffer
+	printKubeconfigWarning(entries, &buf)
+	err := checkRegExp(`Warning: cluster k8s is added with insecure-skip-tls-verify`, buf)
+	if err != nil {
+		t.Errorf("Expecting a warning for a cluster without CA: %s", err)
+	}
+
+	entries.ClusterCA = []byte("-----BEGIN CERTIFICATE-----")
+	buf.Reset()
+	printKubeconfigWarning(entries, &buf)
+	if buf.Len() != 0 {
+		t.Errorf("Not expecting a warning for a cluster with a CA, got %s", buf.String())
+	}
+}
@@ -402,7 +402,7 @@ func createProject(c *cli.Context, w io.Writer) error {
 		}
 
 		id, err := waitOnTaskOperation(createTask.ID, c)
-		if err != nil {
+		if err != nil || isAsync(c) {
 			return err
 		}
 
@@ -439,7 +439,7 @@ func deleteProject(c *cli.Context) error {
 		return err
 	}
 	_, err = waitOnTaskOperation(deleteTask.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -554,11 +554,12 @@ func setProject(c *cli.Context) error {
 		return err
 	}
 
-	if config == nil || config.Tenant == nil {
-		return fmt.Errorf("Error: Set tenant first using 'tenant set <name>' or '-t <name>' option")
+	tenant, err := verifyTenant("")
+	if err != nil {
+		return err
 	}
 
-	project, err := findProject(config.Tenant.ID, name)
+	project, err := findProject(tenant.ID, name)
 	if err != nil {
 		return err
 	}
@@ -691,7 +692,7 @@ func setSecurityGroupsForProject(c *cli.Context) error {
 	}
 
 	_, err = waitOnTaskOperation(task.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -772,7 +773,7 @@ func modifyProjectIamPolicy(c *cli.Context, w io.Writer, action string) error {
 	}
 
 	_, err = waitOnTaskOperation(task.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -205,7 +205,7 @@ func createRouter(c *cli.Context, w io.Writer) error {
 			return err
 		}
 		routerID, err := waitOnTaskOperation(createTask.ID, c)
-		if err != nil {
+		if err != nil || isAsync(c) {
 			return err
 		}
 
@@ -239,7 +239,7 @@ func deleteRouter(c *cli.Context) error {
 		return err
 	}
 	_, err = waitOnTaskOperation(deleteTask.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -360,7 +360,7 @@ func updateRouter(c *cli.Context, w io.Writer) error {
 	}
 
 	id, err = waitOnTaskOperation(updateRouterTask.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -0,0 +1,332 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package command
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/urfave/cli"
+	"github.com/vmware/photon-controller-go-sdk/photon"
+
+	"github.com/vmware/photon-controller-cli/photon/client"
+	"github.com/vmware/photon-controller-cli/photon/utils"
+)
+
+// Number of VMs whose networks are looked up at the same time
+const healthNetworkLookups = 10
+
+// Roles of the service VMs, in the order they are reported. VMs are tagged
+// service:<service-id>:<role>.
+var serviceRoles = []string{"master", "etcd", "worker"}
+
+// The overall health of a service, with the problems that make it unhealthy
+type serviceHealthReport struct {
+	ServiceID string              `json:"serviceId"`
+	Name      string              `json:"name"`
+	Type      string              `json:"type"`
+	State     string              `json:"state"`
+	Healthy   bool                `json:"healthy"`
+	Roles     []serviceRoleHealth `json:"roles"`
+	VMs       []serviceVMHealth   `json:"vms"`
+	Problems  []string            `json:"problems"`
+}
+
+// Expected and actual number of VMs with a role. Expected is -1 when the service does
+// not tell how many there should be.
+type serviceRoleHealth struct {
+	Role     string `json:"role"`
+	Expected int    `json:"expected"`
+	Actual   int    `json:"actual"`
+}
+
+type serviceVMHealth struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Role      string `json:"role"`
+	State     string `json:"state"`
+	IPAddress string `json:"ipAddress"`
+	// Why the IP address could not be looked up
+	Error string `json:"error,omitempty"`
+}
+
+// Checks the state of a service, the number of VMs it has for each role and that they are
+// started with an IP address. Returns an error, and so exits with a non-zero status, when
+// the service is not healthy.
+func showServiceHealth(c *cli.Context, w io.Writer) error {
+	err := checkArgCount(c, 1)
+	if err != nil {
+		return err
+	}
+	id := c.Args().First()
+
+	client.Photonclient, err = client.GetClient(c)
+	if err != nil {
+		return err
+	}
+
+	service, err := client.Photonclient.Services.Get(id)
+	if err != nil {
+		return err
+	}
+	vms, err := client.Photonclient.Services.GetVMs(id)
+	if err != nil {
+		return err
+	}
+
+	// Resolved once, the networks of the VMs are looked up at the same time
+	options, err := getTaskPollOptions()
+	if err != nil {
+		return err
+	}
+	report := checkServiceHealth(service, lookupServiceVMs(vms.Items, options))
+
+	if c.GlobalIsSet("non-interactive") {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", report.ServiceID, report.Name, report.State, healthVerdict(report.Healthy))
+		for _, problem := range report.Problems {
+			fmt.Fprintf(w, "%s\n", problem)
+		}
+	} else if utils.NeedsFormatting(c) {
+		utils.FormatObject(report, w, c)
+	} else {
+		err = printServiceHealth(report, w)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !report.Healthy {
+		return fmt.Errorf("Service %s is not healthy", report.ServiceID)
+	}
+	return nil
+}
+
+// Looks up the role and IP address of the VMs, the IP address only for started VMs
+func lookupServiceVMs(vms []photon.VM, options *taskPollOptions) []serviceVMHealth {
+	results := make([]serviceVMHealth, len(vms))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < healthNetworkLookups; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				vm := vms[index]
+				result := serviceVMHealth{ID: vm.ID, Name: vm.Name, Role: serviceVMRole(vm), State: vm.State}
+				if vm.State == "STARTED" {
+					address, err := lookupVMAddress(vm.ID, options)
+					if err != nil {
+						result.Error = err.Error()
+					}
+					result.IPAddress = address
+				}
+				results[index] = result
+			}
+		}()
+	}
+	for index := range vms {
+		jobs <- index
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+func serviceVMRole(vm photon.VM) string {
+	for _, tag := range vm.Tags {
+		parts := strings.Split(tag, ":")
+		if len(parts) == 3 && parts[0] == "service" {
+			return strings.ToLower(parts[2])
+		}
+	}
+	return "-"
+}
+
+// Returns the IP address of the VM, or an empty string if it has none
+func lookupVMAddress(id string, options *taskPollOptions) (string, error) {
+	task, err := client.Photonclient.VMs.GetNetworks(id)
+	if err != nil {
+		return "", err
+	}
+	task, err = pollTaskWithOptions(client.Photonclient, task.ID, options, false)
+	if err != nil {
+		return "", err
+	}
+	properties, ok := task.ResourceProperties.(map[string]interface{})
+	if !ok {
+		return "", nil
+	}
+	networks, _ := properties["networkConnections"].([]interface{})
+	address := vmIPAddress(networks)
+	if address == "-" {
+		address = ""
+	}
+	return address, nil
+}
+
+// Builds the report from the service and its VMs
+func checkServiceHealth(service *photon.Service, vms []serviceVMHealth) *serviceHealthReport {
+	report := &serviceHealthReport{
+		ServiceID: service.ID,
+		Name:      service.Name,
+		Type:      service.Type,
+		State:     service.State,
+		VMs:       vms,
+		Problems:  []string{},
+	}
+
+	if service.State != "READY" {
+		problem := fmt.Sprintf("Service is %s", service.State)
+		if len(service.ErrorReason) != 0 {
+			problem += ": " + service.ErrorReason
+		}
+		report.Problems = append(report.Problems, problem)
+	}
+
+	actual := map[string]int{}
+	for _, vm := range vms {
+		actual[vm.Role]++
+	}
+	expected := expectedServiceVMs(service)
+	roles := append([]string{}, serviceRoles...)
+	extraRoles := []string{}
+	for role := range actual {
+		if _, ok := expected[role]; !ok && !isServiceRole(role) {
+			extraRoles = append(extraRoles, role)
+		}
+	}
+	sort.Strings(extraRoles)
+	roles = append(roles, extraRoles...)
+
+	for _, role := range roles {
+		count, known := expected[role]
+		if !known {
+			count = -1
+		}
+		if count <= 0 && actual[role] == 0 {
+			continue
+		}
+		report.Roles = append(report.Roles, serviceRoleHealth{Role: role, Expected: count, Actual: actual[role]})
+		if known && count != actual[role] {
+			report.Problems = append(report.Problems,
+				fmt.Sprintf("Expected %d %s VMs, found %d", count, role, actual[role]))
+		}
+	}
+
+	for _, vm := range vms {
+		switch {
+		case vm.State != "STARTED":
+			report.Problems = append(report.Problems, fmt.Sprintf("VM %s (%s) is %s", vm.Name, vm.ID, vm.State))
+		case len(vm.Error) != 0:
+			report.Problems = append(report.Problems,
+				fmt.Sprintf("Could not get the IP address of VM %s (%s): %s", vm.Name, vm.ID, vm.Error))
+		case len(vm.IPAddress) == 0:
+			report.Problems = append(report.Problems, fmt.Sprintf("VM %s (%s) has no IP address", vm.Name, vm.ID))
+		}
+	}
+
+	report.Healthy = len(report.Problems) == 0
+	return report
+}
+
+// Returns the number of VMs the service should have by role, as far as its type and extended
+// properties tell
+func expectedServiceVMs(service *photon.Service) map[string]int {
+	properties := service.ExtendedProperties
+	countOf := func(numberKey string, ipKeys ...string) (int, bool) {
+		if count, err := strconv.Atoi(properties[numberKey]); err == nil && count > 0 {
+			return count, true
+		}
+		count := 0
+		for _, key := range ipKeys {
+			if len(properties[key]) != 0 {
+				count++
+			}
+		}
+		return count, count > 0
+	}
+
+	expected := map[string]int{}
+	switch service.Type {
+	case "KUBERNETES":
+		if count, ok := countOf(photon.ExtendedPropertyNumberOfMasters,
+			photon.ExtendedPropertyMasterIP, photon.ExtendedPropertyMasterIP2); ok {
+			expected["master"] = count
+		}
+		if count, ok := countOf(photon.ExtendedPropertyNumberOfETCDs,
+			photon.ExtendedPropertyETCDIP1, photon.ExtendedPropertyETCDIP2, photon.ExtendedPropertyETCDIP3); ok {
+			expected["etcd"] = count
+		}
+		expected["worker"] = service.WorkerCount
+	case "HARBOR":
+		expected["master"] = 1
+	}
+	return expected
+}
+
+func isServiceRole(role string) bool {
+	for _, serviceRole := range serviceRoles {
+		if role == serviceRole {
+			return true
+		}
+	}
+	return false
+}
+
+func healthVerdict(healthy bool) string {
+	if healthy {
+		return "HEALTHY"
+	}
+	return "UNHEALTHY"
+}
+
+func printServiceHealth(report *serviceHealthReport, w io.Writer) error {
+	fmt.Fprintf(w, "Service ID:  %s\n", report.ServiceID)
+	fmt.Fprintf(w, "  Name:      %s\n", report.Name)
+	fmt.Fprintf(w, "  Type:      %s\n", report.Type)
+	fmt.Fprintf(w, "  State:     %s\n", report.State)
+	fmt.Fprintf(w, "  Health:    %s\n\n", healthVerdict(report.Healthy))
+
+	tw := new(tabwriter.Writer)
+	tw.Init(w, 4, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Role\tExpected\tActual\n")
+	for _, role := range report.Roles {
+		expected := "-"
+		if role.Expected >= 0 {
+			expected = strconv.Itoa(role.Expected)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\n", role.Role, expected, role.Actual)
+	}
+	fmt.Fprintf(tw, "\nVM ID\tVM Name\tRole\tState\tVM IP\n")
+	for _, vm := range report.VMs {
+		address := vm.IPAddress
+		if len(address) == 0 {
+			address = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", vm.ID, vm.Name, vm.Role, vm.State, address)
+	}
+	err := tw.Flush()
+	if err != nil {
+		return err
+	}
+
+	if len(report.Problems) != 0 {
+		fmt.Fprintf(w, "\nProblems:\n")
+		for _, problem := range report.Problems {
+			fmt.Fprintf(w, "  %s\n", problem)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/vmware/photon-controller-cli/photon/client"
+	"github.com/vmware/photon-controller-cli/photon/mocks"
+
+	"github.com/urfave/cli"
+	"github.com/vmware/photon-controller-go-sdk/photon"
+)
+
+func TestServiceHealth(t *testing.T) {
+	service := &photon.Service{
+		ID:          "fake_service_id",
+		Name:        "k8s",
+		State:       "READY",
+		Type:        "KUBERNETES",
+		WorkerCount: 1,
+		ExtendedProperties: map[string]string{
+			photon.ExtendedPropertyMasterIP: "192.0.2.20",
+			photon.ExtendedPropertyETCDIP1:  "192.0.2.21",
+		},
+	}
+	serviceResponse, err := json.Marshal(service)
+	if err != nil {
+		t.Error("Not expecting error serializing service")
+	}
+	vms := photon.VMs{Items: []photon.VM{
+		{ID: "master_vm_id", Name: "master-1", State: "STARTED", Tags: []string{"service:fake_service_id:master"}},
+		{ID: "etcd_vm_id", Name: "etcd-1", State: "STARTED", Tags: []string{"service:fake_service_id:etcd"}},
+		{ID: "worker_vm_id", Name: "worker-1", State: "STARTED", Tags: []string{"service:fake_service_id:worker"}},
+	}}
+	vmsResponse, err := json.Marshal(vms)
+	if err != nil {
+		t.Error("Not expecting error serializing VMs")
+	}
+
+	server = mocks.NewTestServer()
+	defer server.Close()
+
+	mocks.RegisterResponder("GET", server.URL+rootUrl+"/services/"+service.ID,
+		mocks.CreateResponder(200, string(serviceResponse)))
+	mocks.RegisterResponder("GET", server.URL+rootUrl+"/services/"+service.ID+"/vms",
+		mocks.CreateResponder(200, string(vmsResponse)))
+	for i, vm := range vms.Items {
+		task := &photon.Task{ID: vm.ID + "_networks_task", Operation: "GET_NETWORKS", State: "COMPLETED",
+			ResourceProperties: map[string]interface{}{"networkConnections": []interface{}{
+				map[string]interface{}{"network": "VM Network", "ipAddress": "192.0.2.3" + strconv.Itoa(i)},
+			}}}
+		taskResponse, err := json.Marshal(task)
+		if err != nil {
+			t.Error("Not expecting error serializing task")
+		}
+		mocks.RegisterResponder("GET", server.URL+rootUrl+"/vms/"+vm.ID+"/subnets",
+			mocks.CreateResponder(200, string(taskResponse)))
+		mocks.RegisterResponder("GET", server.URL+rootUrl+"/tasks/"+task.ID,
+			mocks.CreateResponder(200, string(taskResponse)))
+	}
+	mocks.Activate(true)
+
+	httpClient := &http.Client{Transport: mocks.DefaultMockTransport}
+	client.Photonclient = photon.NewTestClient(server.URL, nil, httpClient)
+
+	globalSet := flag.NewFlagSet("test", 0)
+	globalSet.String("output", "json", "output")
+	err = globalSet.Parse([]string{"--output", "json"})
+	if err != nil {
+		t.Error("Not expecting global argument parsing to fail")
+	}
+	globalCtx := cli.NewContext(nil, globalSet, nil)
+	set := flag.NewFlagSet("test", 0)
+	err = set.Parse([]string{service.ID})
+	if err != nil {
+		t.Error("Not expecting argument parsing to fail")
+	}
+	ctx := cli.NewContext(nil, set, globalCtx)
+
+	var output bytes.Buffer
+	err = showServiceHealth(ctx, &output)
+	if err != nil {
+		t.Error("Not expecting the service to be unhealthy: " + err.Error())
+	}
+	var report serviceHealthReport
+	err = json.Unmarshal(output.Bytes(), &report)
+	if err != nil {
+		t.Error("Not expecting error parsing the report: " + err.Error())
+	}
+	if !report.Healthy || len(report.Roles) != 3 || len(report.VMs) != 3 || report.VMs[2].IPAddress != "192.0.2.32" {
+		t.Errorf("Expecting a healthy service with 3 VMs, got %+v", report)
+	}
+}
+
+func TestCheckServiceHealth(t *testing.T) {
+	service := &photon.Service{ID: "fake_service_id", State: "ERROR", ErrorReason: "etcd failed", Type: "KUBERNETES",
+		WorkerCount: 2, ExtendedProperties: map[string]string{
+			photon.ExtendedPropertyNumberOfMasters: "1", photon.ExtendedPropertyNumberOfETCDs: "1"}}
+	vms := []serviceVMHealth{
+		{ID: "1", Name: "master-1", Role: "master", State: "STARTED", IPAddress: "192.0.2.20"},
+		{ID: "2", Name: "etcd-1", Role: "etcd", State: "ERROR"},
+		{ID: "3", Name: "worker-1", Role: "worker", State: "STARTED"},
+	}
+
+	report := checkServiceHealth(service, vms)
+	expected := []string{
+		"Service is ERROR: etcd failed",
+		"Expected 2 worker VMs, found 1",
+		"VM etcd-1 (2) is ERROR",
+		"VM worker-1 (3) has no IP address",
+	}
+	if report.Healthy || len(report.Problems) != len(expected) {
+		t.Fatalf("Expecting %d problems, got %+v", len(expected), report.Problems)
+	}
+	for i, problem := range expected {
+		if report.Problems[i] != problem {
+			t.Errorf("Expecting problem '%s', got '%s'", problem, report.Problems[i])
+		}
+	}
+
+	harbor := &photon.Service{ID: "fake_harbor_id", State: "READY", Type: "HARBOR"}
+	report = checkServiceHealth(harbor, []serviceVMHealth{
+		{ID: "1", Name: "harbor", Role: "master", State: "STARTED", IPAddress: "192.0.2.40"}})
+	if !report.Healthy || len(report.Roles) != 1 {
+		t.Errorf("Expecting a healthy Harbor service, got %+v", report)
+	}
+}
@@ -0,0 +1,249 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package command
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/urfave/cli"
+	"github.com/vmware/photon-controller-go-sdk/photon"
+
+	"github.com/vmware/photon-controller-cli/photon/client"
+	"github.com/vmware/photon-controller-cli/photon/utils"
+)
+
+// What an upgrade did, or would do with --dry-run, and the image each VM ended up on
+type serviceUpgradeReport struct {
+	ServiceID   string             `json:"serviceId"`
+	Name        string             `json:"name"`
+	FromImageID string             `json:"fromImageId"`
+	ToImageID   string             `json:"toImageId"`
+	DryRun      bool               `json:"dryRun"`
+	State       string             `json:"state"`
+	VMs         []serviceUpgradeVM `json:"vms"`
+	Error       string             `json:"error,omitempty"`
+	// Progress reported by the service after the upgrade
+	UpgradeStatus *photon.ServiceUpgradeStatus `json:"upgradeStatus,omitempty"`
+}
+
+type serviceUpgradeVM struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Role    string `json:"role"`
+	State   string `json:"state"`
+	ImageID string `json:"imageId"`
+}
+
+// Upgrades a service to a new image once the image is READY and the service is READY, then
+// waits for the service to be READY again. When anything fails it reports the image each VM
+// is on, and how to go back to the previous image.
+func upgradeService(c *cli.Context, w io.Writer) error {
+	err := checkArgCount(c, 1)
+	if err != nil {
+		return err
+	}
+	serviceID := c.Args().First()
+	imageID := c.String("image-id")
+	dryRun := c.Bool("dry-run")
+
+	if len(imageID) == 0 {
+		return fmt.Errorf("Please provide the image to upgrade to with --image-id")
+	}
+	if isAsync(c) {
+		return fmt.Errorf("service upgrade waits for the service to be ready and cannot be used with --async")
+	}
+
+	client.Photonclient, err = client.GetClient(c)
+	if err != nil {
+		return err
+	}
+
+	service, err := client.Photonclient.Services.Get(serviceID)
+	if err != nil {
+		return err
+	}
+	report := &serviceUpgradeReport{
+		ServiceID:   service.ID,
+		Name:        service.Name,
+		FromImageID: service.ImageID,
+		ToImageID:   imageID,
+		DryRun:      dryRun,
+		State:       service.State,
+	}
+	err = checkServiceUpgrade(service, imageID)
+	if err != nil {
+		return err
+	}
+	report.VMs, err = serviceUpgradeInventory(serviceID)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		return printServiceUpgrade(report, w, c)
+	}
+
+	if !c.GlobalIsSet("non-interactive") && !utils.NeedsFormatting(c) {
+		err = printServiceUpgrade(report, w, c)
+		if err != nil {
+			return err
+		}
+		if !confirmed(c) {
+			fmt.Fprintf(w, "Cancelled\n")
+			return nil
+		}
+	}
+
+	upgradeErr := runServiceUpgrade(serviceID, imageID, c)
+
+	// Record where the VMs ended up, also when the upgrade went well
+	service, err = client.Photonclient.Services.Get(serviceID)
+	if err == nil {
+		report.State = service.State
+		report.UpgradeStatus = service.UpgradeStatus
+		report.VMs, err = serviceUpgradeInventory(serviceID)
+	}
+	if upgradeErr != nil {
+		report.Error = upgradeErr.Error()
+	}
+	if err != nil {
+		if upgradeErr != nil {
+			return fmt.Errorf("%s, and the VMs of the service could not be listed: %s", upgradeErr, err)
+		}
+		return err
+	}
+
+	if utils.NeedsFormatting(c) {
+		utils.FormatObject(report, w, c)
+	} else if upgradeErr != nil || c.GlobalIsSet("non-interactive") {
+		err = printServiceUpgrade(report, w, c)
+		if err != nil {
+			return err
+		}
+	}
+	if upgradeErr != nil {
+		if !c.GlobalIsSet("non-interactive") && !utils.NeedsFormatting(c) {
+			fmt.Fprintf(w, "\nThe upgrade did not complete. Once the service is READY, you can go back to\n"+
+				"the previous image with:\n  photon service upgrade %s --image-id %s\n", serviceID, report.FromImageID)
+		}
+		return upgradeErr
+	}
+	if !c.GlobalIsSet("non-interactive") && !utils.NeedsFormatting(c) {
+		fmt.Fprintf(w, "Service %s is ready and uses image %s\n", serviceID, imageID)
+	}
+	return nil
+}
+
+// Pre-checks: the service is READY and not already on the image, which exists and is READY
+func checkServiceUpgrade(service *photon.Service, imageID string) error {
+	if service.State != "READY" {
+		return fmt.Errorf("Service %s is %s, it can only be upgraded when READY", service.ID, service.State)
+	}
+	if service.ImageID == imageID {
+		return fmt.Errorf("Service %s already uses image %s", service.ID, imageID)
+	}
+	image, err := client.Photonclient.Images.Get(imageID)
+	if err != nil {
+		return fmt.Errorf("Could not get image %s: %s", imageID, err)
+	}
+	if image.State != "READY" {
+		return fmt.Errorf("Image %s (%s) is %s, it needs to be READY", image.Name, image.ID, image.State)
+	}
+	return nil
+}
+
+func runServiceUpgrade(serviceID string, imageID string, c *cli.Context) error {
+	task, err := client.Photonclient.Services.ChangeVersion(serviceID,
+		&photon.ServiceChangeVersionOperation{NewImageID: imageID})
+	if err != nil {
+		return err
+	}
+	if c.GlobalIsSet("non-interactive") || utils.NeedsFormatting(c) {
+		_, err = waitForTask(task.ID)
+	} else {
+		_, err = pollTask(task.ID)
+	}
+	if err != nil {
+		return err
+	}
+	service, err := waitForService(serviceID, showServiceProgress(c))
+	if err != nil {
+		return err
+	}
+	if service.ImageID != imageID {
+		return fmt.Errorf("Service %s is READY but uses image %s", serviceID, service.ImageID)
+	}
+	return nil
+}
+
+func serviceUpgradeInventory(serviceID string) ([]serviceUpgradeVM, error) {
+	vms, err := client.Photonclient.Services.GetVMs(serviceID)
+	if err != nil {
+		return nil, err
+	}
+	inventory := []serviceUpgradeVM{}
+	for _, vm := range vms.Items {
+		inventory = append(inventory, serviceUpgradeVM{
+			ID:      vm.ID,
+			Name:    vm.Name,
+			Role:    serviceVMRole(vm),
+			State:   vm.State,
+			ImageID: vm.SourceImageID,
+		})
+	}
+	return inventory, nil
+}
+
+func printServiceUpgrade(report *serviceUpgradeReport, w io.Writer, c *cli.Context) error {
+	if c.GlobalIsSet("non-interactive") {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", report.ServiceID, report.Name, report.State,
+			report.FromImageID, report.ToImageID)
+		for _, vm := range report.VMs {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", vm.ID, vm.Name, vm.Role, vm.State, vm.ImageID)
+		}
+		return nil
+	}
+	if utils.NeedsFormatting(c) {
+		utils.FormatObject(report, w, c)
+		return nil
+	}
+
+	if report.DryRun {
+		fmt.Fprintf(w, "Dry run, service %s (%s) would be upgraded\n", report.Name, report.ServiceID)
+	} else {
+		fmt.Fprintf(w, "Service %s (%s) is %s\n", report.Name, report.ServiceID, report.State)
+	}
+	fmt.Fprintf(w, "  From image:  %s\n", report.FromImageID)
+	fmt.Fprintf(w, "  To image:    %s\n", report.ToImageID)
+	if status := report.UpgradeStatus; status != nil && !report.DryRun {
+		fmt.Fprintf(w, "  Upgraded:    %d/%d nodes\n", status.NumNodesUpgraded, status.TotalNodes)
+		if len(status.UpgradeResultMessage) != 0 {
+			fmt.Fprintf(w, "  Result:      %s\n", status.UpgradeResultMessage)
+		}
+	}
+	fmt.Fprintf(w, "\n")
+
+	tw := new(tabwriter.Writer)
+	tw.Init(w, 4, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "VM ID\tVM Name\tRole\tState\tImage\n")
+	for _, vm := range report.VMs {
+		image := vm.ImageID
+		switch image {
+		case report.FromImageID:
+			image += " (old)"
+		case report.ToImageID:
+			image += " (new)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", vm.ID, vm.Name, vm.Role, vm.State, image)
+	}
+	return tw.Flush()
+}
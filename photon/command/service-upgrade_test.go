@@ -0,0 +1,168 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/vmware/photon-controller-cli/photon/client"
+	"github.com/vmware/photon-controller-cli/photon/mocks"
+
+	"github.com/urfave/cli"
+	"github.com/vmware/photon-controller-go-sdk/photon"
+)
+
+// Serves a service on the old image until the upgrade is submitted, then in the final state
+func registerUpgradeResponders(t *testing.T, final *photon.Service, finalVMs []photon.VM) *bool {
+	oldService := &photon.Service{ID: "fake_service_id", Name: "k8s", State: "READY", Type: "KUBERNETES",
+		ImageID: "old_image_id"}
+	oldVMs := []photon.VM{
+		{ID: "master_vm_id", Name: "master-1", State: "STARTED", SourceImageID: "old_image_id",
+			Tags: []string{"service:fake_service_id:master"}},
+		{ID: "worker_vm_id", Name: "worker-1", State: "STARTED", SourceImageID: "old_image_id",
+			Tags: []string{"service:fake_service_id:worker"}},
+	}
+	marshal := func(value interface{}) string {
+		response, err := json.Marshal(value)
+		if err != nil {
+			t.Error("Not expecting error serializing response")
+		}
+		return string(response)
+	}
+	task := &photon.Task{ID: "fake_change_version_task_id", Operation: "CHANGE_VERSION", State: "COMPLETED"}
+
+	submitted := false
+	mocks.RegisterResponder("GET", server.URL+rootUrl+"/services/fake_service_id",
+		func(req *http.Request) (*http.Response, error) {
+			if submitted {
+				return mocks.CreateResponder(200, marshal(final))(req)
+			}
+			return mocks.CreateResponder(200, marshal(oldService))(req)
+		})
+	mocks.RegisterResponder("GET", server.URL+rootUrl+"/services/fake_service_id/vms",
+		func(req *http.Request) (*http.Response, error) {
+			if submitted {
+				return mocks.CreateResponder(200, marshal(photon.VMs{Items: finalVMs}))(req)
+			}
+			return mocks.CreateResponder(200, marshal(photon.VMs{Items: oldVMs}))(req)
+		})
+	mocks.RegisterResponder("GET", server.URL+rootUrl+"/images/new_image_id",
+		mocks.CreateResponder(200, marshal(&photon.Image{ID: "new_image_id", Name: "k8s-1.6", State: "READY"})))
+	mocks.RegisterResponder("POST", server.URL+rootUrl+"/services/fake_service_id/change_version",
+		func(req *http.Request) (*http.Response, error) {
+			submitted = true
+			return mocks.CreateResponder(200, marshal(task))(req)
+		})
+	mocks.RegisterResponder("GET", server.URL+rootUrl+"/tasks/"+task.ID, mocks.CreateResponder(200, marshal(task)))
+	mocks.Activate(true)
+
+	httpClient := &http.Client{Transport: mocks.DefaultMockTransport}
+	client.Photonclient = photon.NewTestClient(server.URL, nil, httpClient)
+	return &submitted
+}
+
+func upgradeContext(t *testing.T, args ...string) *cli.Context {
+	globalSet := flag.NewFlagSet("test", 0)
+	globalSet.String("output", "", "output")
+	err := globalSet.Parse([]string{"--output", "json"})
+	if err != nil {
+		t.Error("Not expecting global argument parsing to fail")
+	}
+	globalCtx := cli.NewContext(nil, globalSet, nil)
+	set := flag.NewFlagSet("test", 0)
+	set.String("image-id", "", "image id")
+	set.Bool("dry-run", false, "dry run")
+	err = set.Parse(args)
+	if err != nil {
+		t.Error("Not expecting argument parsing to fail")
+	}
+	return cli.NewContext(nil, set, globalCtx)
+}
+
+func TestUpgradeService(t *testing.T) {
+	server = mocks.NewTestServer()
+	defer server.Close()
+
+	upgraded := &photon.Service{ID: "fake_service_id", Name: "k8s", State: "READY", Type: "KUBERNETES",
+		ImageID: "new_image_id"}
+	upgradedVMs := []photon.VM{
+		{ID: "master_vm_id_2", Name: "master-1", State: "STARTED", SourceImageID: "new_image_id"},
+		{ID: "worker_vm_id_2", Name: "worker-1", State: "STARTED", SourceImageID: "new_image_id"},
+	}
+	submitted := registerUpgradeResponders(t, upgraded, upgradedVMs)
+
+	var output bytes.Buffer
+	err := upgradeService(upgradeContext(t, "--image-id", "new_image_id", "--dry-run", "fake_service_id"), &output)
+	if err != nil {
+		t.Error("Not expecting error in dry run: " + err.Error())
+	}
+	var report serviceUpgradeReport
+	err = json.Unmarshal(output.Bytes(), &report)
+	if err != nil {
+		t.Error("Not expecting error parsing the report: " + err.Error())
+	}
+	if *submitted || !report.DryRun || report.FromImageID != "old_image_id" || len(report.VMs) != 2 ||
+		report.VMs[1].Role != "worker" {
+		t.Errorf("Expecting a dry run listing the VMs on the old image, got %+v", report)
+	}
+
+	err = upgradeService(upgradeContext(t, "--image-id", "old_image_id", "fake_service_id"), &output)
+	if err == nil || !strings.Contains(err.Error(), "already uses image") {
+		t.Errorf("Expecting the service to already use the image, got %v", err)
+	}
+
+	output.Reset()
+	err = upgradeService(upgradeContext(t, "--image-id", "new_image_id", "fake_service_id"), &output)
+	if err != nil {
+		t.Error("Not expecting error upgrading service: " + err.Error())
+	}
+	report = serviceUpgradeReport{}
+	err = json.Unmarshal(output.Bytes(), &report)
+	if err != nil {
+		t.Error("Not expecting error parsing the report: " + err.Error())
+	}
+	if !*submitted || report.State != "READY" || len(report.VMs) != 2 || report.VMs[0].ImageID != "new_image_id" {
+		t.Errorf("Expecting the VMs to be on the new image, got %+v", report)
+	}
+}
+
+func TestUpgradeServiceFailure(t *testing.T) {
+	server = mocks.NewTestServer()
+	defer server.Close()
+
+	failed := &photon.Service{ID: "fake_service_id", Name: "k8s", State: "ERROR", Type: "KUBERNETES",
+		ImageID: "old_image_id", UpgradeStatus: &photon.ServiceUpgradeStatus{NewImageID: "new_image_id",
+			TotalNodes: 2, NumNodesUpgraded: 1, UpgradeResultMessage: "worker-1 did not start"}}
+	failedVMs := []photon.VM{
+		{ID: "master_vm_id_2", Name: "master-1", State: "STARTED", SourceImageID: "new_image_id"},
+		{ID: "worker_vm_id", Name: "worker-1", State: "ERROR", SourceImageID: "old_image_id"},
+	}
+	registerUpgradeResponders(t, failed, failedVMs)
+
+	var output bytes.Buffer
+	err := upgradeService(upgradeContext(t, "--image-id", "new_image_id", "fake_service_id"), &output)
+	if err == nil || !strings.Contains(err.Error(), "ERROR state") {
+		t.Errorf("Expecting the upgrade to fail, got %v", err)
+	}
+	var report serviceUpgradeReport
+	err = json.Unmarshal(output.Bytes(), &report)
+	if err != nil {
+		t.Error("Not expecting error parsing the report: " + err.Error())
+	}
+	if len(report.Error) == 0 || report.UpgradeStatus == nil || len(report.VMs) != 2 ||
+		report.VMs[0].ImageID != "new_image_id" || report.VMs[1].ImageID != "old_image_id" {
+		t.Errorf("Expecting the image of each VM after the failure, got %+v", report)
+	}
+}
@@ -19,20 +19,22 @@ import (
 	"os"
 	"strconv"
 	"strings"
-	"sync"
 	"syscall"
 	"time"
 	"unicode"
 
 	"github.com/vmware/photon-controller-cli/photon/client"
+	"github.com/vmware/photon-controller-cli/photon/manifest"
 	"github.com/vmware/photon-controller-cli/photon/utils"
 
 	"github.com/urfave/cli"
 	"github.com/vmware/photon-controller-go-sdk/photon"
 
+	"encoding/base64"
 	"encoding/pem"
 	"github.com/vmware/photon-controller-go-sdk/photon/lightwave"
 	"golang.org/x/crypto/ssh/terminal"
+	"gopkg.in/yaml.v2"
 )
 
 // Creates a cli.Command for services
@@ -44,6 +46,8 @@ import (
 //              delete;              Usage: service delete <id>
 //              trigger-maintenance; Usage: service trigger-maintenance <id>
 //              cert-to-file;        Usage: service cert-to-file <id> <file_path>
+//              health;              Usage: service health <id>
+//              upgrade;             Usage: service upgrade <id> --image-id <image-id> [<options>]
 
 func GetServiceCommand() cli.Command {
 	command := cli.Command{
@@ -59,8 +63,29 @@ func GetServiceCommand() cli.Command {
 					"   photon service create -n k8-service -k KUBERNETES --dns 10.0.0.1 \\ \n" +
 					"     --gateway 192.0.2.1 --netmask 255.255.255.0 --master-ip 192.0.2.20 \\ \n" +
 					"     --container-network 10.2.0.0/16 --etcd1 192.0.2.21 \\ \n" +
-					"     -c 1 -v cluster-vm -d small-disk --ssh-key ~/.ssh/id_dsa.pub",
+					"     -c 1 -v cluster-vm -d small-disk --ssh-key ~/.ssh/id_dsa.pub \n\n" +
+					"   The service can also be described by a YAML or JSON file with the fields of the \n" +
+					"   API's service create spec plus 'tenant' and 'project'. Flags override its values: \n" +
+					"     tenant: cloud-dev \n" +
+					"     project: web \n" +
+					"     name: k8-service \n" +
+					"     type: KUBERNETES \n" +
+					"     workerCount: 3 \n" +
+					"     masterVmFlavor: cluster-vm \n" +
+					"     extendedProperties: \n" +
+					"       dns: 10.0.0.1 \n" +
+					"       gateway: 192.0.2.1 \n" +
+					"       netmask: 255.255.255.0 \n" +
+					"       master_ip: 192.0.2.20 \n" +
+					"       load_balancer_ip: 192.0.2.19 \n" +
+					"       etcd_ip1: 192.0.2.21 \n" +
+					"       container_network: 10.2.0.0/16 \n\n" +
+					"   photon service create --spec k8-service.yaml --worker_count 5",
 				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "spec",
+						Usage: "YAML or JSON file describing the service, overridden by the other flags",
+					},
 					cli.StringFlag{
 						Name:  "tenant, t",
 						Usage: "Tenant name",
@@ -344,6 +369,49 @@ func GetServiceCommand() cli.Command {
 					}
 				},
 			},
+			{
+				Name:      "upgrade",
+				Usage:     "Upgrade a service to a new image after checking it can be",
+				ArgsUsage: "service-id",
+				Description: "Check that the service is READY and that the image exists and is READY, list \n" +
+					"   the VMs of the service with their image, change the version of the service and \n" +
+					"   wait for it to be READY again. When the upgrade fails, list the image each VM is \n" +
+					"   on and how to go back to the previous image. With --dry-run, only run the checks \n" +
+					"   and list the VMs. \n\n" +
+					"   Example: photon service upgrade 9b159e92-9495-49a4-af58-53ad4764f616 \\ \n" +
+					"     -i 2aeaf034-3b02-4873-a6fc-f92615dca849 --dry-run",
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "image-id, i",
+						Usage: "ID of the image to upgrade to",
+					},
+					cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "Run the checks and list the VMs without upgrading",
+					},
+				},
+				Action: func(c *cli.Context) {
+					err := upgradeService(c, os.Stdout)
+					if err != nil {
+						log.Fatal("Error: ", err)
+					}
+				},
+			},
+			{
+				Name:      "health",
+				Usage:     "Check the health of a service",
+				ArgsUsage: "service-id",
+				Description: "Check that the service is READY, that it has the expected number of master, \n" +
+					"   etcd and worker VMs, and that they are all started with an IP address. Exits with \n" +
+					"   a non-zero status when any check fails, listing the problems found. \n\n" +
+					"   Example: photon service health 9b159e92-9495-49a4-af58-53ad4764f616",
+				Action: func(c *cli.Context) {
+					err := showServiceHealth(c, os.Stdout)
+					if err != nil {
+						log.Fatal("Error: ", err)
+					}
+				},
+			},
 			{
 				Name:      "get-kubectl-auth",
 				Usage:     "Generate the kubectl command for authentication",
@@ -354,7 +422,10 @@ func GetServiceCommand() cli.Command {
 					"9b159e92-9495-49a4-af58-53ad4764f616 \n" +
 					"To directly set the user, cluster and context for kubectl config \n" +
 					"Please run: eval \"$(photon service get-kubectl-auth -u admin -p password " +
-					"9b159e92-9495-49a4-af58-53ad4764f616)\"",
+					"9b159e92-9495-49a4-af58-53ad4764f616)\"\n" +
+					"Or add the cluster, user and context to a kubeconfig file, keeping its other entries: \n" +
+					"photon service get-kubectl-auth -u admin -p password --kubeconfig ~/.kube/config " +
+					"--use-context 9b159e92-9495-49a4-af58-53ad4764f616",
 				Flags: []cli.Flag{
 					cli.StringFlag{
 						Name:  "username, u",
@@ -364,6 +435,19 @@ func GetServiceCommand() cli.Command {
 						Name:  "password, p",
 						Usage: "Password used for Photon Controller login",
 					},
+					cli.StringFlag{
+						Name:  "kubeconfig",
+						Usage: "Kubeconfig file to add the cluster, user and context to instead of printing kubectl commands",
+					},
+					cli.BoolFlag{
+						Name:  "use-context",
+						Usage: "With --kubeconfig, make the new context the current one",
+					},
+					cli.StringFlag{
+						Name: "certificate-authority",
+						Usage: "With --kubeconfig, PEM file of the CA the Kubernetes API server is verified with, " +
+							"the server is not verified without it",
+					},
 				},
 				Action: func(c *cli.Context) {
 					err := getKubectlAuth(c)
@@ -389,31 +473,79 @@ func createService(c *cli.Context, w io.Writer) error {
 		return err
 	}
 
-	tenantName := c.String("tenant")
-	projectName := c.String("project")
-	name := c.String("name")
-	service_type := c.String("type")
-	vm_flavor := c.String("vm_flavor")
-	master_vm_flavor := c.String("master-vm-flavor")
-	worker_vm_flavor := c.String("worker-vm-flavor")
-	disk_flavor := c.String("disk_flavor")
-	subnet_id := c.String("subnet_id")
-	image_id := c.String("image-id")
-	worker_count := c.Int("worker_count")
-	dns := c.String("dns")
-	gateway := c.String("gateway")
-	netmask := c.String("netmask")
-	masterIP := c.String("master-ip")
-	masterIP2 := c.String("master-ip2")
-	loadBalancerIP := c.String("load-balancer-ip")
-	container_network := c.String("container-network")
-	etcd1 := c.String("etcd1")
-	etcd2 := c.String("etcd2")
-	etcd3 := c.String("etcd3")
-	batch_size := c.Int("batchSize")
+	spec := &manifest.Service{}
+	specFile := c.String("spec")
+	if len(specFile) != 0 {
+		spec, err = manifest.LoadService(specFile)
+		if err != nil {
+			return err
+		}
+	}
+	properties := spec.ExtendedProperties
+
+	// Flags given on the command line override the values of the spec file
+	option := func(flag string, value string) string {
+		if len(specFile) == 0 || c.IsSet(flag) {
+			return c.String(flag)
+		}
+		return value
+	}
+	intOption := func(flag string, value int) int {
+		if len(specFile) == 0 || c.IsSet(flag) {
+			return c.Int(flag)
+		}
+		return value
+	}
+	intProperty := func(key string) (int, error) {
+		if len(properties[key]) == 0 {
+			return 0, nil
+		}
+		value, err := strconv.Atoi(properties[key])
+		if err != nil {
+			return 0, fmt.Errorf("The %s extended property of the spec file should be a number", key)
+		}
+		return value, nil
+	}
+
+	tenantName := option("tenant", spec.Tenant)
+	projectName := option("project", spec.Project)
+	name := option("name", spec.Name)
+	service_type := option("type", spec.Type)
+	vm_flavor := option("vm_flavor", spec.VMFlavor)
+	master_vm_flavor := option("master-vm-flavor", spec.MasterVmFlavor)
+	worker_vm_flavor := option("worker-vm-flavor", spec.WorkerVmFlavor)
+	disk_flavor := option("disk_flavor", spec.DiskFlavor)
+	subnet_id := option("subnet_id", spec.SubnetId)
+	image_id := option("image-id", spec.ImageID)
+	worker_count := intOption("worker_count", spec.WorkerCount)
+	dns := option("dns", properties[photon.ExtendedPropertyDNS])
+	gateway := option("gateway", properties[photon.ExtendedPropertyGateway])
+	netmask := option("netmask", properties[photon.ExtendedPropertyNetMask])
+	masterIP := option("master-ip", properties[photon.ExtendedPropertyMasterIP])
+	masterIP2 := option("master-ip2", properties[photon.ExtendedPropertyMasterIP2])
+	loadBalancerIP := option("load-balancer-ip", properties[photon.ExtendedPropertyLoadBalancerIP])
+	container_network := option("container-network", properties[photon.ExtendedPropertyContainerNetwork])
+	etcd1 := option("etcd1", properties[photon.ExtendedPropertyETCDIP1])
+	etcd2 := option("etcd2", properties[photon.ExtendedPropertyETCDIP2])
+	etcd3 := option("etcd3", properties[photon.ExtendedPropertyETCDIP3])
+	batch_size := intOption("batchSize", spec.BatchSizeWorker)
+	admin_password := option("admin-password", properties[photon.ExtendedPropertyAdminPassword])
+
+	etcdCount, err := intProperty(photon.ExtendedPropertyNumberOfETCDs)
+	if err != nil {
+		return err
+	}
+	etcdCount = intOption("number-of-etcds", etcdCount)
+	masterCount, err := intProperty(photon.ExtendedPropertyNumberOfMasters)
+	if err != nil {
+		return err
+	}
+	masterCount = intOption("number-of-masters", masterCount)
+
+	// The spec file holds the SSH key and registry CA certificate themselves, the flags
+	// name files to read them from
 	ssh_key := c.String("ssh-key")
 	ca_cert := c.String("registry-ca-cert")
-	admin_password := c.String("admin-password")
 
 	if admin_password != "" {
 		result := validateHarborPassword(admin_password)
@@ -425,6 +557,9 @@ func createService(c *cli.Context, w io.Writer) error {
 
 	wait_for_ready := c.IsSet("wait-for-ready")
 
+	// A spec file describes the whole service, so nothing is asked for
+	interactive := !c.GlobalIsSet("non-interactive") && len(specFile) == 0
+
 	const DEFAULT_WORKER_COUNT = 1
 
 	client.Photonclient, err = client.GetClient(c)
@@ -442,7 +577,7 @@ func createService(c *cli.Context, w io.Writer) error {
 		return err
 	}
 
-	if !c.GlobalIsSet("non-interactive") {
+	if interactive {
 		name, err = askForInput("Service name: ", name)
 		if err != nil {
 			return err
@@ -471,7 +606,7 @@ func createService(c *cli.Context, w io.Writer) error {
 		worker_count = DEFAULT_WORKER_COUNT
 	}
 
-	if !c.GlobalIsSet("non-interactive") {
+	if interactive {
 		if !sdn {
 			dns, err = askForInput("Service DNS server: ", dns)
 			if err != nil {
@@ -492,11 +627,10 @@ func createService(c *cli.Context, w io.Writer) error {
 		}
 	}
 
-	if !sdn && (len(dns) == 0 || len(gateway) == 0 || len(netmask) == 0) {
-		return fmt.Errorf("Provide a valid DNS, gateway, and netmask")
-	}
-
 	extended_properties := make(map[string]string)
+	for key, value := range properties {
+		extended_properties[key] = value
+	}
 	extended_properties[photon.ExtendedPropertyDNS] = dns
 	extended_properties[photon.ExtendedPropertyGateway] = gateway
 	extended_properties[photon.ExtendedPropertyNetMask] = netmask
@@ -521,7 +655,7 @@ func createService(c *cli.Context, w io.Writer) error {
 	service_type = strings.ToUpper(service_type)
 	switch service_type {
 	case "KUBERNETES":
-		if !c.GlobalIsSet("non-interactive") {
+		if interactive {
 			container_network, err = askForInput("Container network CIDR: ", container_network)
 			if err != nil {
 				return err
@@ -529,9 +663,7 @@ func createService(c *cli.Context, w io.Writer) error {
 		}
 		extended_properties[photon.ExtendedPropertyContainerNetwork] = container_network
 		if sdn {
-			etcdCount := c.Int("number-of-etcds")
-			masterCount := c.Int("number-of-masters")
-			if !c.GlobalIsSet("non-interactive") {
+			if interactive {
 				etcdCount, err = askForInputInt("Number of Etcd instances: ", etcdCount)
 				if err != nil {
 					return err
@@ -544,7 +676,7 @@ func createService(c *cli.Context, w io.Writer) error {
 			extended_properties[photon.ExtendedPropertyNumberOfETCDs] = strconv.Itoa(etcdCount)
 			extended_properties[photon.ExtendedPropertyNumberOfMasters] = strconv.Itoa(masterCount)
 		} else {
-			if !c.GlobalIsSet("non-interactive") {
+			if interactive {
 				masterIP, err = askForInput("Kubernetes master 1 static IP address: ", masterIP)
 				if err != nil {
 					return err
@@ -583,27 +715,15 @@ func createService(c *cli.Context, w io.Writer) error {
 				}
 			}
 
-			if len(masterIP) == 0 {
-				return fmt.Errorf("Must specify at least one master IP")
-			}
 			extended_properties[photon.ExtendedPropertyMasterIP] = masterIP
-			if len(masterIP2) != 0 {
-				extended_properties[photon.ExtendedPropertyMasterIP2] = masterIP2
-			}
-			if len(loadBalancerIP) == 0 {
-				return fmt.Errorf("Must specify a load balancer IP")
-			}
+			setOptionalProperty(extended_properties, photon.ExtendedPropertyMasterIP2, masterIP2)
 			extended_properties[photon.ExtendedPropertyLoadBalancerIP] = loadBalancerIP
 			extended_properties[photon.ExtendedPropertyETCDIP1] = etcd1
-			if len(etcd2) != 0 {
-				extended_properties[photon.ExtendedPropertyETCDIP2] = etcd2
-				if len(etcd3) != 0 {
-					extended_properties[photon.ExtendedPropertyETCDIP3] = etcd3
-				}
-			}
+			setOptionalProperty(extended_properties, photon.ExtendedPropertyETCDIP2, etcd2)
+			setOptionalProperty(extended_properties, photon.ExtendedPropertyETCDIP3, etcd3)
 		}
 	case "HARBOR":
-		if !c.GlobalIsSet("non-interactive") {
+		if interactive {
 			masterIP, err = askForInput("Harbor master static IP address: ", masterIP)
 			if err != nil {
 				return err
@@ -621,23 +741,11 @@ func createService(c *cli.Context, w io.Writer) error {
 					return err
 				}
 				admin_password = string(bytePassword)
-
-				result := validateHarborPassword(admin_password)
-				if result != true {
-					return fmt.Errorf("The Harbor password is invalid. It should have at least 7 " +
-						"characters with 1 lowercase letter, 1 capital letter and 1 numeric " +
-						"character.")
-				}
 				fmt.Printf("\n")
 			}
 		}
-		if len(masterIP) == 0 {
-			return fmt.Errorf("Must specify at least a master IP for Harbor")
-		}
 		extended_properties[photon.ExtendedPropertyMasterIP] = masterIP
 		extended_properties[photon.ExtendedPropertyAdminPassword] = admin_password
-	default:
-		return fmt.Errorf("Unsupported service type: %s", service_type)
 	}
 
 	serviceSpec := photon.ServiceCreateSpec{}
@@ -661,6 +769,11 @@ func createService(c *cli.Context, w io.Writer) error {
 	serviceSpec.BatchSizeWorker = batch_size
 	serviceSpec.ExtendedProperties = extended_properties
 
+	err = validateServiceSpec(&serviceSpec, sdn)
+	if err != nil {
+		return err
+	}
+
 	if !c.GlobalIsSet("non-interactive") {
 		fmt.Printf("\n")
 		fmt.Printf("Creating service: %s (%s)\n", serviceSpec.Name, serviceSpec.Type)
@@ -686,7 +799,7 @@ func createService(c *cli.Context, w io.Writer) error {
 		}
 
 		_, err = waitOnTaskOperation(createTask.ID, c)
-		if err != nil {
+		if err != nil || isAsync(c) {
 			return err
 		}
 
@@ -694,7 +807,7 @@ func createService(c *cli.Context, w io.Writer) error {
 			if !utils.NeedsFormatting(c) {
 				fmt.Printf("Waiting for service %s to become ready\n", createTask.Entity.ID)
 			}
-			service, err := waitForService(createTask.Entity.ID)
+			service, err := waitForService(createTask.Entity.ID, showServiceProgress(c))
 			if err != nil {
 				return err
 			}
@@ -900,12 +1013,12 @@ func resizeService(c *cli.Context, w io.Writer) error {
 		}
 
 		_, err = waitOnTaskOperation(resizeTask.ID, c)
-		if err != nil {
+		if err != nil || isAsync(c) {
 			return err
 		}
 
 		if wait_for_ready {
-			service, err := waitForService(service_id)
+			service, err := waitForService(service_id, showServiceProgress(c))
 			if err != nil {
 				return err
 			}
@@ -957,7 +1070,7 @@ func deleteService(c *cli.Context) error {
 		}
 
 		_, err = waitOnTaskOperation(deleteTask.ID, c)
-		if err != nil {
+		if err != nil || isAsync(c) {
 			return err
 		}
 	} else {
@@ -996,7 +1109,7 @@ func triggerMaintenance(c *cli.Context) error {
 	}
 
 	_, err = waitOnTaskOperation(task.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -1045,10 +1158,10 @@ func changeVersion(c *cli.Context, w io.Writer) error {
 
 		if waitForReady {
 			_, err = waitOnTaskOperation(changeVersionTask.ID, c)
-			if err != nil {
+			if err != nil || isAsync(c) {
 				return err
 			}
-			service, err := waitForService(serviceID)
+			service, err := waitForService(serviceID, showServiceProgress(c))
 			if err != nil {
 				return err
 			}
@@ -1144,26 +1257,60 @@ func getKubectlAuth(c *cli.Context) error {
 		return err
 	}
 
-	certFileName := "lw-ca-cert-" + generateRandomString(4) + ".pem"
-	certFile, err := ioutil.TempFile(os.TempDir(), certFileName)
+	var certData []byte
+	for _, cert := range certs {
+		certData = append(certData, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Headers: nil, Bytes: cert.Raw})...)
+	}
 
+	options, err := client.Photonclient.Auth.GetClientTokensByPassword(username, password, service.ClientID)
 	if err != nil {
 		return err
 	}
 
-	for _, cert := range certs {
-		_, err := certFile.Write(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Headers: nil, Bytes: cert.Raw}))
+	loadBalancerIp := service.ExtendedProperties[photon.ExtendedPropertyLoadBalancerIP]
+	clusterName := service.Name
+
+	if kubeconfig := c.String("kubeconfig"); len(kubeconfig) != 0 {
+		entries := &kubeconfigEntries{
+			ClusterName: clusterName,
+			Server:      fmt.Sprintf("https://%s:6443", loadBalancerIp),
+			UserName:    clusterName + "-" + username,
+			AuthProvider: yaml.MapSlice{
+				{Key: "idp-issuer-url", Value: fmt.Sprintf("https://%s/openidconnect/%s", authInfo.Endpoint, authInfo.Domain)},
+				{Key: "client-id", Value: service.ClientID},
+				{Key: "client-secret", Value: service.ClientID},
+				{Key: "refresh-token", Value: options.RefreshToken},
+				{Key: "id-token", Value: options.IdToken},
+				{Key: "idp-certificate-authority-data", Value: base64.StdEncoding.EncodeToString(certData)},
+			},
+			ContextName: clusterName + "-context",
+		}
+		if caFile := c.String("certificate-authority"); len(caFile) != 0 {
+			entries.ClusterCA, err = ioutil.ReadFile(caFile)
+			if err != nil {
+				return err
+			}
+		}
+		printKubeconfigWarning(entries, os.Stderr)
+		err = mergeKubeconfig(kubeconfig, entries, c.Bool("use-context"))
 		if err != nil {
 			return err
 		}
+		fmt.Printf("Added cluster %s, user %s and context %s to %s\n",
+			entries.ClusterName, entries.UserName, entries.ContextName, kubeconfig)
+		return nil
 	}
 
-	err = certFile.Close()
+	certFileName := "lw-ca-cert-" + generateRandomString(4) + ".pem"
+	certFile, err := ioutil.TempFile(os.TempDir(), certFileName)
 	if err != nil {
 		return err
 	}
-
-	options, err := client.Photonclient.Auth.GetClientTokensByPassword(username, password, service.ClientID)
+	_, err = certFile.Write(certData)
+	if err != nil {
+		return err
+	}
+	err = certFile.Close()
 	if err != nil {
 		return err
 	}
@@ -1179,9 +1326,6 @@ func getKubectlAuth(c *cli.Context) error {
 	fmt.Printf("    --auth-provider-arg=idp-certificate-authority=%s \n", certFile.Name())
 	fmt.Println("")
 
-	loadBalancerIp := service.ExtendedProperties[photon.ExtendedPropertyLoadBalancerIP]
-	clusterName := service.Name
-
 	// Command for create the cluster in the kubectl config
 	fmt.Printf("kubectl config set-cluster %s \\\n", clusterName)
 	fmt.Printf("    --server=https://%s:6443 \\\n", loadBalancerIp)
@@ -1200,54 +1344,61 @@ func getKubectlAuth(c *cli.Context) error {
 	return nil
 }
 
-// Helper routine which waits for a service to enter the READY state.
-func waitForService(id string) (service *photon.Service, err error) {
+// Helper routine which waits for a service to enter the READY state, as long as
+// --task-timeout or the TaskTimeout setting allow, 60 minutes by default.
+func waitForService(id string, showProgress bool) (service *photon.Service, err error) {
+	options, err := getServicePollOptions()
+	if err != nil {
+		return nil, err
+	}
 	start := time.Now()
 	numErr := 0
+	pollDelay := options.Interval
 
-	taskPollTimeout := 60 * time.Minute
-	taskPollDelay := 2 * time.Second
-	taskRetryCount := 3
-
-	var wg sync.WaitGroup
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		displayTaskProgress(start)
-	}()
+	var display *taskProgressDisplay
+	if showProgress {
+		display = startTaskProgress()
+		defer display.finish()
+	}
 
-	for time.Since(start) < taskPollTimeout {
+	for time.Since(start) < options.Timeout {
 		service, err = client.Photonclient.Services.Get(id)
 		if err != nil {
 			numErr++
 			if numErr > taskRetryCount {
-				endAnimation = true
-				wg.Wait()
 				return
 			}
 		}
 		if service != nil {
+			if display != nil {
+				display.update(&photon.Task{Operation: "WAIT_FOR_SERVICE", State: service.State})
+			}
 			switch strings.ToUpper(service.State) {
 			case "ERROR":
-				endAnimation = true
-				wg.Wait()
 				err = fmt.Errorf("Service %s entered ERROR state", id)
 				return
 			case "READY":
-				endAnimation = true
-				wg.Wait()
 				return
 			}
 		}
-		time.Sleep(taskPollDelay)
+
+		delay := withJitter(pollDelay)
+		if remaining := options.Timeout - time.Since(start); delay > remaining && remaining > 0 {
+			delay = remaining
+		}
+		time.Sleep(delay)
+		pollDelay = nextPollInterval(pollDelay)
 	}
 
-	endAnimation = true
-	wg.Wait()
-	err = fmt.Errorf("Timed out while waiting for service to enter READY state")
+	err = fmt.Errorf("Timed out after %s while waiting for service %s to enter READY state", options.Timeout, id)
 	return
 }
 
+// Progress is only displayed on the terminal, not in scripted or formatted output
+func showServiceProgress(c *cli.Context) bool {
+	return !c.GlobalIsSet("non-interactive") && !utils.NeedsFormatting(c)
+}
+
 // This is a helper function for reading the ssh key from a file.
 func readSSHKey(filename string) (result string, err error) {
 	file, err := os.Open(filename)
@@ -1380,6 +1531,63 @@ func validateHarborPassword(password string) bool {
 	return correct && number && upper && lower && (count >= 7)
 }
 
+// Checks that the spec has what the service type needs with virtual (SDN) or physical
+// networking, whether it came from flags, prompts or a spec file
+func validateServiceSpec(spec *photon.ServiceCreateSpec, sdn bool) error {
+	properties := spec.ExtendedProperties
+	if len(spec.Name) == 0 || len(spec.Type) == 0 {
+		return fmt.Errorf("Provide a valid service name and type")
+	}
+	if !sdn && (len(properties[photon.ExtendedPropertyDNS]) == 0 ||
+		len(properties[photon.ExtendedPropertyGateway]) == 0 ||
+		len(properties[photon.ExtendedPropertyNetMask]) == 0) {
+		return fmt.Errorf("Provide a valid DNS, gateway, and netmask")
+	}
+
+	switch spec.Type {
+	case "KUBERNETES":
+		if sdn {
+			for _, key := range []string{photon.ExtendedPropertyNumberOfMasters, photon.ExtendedPropertyNumberOfETCDs} {
+				count, err := strconv.Atoi(properties[key])
+				if err != nil || count < 1 {
+					return fmt.Errorf("Must specify the number of masters and etcd instances with virtual networking")
+				}
+			}
+			return nil
+		}
+		if len(properties[photon.ExtendedPropertyMasterIP]) == 0 {
+			return fmt.Errorf("Must specify at least one master IP")
+		}
+		if len(properties[photon.ExtendedPropertyLoadBalancerIP]) == 0 {
+			return fmt.Errorf("Must specify a load balancer IP")
+		}
+		if len(properties[photon.ExtendedPropertyETCDIP3]) != 0 && len(properties[photon.ExtendedPropertyETCDIP2]) == 0 {
+			return fmt.Errorf("Must specify etcd server 2 when specifying etcd server 3")
+		}
+	case "HARBOR":
+		if len(properties[photon.ExtendedPropertyMasterIP]) == 0 {
+			return fmt.Errorf("Must specify at least a master IP for Harbor")
+		}
+		password := properties[photon.ExtendedPropertyAdminPassword]
+		if len(password) != 0 && !validateHarborPassword(password) {
+			return fmt.Errorf("The Harbor password is invalid. It should have at least 7 characters " +
+				"with 1 lowercase letter, 1 capital letter and 1 numeric character.")
+		}
+	default:
+		return fmt.Errorf("Unsupported service type: %s", spec.Type)
+	}
+	return nil
+}
+
+// Sets an extended property, or removes it when the value is empty
+func setOptionalProperty(properties map[string]string, key string, value string) {
+	if len(value) == 0 {
+		delete(properties, key)
+	} else {
+		properties[key] = value
+	}
+}
+
 func generateRandomString(length int) string {
 	const asciiA = 65
 	const asciiZ = 90
@@ -13,10 +13,12 @@ import (
 	"bytes"
 	"encoding/json"
 	"flag"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/vmware/photon-controller-cli/photon/client"
 	"github.com/vmware/photon-controller-cli/photon/mocks"
@@ -709,6 +711,34 @@ func TestChangeVersionService(t *testing.T) {
 	}
 }
 
+func TestWaitForServiceTimeout(t *testing.T) {
+	server := mocks.NewTestServer()
+	defer server.Close()
+
+	registerJSONResponder(t, "GET", server.URL+rootUrl+"/services/fake_service_id",
+		&photon.Service{ID: "fake_service_id", State: "MAINTENANCE"})
+
+	mocks.Activate(true)
+	httpClient := &http.Client{Transport: mocks.DefaultMockTransport}
+	client.Photonclient = photon.NewTestClient(server.URL, nil, httpClient)
+
+	TaskTimeout = 50 * time.Millisecond
+	PollInterval = time.Millisecond
+	defer func() {
+		TaskTimeout = 0
+		PollInterval = 0
+	}()
+
+	start := time.Now()
+	_, err := waitForService("fake_service_id", false)
+	if err == nil || !strings.Contains(err.Error(), "Timed out after 50ms") {
+		t.Errorf("Expecting waiting for the service to time out, got %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("Expecting --task-timeout to bound the wait for the service")
+	}
+}
+
 func TestServiceCertToFile(t *testing.T) {
 	service := &photon.Service{
 		Name:        "fake_service_name",
@@ -783,3 +813,134 @@ func TestValidateHarborPassword(t *testing.T) {
 		t.Error("expected: false and result was true")
 	}
 }
+
+func TestCreateServiceFromSpec(t *testing.T) {
+	infoResponse, err := json.Marshal(&photon.Info{NetworkType: PHYSICAL})
+	if err != nil {
+		t.Error("Not expecting error when serializing info")
+	}
+	tenantResponse, err := json.Marshal(photon.Tenants{Items: []photon.Tenant{{Name: "cloud-dev", ID: "fake_tenant_id"}}})
+	if err != nil {
+		t.Error("Not expecting error serializing tenants")
+	}
+	projectResponse, err := json.Marshal(photon.ProjectList{Items: []photon.ProjectCompact{{Name: "web", ID: "fake_project_id"}}})
+	if err != nil {
+		t.Error("Not expecting error serializing projects")
+	}
+	task := &photon.Task{Operation: "CREATE_SERVICE", State: "COMPLETED", ID: "fake_create_service_task_id",
+		Entity: photon.Entity{ID: "fake_service_id"}}
+	taskResponse, err := json.Marshal(task)
+	if err != nil {
+		t.Error("Not expecting error serializing task")
+	}
+
+	server = mocks.NewTestServer()
+	defer server.Close()
+
+	var created photon.ServiceCreateSpec
+	mocks.RegisterResponder("GET", server.URL+rootUrl+"/info", mocks.CreateResponder(200, string(infoResponse)))
+	mocks.RegisterResponder("GET", server.URL+rootUrl+"/tenants", mocks.CreateResponder(200, string(tenantResponse)))
+	mocks.RegisterResponder("GET", server.URL+rootUrl+"/tenants/fake_tenant_id/projects?name=web",
+		mocks.CreateResponder(200, string(projectResponse)))
+	mocks.RegisterResponder("POST", server.URL+rootUrl+"/projects/fake_project_id/services",
+		func(req *http.Request) (*http.Response, error) {
+			err := json.NewDecoder(req.Body).Decode(&created)
+			if err != nil {
+				t.Error("Not expecting error decoding the service spec: ", err)
+			}
+			return mocks.CreateResponder(200, string(taskResponse))(req)
+		})
+	mocks.RegisterResponder("GET", server.URL+rootUrl+"/tasks/"+task.ID, mocks.CreateResponder(200, string(taskResponse)))
+	mocks.Activate(true)
+
+	httpClient := &http.Client{Transport: mocks.DefaultMockTransport}
+	client.Photonclient = photon.NewTestClient(server.URL, nil, httpClient)
+
+	specFile, err := ioutil.TempFile("", "service-spec")
+	if err != nil {
+		t.Fatal("Not expecting error creating the spec file: ", err)
+	}
+	defer os.Remove(specFile.Name())
+	_, err = specFile.WriteString(`{"tenant": "cloud-dev", "project": "web", "name": "k8s", "type": "kubernetes",
+		"workerCount": 3, "masterVmFlavor": "cluster-vm",
+		"extendedProperties": {"dns": "10.0.0.1", "gateway": "192.0.2.1", "netmask": "255.255.255.0",
+			"master_ip": "192.0.2.20", "etcd_ip1": "192.0.2.21", "container_network": "10.2.0.0/16"}}`)
+	if err != nil {
+		t.Fatal("Not expecting error writing the spec file: ", err)
+	}
+	specFile.Close()
+
+	globalSet := flag.NewFlagSet("test", 0)
+	globalSet.Bool("non-interactive", true, "doc")
+	globalCtx := cli.NewContext(nil, globalSet, nil)
+	err = globalSet.Parse([]string{"--non-interactive"})
+	if err != nil {
+		t.Error("Not expecting argument parsing to fail")
+	}
+
+	newContext := func(args ...string) *cli.Context {
+		set := flag.NewFlagSet("test", 0)
+		set.String("spec", "", "service spec")
+		set.String("tenant", "", "tenant name")
+		set.Int("worker_count", 0, "worker count")
+		set.String("load-balancer-ip", "", "load balancer ip")
+		set.String("etcd3", "", "etcd3 ip")
+		err := set.Parse(append([]string{"--spec", specFile.Name()}, args...))
+		if err != nil {
+			t.Error("Not expecting argument parsing to fail")
+		}
+		return cli.NewContext(nil, set, globalCtx)
+	}
+
+	// The spec file has no load balancer IP
+	err = createService(newContext(), os.Stdout)
+	if err == nil || !strings.Contains(err.Error(), "load balancer") {
+		t.Errorf("Expecting an error about the missing load balancer IP, got %v", err)
+	}
+	err = createService(newContext("--load-balancer-ip", "192.0.2.19", "--etcd3", "192.0.2.23"), os.Stdout)
+	if err == nil || !strings.Contains(err.Error(), "etcd server 2") {
+		t.Errorf("Expecting an error about etcd server 3 without etcd server 2, got %v", err)
+	}
+
+	err = createService(newContext("--load-balancer-ip", "192.0.2.19", "--worker_count", "5"), os.Stdout)
+	if err != nil {
+		t.Error("Not expecting error creating service: " + err.Error())
+	}
+	if created.Name != "k8s" || created.Type != "KUBERNETES" || created.WorkerCount != 5 ||
+		created.MasterVmFlavor != "cluster-vm" ||
+		created.ExtendedProperties[photon.ExtendedPropertyLoadBalancerIP] != "192.0.2.19" ||
+		created.ExtendedProperties[photon.ExtendedPropertyContainerNetwork] != "10.2.0.0/16" {
+		t.Errorf("Expecting the spec file with the flags overriding it, got %+v", created)
+	}
+}
+
+func TestValidateServiceSpec(t *testing.T) {
+	harbor := &photon.ServiceCreateSpec{Name: "registry", Type: "HARBOR", ExtendedProperties: map[string]string{
+		photon.ExtendedPropertyMasterIP: "192.0.2.20", photon.ExtendedPropertyAdminPassword: "harbor"}}
+	err := validateServiceSpec(harbor, true)
+	if err == nil || !strings.Contains(err.Error(), "Harbor password") {
+		t.Errorf("Expecting an invalid Harbor password, got %v", err)
+	}
+	harbor.ExtendedProperties[photon.ExtendedPropertyAdminPassword] = "Harbor123"
+	if err = validateServiceSpec(harbor, true); err != nil {
+		t.Errorf("Not expecting error validating Harbor service: %v", err)
+	}
+	if err = validateServiceSpec(harbor, false); err == nil {
+		t.Error("Expecting DNS, gateway and netmask to be needed with physical networking")
+	}
+
+	k8s := &photon.ServiceCreateSpec{Name: "k8s", Type: "KUBERNETES", ExtendedProperties: map[string]string{
+		photon.ExtendedPropertyNumberOfMasters: "1", photon.ExtendedPropertyNumberOfETCDs: "0"}}
+	if err = validateServiceSpec(k8s, true); err == nil {
+		t.Error("Expecting etcd instances to be needed with virtual networking")
+	}
+	k8s.ExtendedProperties[photon.ExtendedPropertyNumberOfETCDs] = "3"
+	if err = validateServiceSpec(k8s, true); err != nil {
+		t.Errorf("Not expecting error validating Kubernetes service: %v", err)
+	}
+
+	k8s.Type = "SWARM"
+	if err = validateServiceSpec(k8s, true); err == nil {
+		t.Error("Expecting an unsupported service type")
+	}
+}
@@ -12,36 +12,46 @@ package command
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"fmt"
 
+	"github.com/vmware/photon-controller-cli/photon/client"
 	cf "github.com/vmware/photon-controller-cli/photon/configuration"
 )
 
+// Tells if the server is trusted: through the certificate pinned for it if there is one,
+// or else through the system's certificate authorities. The certificates of the local
+// store are not used, they may have been accepted for another server. Returns a
+// client.CertificateChangedError if the server presents another certificate than the
+// pinned one.
 func isServerTrusted(server string) (bool, error) {
-	bServerTrusted := false
-
-	roots, err := cf.GetCertsFromLocalStore()
-
+	cliConfig, err := cf.LoadConfig()
 	if err != nil {
-		return bServerTrusted, err
+		return false, err
+	}
+	pins := cliConfig.CertPins[server]
+	if len(pins) != 0 {
+		cert, err := getServerCert(server)
+		if err != nil {
+			return false, err
+		}
+		if !cf.IsCertPinned(pins, cert) {
+			return false, client.CertificateChangedError{Host: server, Fingerprint: cf.CertFingerprint(cert)}
+		}
+		return true, nil
 	}
 
 	//Try connecting securely to the server
-	config := tls.Config{RootCAs: roots, InsecureSkipVerify: false}
-	conn, err := tls.Dial("tcp", server, &config)
-
+	conn, err := tls.Dial("tcp", server, &tls.Config{})
 	if err == nil {
-		bServerTrusted = true
 		_ = conn.Close()
-	} else {
-		switch err.(type) {
-		case x509.UnknownAuthorityError:
-			bServerTrusted = false
-			err = nil
-		}
+		return true, nil
 	}
-
-	return bServerTrusted, err
+	var unknownAuthority x509.UnknownAuthorityError
+	if errors.As(err, &unknownAuthority) {
+		return false, nil
+	}
+	return false, err
 }
 
 func getServerCert(server string) (*x509.Certificate, error) {
@@ -62,3 +72,13 @@ func getServerCert(server string) (*x509.Certificate, error) {
 	fmt.Println(err)
 	return nil, err
 }
+
+// Trusts only these certificates for the server from now on
+func pinServerCert(server string, certs ...*x509.Certificate) error {
+	config, err := cf.LoadConfig()
+	if err != nil {
+		return err
+	}
+	config.PinCert(server, certs...)
+	return cf.SaveConfig(config)
+}
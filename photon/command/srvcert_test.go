@@ -119,15 +119,85 @@ func TestServerTrustUtils(t *testing.T) {
 		return
 	}
 
-	//At this point we should have added the root cert of the remote server
-	//trust should be established already
+	//The local store may hold certs accepted for other servers, it does not
+	//establish trust on its own
 	bServerTrusted, err = isServerTrusted(u.Host)
-	if err != nil || bServerTrusted == false {
-		t.Error("Failed to check server trust")
+	if err != nil || bServerTrusted == true {
+		t.Error("Not expecting the local store to establish trust")
 	}
 
 	err = cf.RemoveCertFromLocalStore(cert)
 	if err != nil {
 		t.Error("Failed to Add server cert to local store")
 	}
+
+	configOri, err := cf.LoadConfig()
+	if err != nil {
+		t.Error("Not expecting error loading config file")
+	}
+	defer func() {
+		err = cf.SaveConfig(configOri)
+		if err != nil {
+			t.Error("Not expecting error when saving config file")
+		}
+	}()
+
+	//Once pinned, the cert is trusted for this server only
+	err = pinServerCert(u.Host, cert)
+	if err != nil {
+		t.Error("Failed to pin server cert")
+	}
+	bServerTrusted, err = isServerTrusted(u.Host)
+	if err != nil || bServerTrusted == false {
+		t.Error("Failed to check server trust")
+	}
+	otherHost := "localhost:" + u.Port()
+	bServerTrusted, err = isServerTrusted(otherHost)
+	if err != nil || bServerTrusted == true {
+		t.Error("Not expecting the cert pinned for another server to be trusted")
+	}
+}
+
+func TestAuthServerRoots(t *testing.T) {
+	configOri, err := cf.LoadConfig()
+	if err != nil {
+		t.Error("Not expecting error loading config file")
+	}
+	defer func() {
+		err = cf.SaveConfig(configOri)
+		if err != nil {
+			t.Error("Not expecting error when saving config file")
+		}
+	}()
+
+	certs := map[string]*x509.Certificate{}
+	for _, name := range []string{"own", "other", "imported"} {
+		cert_b, _ := genTestRootCert()
+		cert, err := x509.ParseCertificate(cert_b)
+		if err != nil {
+			t.Fatal("Not expecting error parsing test cert: ", err)
+		}
+		err = cf.AddCertToLocalStore(cert)
+		if err != nil {
+			t.Fatal("Not expecting error adding cert to local store: ", err)
+		}
+		defer func() { _ = cf.RemoveCertFromLocalStore(cert) }()
+		certs[name] = cert
+	}
+
+	config := &cf.Configuration{CloudTarget: "https://192.0.2.10"}
+	config.PinCert("192.0.2.11:443", certs["own"])
+	config.PinCert("198.51.100.11:443", certs["other"])
+	config.AuthServers = map[string]string{"192.0.2.10:443": "192.0.2.11:443"}
+
+	roots, err := config.AuthServerRoots()
+	if err != nil {
+		t.Fatal("Not expecting error getting the auth server roots: ", err)
+	}
+	for name, expected := range map[string]bool{"own": true, "other": false, "imported": true} {
+		_, err = certs[name].Verify(x509.VerifyOptions{Roots: roots})
+		if (err == nil) != expected {
+			t.Errorf("Expecting the %s cert to be trusted for the auth server: %t, got %v", name, expected, err)
+		}
+	}
 }
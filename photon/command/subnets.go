@@ -263,7 +263,7 @@ func createVirtualSubnet(c *cli.Context, w io.Writer, routerId string) error {
 			return err
 		}
 		subnetID, err := waitOnTaskOperation(createTask.ID, c)
-		if err != nil {
+		if err != nil || isAsync(c) {
 			return err
 		}
 
@@ -335,7 +335,7 @@ func createPhysicalSubnet(c *cli.Context, w io.Writer) error {
 	}
 
 	id, err := waitOnTaskOperation(task.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -369,7 +369,7 @@ func deleteSubnet(c *cli.Context) error {
 		return err
 	}
 	_, err = waitOnTaskOperation(deleteTask.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -499,7 +499,7 @@ func updateSubnet(c *cli.Context, w io.Writer) error {
 	}
 
 	id, err = waitOnTaskOperation(updateSubnetTask.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -535,7 +535,7 @@ func setDefaultSubnet(c *cli.Context, w io.Writer) error {
 
 	if confirmed(c) {
 		id, err := waitOnTaskOperation(task.ID, c)
-		if err != nil {
+		if err != nil || isAsync(c) {
 			return err
 		}
 
@@ -63,8 +63,12 @@ func GetSystemCommand() cli.Command {
 				Name:      "add-hosts",
 				Usage:     "Add multiple hosts",
 				ArgsUsage: "<host-file>",
+				Description: "Add the hosts of the file, and create their availability zones. Zones that \n" +
+					"   already exist, by name, and hosts already added, by address, are skipped, so the \n" +
+					"   file can be used again after a partial failure. Each zone and host is reported as \n" +
+					"   created, skipped or failed, and the command fails when any of them failed.",
 				Action: func(c *cli.Context) {
-					err := addHosts(c)
+					err := addHosts(c, os.Stdout)
 					if err != nil {
 						log.Fatal("Error: ", err)
 					}
@@ -77,7 +81,7 @@ func GetSystemCommand() cli.Command {
 				ArgsUsage:   "<host-file>",
 				Description: "Deprecated, use add-hosts instead",
 				Action: func(c *cli.Context) {
-					err := addHosts(c)
+					err := addHosts(c, os.Stdout)
 					if err != nil {
 						log.Fatal("Error: ", err)
 					}
@@ -479,7 +483,7 @@ func PauseSystem(c *cli.Context) error {
 	}
 
 	_, err = waitOnTaskOperation(pauseSystemTask.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -505,7 +509,7 @@ func PauseBackgroundTasks(c *cli.Context) error {
 	}
 
 	_, err = waitOnTaskOperation(pauseBackgroundTask.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -531,7 +535,7 @@ func ResumeSystem(c *cli.Context) error {
 	}
 
 	_, err = waitOnTaskOperation(resumeSystemTask.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -570,7 +574,7 @@ func setSystemSecurityGroups(c *cli.Context) error {
 	}
 
 	_, err = waitOnTaskOperation(task.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -686,7 +690,7 @@ func configureNSX(c *cli.Context) error {
 		}
 
 		_, err = waitOnTaskOperation(task.ID, c)
-		if err != nil {
+		if err != nil || isAsync(c) {
 			return err
 		}
 	} else {
@@ -738,7 +742,7 @@ func enableSystemServiceType(c *cli.Context) error {
 		}
 
 		_, err = waitOnTaskOperation(task.ID, c)
-		if err != nil {
+		if err != nil || isAsync(c) {
 			return err
 		}
 
@@ -786,7 +790,7 @@ func disableSystemServiceType(c *cli.Context) error {
 		}
 
 		_, err = waitOnTaskOperation(task.ID, c)
-		if err != nil {
+		if err != nil || isAsync(c) {
 			return err
 		}
 
@@ -817,9 +821,26 @@ func printStatus(status *photon.Status) error {
 	return nil
 }
 
-// Add most hosts in batch mode
-func addHosts(c *cli.Context) error {
-	err := checkArgCount(c, 0)
+// Outcome of adding a zone or a host of the host file: created, skipped because it
+// already exists, or failed
+type addHostsResult struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	ID     string `json:"id,omitempty"`
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+const (
+	addHostsCreated = "created"
+	addHostsSkipped = "skipped"
+	addHostsFailed  = "failed"
+)
+
+// Add most hosts in batch mode. Zones are looked up by name and hosts by address, so that
+// running it again after a partial failure only adds what is missing.
+func addHosts(c *cli.Context, w io.Writer) error {
+	err := checkArgCount(c, 1)
 	if err != nil {
 		return err
 	}
@@ -829,12 +850,36 @@ func addHosts(c *cli.Context) error {
 		return err
 	}
 
+	client.Photonclient, err = client.GetClient(c)
+	if err != nil {
+		return err
+	}
+
+	options, err := getTaskPollOptions()
+	if err != nil {
+		return err
+	}
+
 	// Create Hosts
-	err = createHostsInBatch(dcMap)
+	results, err := createHostsInBatch(dcMap, options, c)
+	if err != nil {
+		return err
+	}
+
+	err = printAddHostsResults(results, w, c)
 	if err != nil {
 		return err
 	}
 
+	failed := 0
+	for _, result := range results {
+		if result.Result == addHostsFailed {
+			failed++
+		}
+	}
+	if failed != 0 {
+		return fmt.Errorf("%d of %d zones and hosts could not be added", failed, len(results))
+	}
 	return nil
 }
 
@@ -847,85 +892,174 @@ func contains(list []string, value string) bool {
 	return false
 }
 
-func createZonesFromDcMap(dcMap *manifest.Installation) (map[string]string, error) {
-	zoneNameToIdMap := make(map[string]string)
-	for _, host := range dcMap.Hosts {
-		if len(host.AvailabilityZone) > 0 {
-			if _, present := zoneNameToIdMap[host.AvailabilityZone]; !present {
-				zoneSpec := &photon.ZoneCreateSpec{
-					Name: host.AvailabilityZone,
-				}
+// Returns the IDs of the zones of the hosts by name, creating the ones that do not exist
+// yet. Zones that could not be created are left out of the map.
+func createZonesFromDcMap(dcMap *manifest.Installation, options *taskPollOptions, c *cli.Context) (
+	map[string]string, []addHostsResult, error) {
 
-				createZoneTask, err := client.Photonclient.Zones.Create(zoneSpec)
-				if err != nil {
-					return nil, err
-				}
+	liveZones, err := client.Photonclient.Zones.GetAll()
+	if err != nil {
+		return nil, nil, err
+	}
 
-				task, err := pollTask(createZoneTask.ID)
-				if err != nil {
-					return nil, err
-				}
-				zoneNameToIdMap[host.AvailabilityZone] = task.Entity.ID
+	zoneNameToIdMap := make(map[string]string)
+	results := []addHostsResult{}
+	seen := make(map[string]bool)
+	for _, host := range dcMap.Hosts {
+		if len(host.AvailabilityZone) == 0 || seen[host.AvailabilityZone] {
+			continue
+		}
+		seen[host.AvailabilityZone] = true
+		result := addHostsResult{Kind: "zone", Name: host.AvailabilityZone}
+
+		for _, zone := range liveZones.Items {
+			if zone.Name == host.AvailabilityZone {
+				result.ID = zone.ID
+				result.Result = addHostsSkipped
+				break
+			}
+		}
+		if len(result.Result) == 0 {
+			zoneSpec := &photon.ZoneCreateSpec{
+				Name: host.AvailabilityZone,
+			}
+			task, err := client.Photonclient.Zones.Create(zoneSpec)
+			if err == nil {
+				task, err = waitForAddHostsTask(task.ID, options, c)
+			}
+			if err != nil {
+				result.Result = addHostsFailed
+				result.Error = err.Error()
+			} else {
+				result.ID = task.Entity.ID
+				result.Result = addHostsCreated
 			}
 		}
+
+		if len(result.ID) != 0 {
+			zoneNameToIdMap[host.AvailabilityZone] = result.ID
+		}
+		results = append(results, result)
 	}
-	return zoneNameToIdMap, nil
+	return zoneNameToIdMap, results, nil
 }
 
-func createHostsInBatch(dcMap *manifest.Installation) error {
-	hostSpecs, err := createHostSpecs(dcMap)
+func createHostsInBatch(dcMap *manifest.Installation, options *taskPollOptions, c *cli.Context) ([]addHostsResult, error) {
+	zoneNameToIdMap, results, err := createZonesFromDcMap(dcMap, options, c)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	hostSpecs, hostZones, err := createHostSpecs(dcMap, zoneNameToIdMap)
+	if err != nil {
+		return nil, err
+	}
+
+	liveHosts, err := client.Photonclient.InfraHosts.GetHosts()
+	if err != nil {
+		return nil, err
+	}
+	hostIDs := make(map[string]string)
+	for _, host := range liveHosts.Items {
+		hostIDs[host.Address] = host.ID
 	}
 
-	createTaskMap := make(map[string]*photon.Task)
-	var creationErrors []error
-	var pollErrors []error
-	for _, spec := range hostSpecs {
+	createTaskMap := make(map[int]*photon.Task)
+	hostResults := make([]addHostsResult, len(hostSpecs))
+	for i, spec := range hostSpecs {
+		hostResults[i] = addHostsResult{Kind: "host", Name: spec.Address}
+		if id, exists := hostIDs[spec.Address]; exists {
+			hostResults[i].ID = id
+			hostResults[i].Result = addHostsSkipped
+			continue
+		}
+		// An address listed twice is only created once
+		hostIDs[spec.Address] = ""
+		if len(hostZones[i]) != 0 && len(spec.Zone) == 0 {
+			hostResults[i].Result = addHostsFailed
+			hostResults[i].Error = fmt.Sprintf("Zone '%s' could not be created", hostZones[i])
+			continue
+		}
 		createHostTask, err := client.Photonclient.InfraHosts.Create(&spec)
 		if err != nil {
-			creationErrors = append(creationErrors, err)
-			fmt.Printf("Creation of Host document with ip '%s' failed: with err '%s'\n",
-				spec.Address, err)
+			hostResults[i].Result = addHostsFailed
+			hostResults[i].Error = err.Error()
 		} else {
-			createTaskMap[spec.Address] = createHostTask
+			createTaskMap[i] = createHostTask
 		}
 	}
 
-	for address, createTask := range createTaskMap {
-		task, err := pollTask(createTask.ID)
+	for i := range hostSpecs {
+		createTask, ok := createTaskMap[i]
+		if !ok {
+			continue
+		}
+		task, err := waitForAddHostsTask(createTask.ID, options, c)
 		if err != nil {
-			pollErrors = append(pollErrors, err)
-			fmt.Printf("Creation of Host with ip '%s' failed: ID = %s with err '%s'\n\n",
-				address, task.ID, err)
+			hostResults[i].Result = addHostsFailed
+			hostResults[i].Error = err.Error()
 		} else {
-			fmt.Printf("Host with ip '%s' created: ID = %s\n\n", address, task.Entity.ID)
+			hostResults[i].ID = task.Entity.ID
+			hostResults[i].Result = addHostsCreated
 		}
 	}
-	return nil
+	return append(results, hostResults...), nil
 }
 
-func createHostSpecs(dcMap *manifest.Installation) ([]photon.HostCreateSpec, error) {
-	zoneNameToIdMap, err := createZonesFromDcMap(dcMap)
+func waitForAddHostsTask(id string, options *taskPollOptions, c *cli.Context) (*photon.Task, error) {
+	showProgress := !c.GlobalIsSet("non-interactive") && !utils.NeedsFormatting(c)
+	return waitForTaskWithOptions(id, options, showProgress)
+}
+
+func printAddHostsResults(results []addHostsResult, w io.Writer, c *cli.Context) error {
+	if c.GlobalIsSet("non-interactive") {
+		for _, result := range results {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", result.Kind, result.Name, result.ID, result.Result, result.Error)
+		}
+		return nil
+	}
+	if utils.NeedsFormatting(c) {
+		utils.FormatObjects(results, w, c)
+		return nil
+	}
+
+	counts := make(map[string]int)
+	tw := new(tabwriter.Writer)
+	tw.Init(w, 4, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "\nKind\tName\tID\tResult\tError\n")
+	for _, result := range results {
+		counts[result.Result]++
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", result.Kind, result.Name, result.ID, result.Result, result.Error)
+	}
+	err := tw.Flush()
 	if err != nil {
-		return nil, err
+		return err
 	}
+	fmt.Fprintf(w, "\nCreated: %d, skipped: %d, failed: %d\n",
+		counts[addHostsCreated], counts[addHostsSkipped], counts[addHostsFailed])
+	return nil
+}
+
+// Returns the specs of the hosts to create, along with the name of the zone of each
+func createHostSpecs(dcMap *manifest.Installation, zoneNameToIdMap map[string]string) (
+	[]photon.HostCreateSpec, []string, error) {
+
 	var hostSpecs []photon.HostCreateSpec
+	var hostZones []string
 	var managementNetworkIps []string
 	for _, host := range dcMap.Hosts {
 		hostIps, err := parseIpRanges(host.IpRanges)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		if hostIps == nil || len(hostIps) == 0 {
-			return nil, errors.New("Host IP Address missing in DC Map")
+			return nil, nil, errors.New("Host IP Address missing in DC Map")
 		}
 
 		if host.Metadata != nil {
 			if managementVmIps, exists := host.Metadata["MANAGEMENT_VM_IPS"]; exists {
 				managementNetworkIps, err = parseIpRanges(managementVmIps)
 				if err != nil {
-					return nil, err
+					return nil, nil, err
 				}
 			}
 		}
@@ -954,10 +1088,11 @@ func createHostSpecs(dcMap *manifest.Installation) ([]photon.HostCreateSpec, err
 				Metadata: metaData,
 			}
 			hostSpecs = append(hostSpecs, hostSpec)
+			hostZones = append(hostZones, host.AvailabilityZone)
 		}
 	}
 
-	return hostSpecs, nil
+	return hostSpecs, hostZones, nil
 }
 
 func parseIpRanges(ipRanges string) ([]string, error) {
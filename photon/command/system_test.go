@@ -10,8 +10,10 @@
 package command
 
 import (
+	"bytes"
 	"encoding/json"
 	"flag"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"testing"
@@ -580,3 +582,118 @@ func TestDisableSystemServiceType(t *testing.T) {
 		t.Error("Not expecting pauseBackgroundTasks to fail")
 	}
 }
+
+func TestAddHosts(t *testing.T) {
+	marshal := func(value interface{}) string {
+		response, err := json.Marshal(value)
+		if err != nil {
+			t.Error("Not expecting error serializing response")
+		}
+		return string(response)
+	}
+
+	server = mocks.NewTestServer()
+	defer server.Close()
+
+	mocks.RegisterResponder("GET", server.URL+rootUrl+"/zones",
+		mocks.CreateResponder(200, marshal(photon.Zones{Items: []photon.Zone{{ID: "zone1_id", Name: "zone1"}}})))
+	zoneTask := &photon.Task{ID: "zone2_task_id", Operation: "CREATE_ZONE", State: "COMPLETED",
+		Entity: photon.Entity{ID: "zone2_id", Kind: "zone"}}
+	mocks.RegisterResponder("POST", server.URL+rootUrl+"/zones", mocks.CreateResponder(200, marshal(zoneTask)))
+	mocks.RegisterResponder("GET", server.URL+rootUrl+"/tasks/"+zoneTask.ID, mocks.CreateResponder(200, marshal(zoneTask)))
+
+	mocks.RegisterResponder("GET", server.URL+rootUrl+"/infrastructure/hosts",
+		mocks.CreateResponder(200, marshal(photon.Hosts{Items: []photon.Host{{ID: "host1_id", Address: "10.0.0.1"}}})))
+	created := map[string]string{}
+	mocks.RegisterResponder("POST", server.URL+rootUrl+"/infrastructure/hosts",
+		func(req *http.Request) (*http.Response, error) {
+			spec := photon.HostCreateSpec{}
+			err := json.NewDecoder(req.Body).Decode(&spec)
+			if err != nil {
+				t.Error("Not expecting error decoding host spec: ", err)
+			}
+			created[spec.Address] = spec.Zone
+			task := &photon.Task{ID: spec.Address + "_task_id", Operation: "CREATE_HOST", State: "COMPLETED",
+				Entity: photon.Entity{ID: spec.Address + "_id", Kind: "host"}}
+			if spec.Address == "10.0.0.3" {
+				task.State = "ERROR"
+				task.Steps = []photon.Step{{Operation: "CREATE_HOST", State: "ERROR",
+					Errors: []photon.ApiError{{Code: "HostExists", Message: "Host is already managed"}}}}
+			}
+			mocks.RegisterResponder("GET", server.URL+rootUrl+"/tasks/"+task.ID, mocks.CreateResponder(200, marshal(task)))
+			return mocks.CreateResponder(200, marshal(task))(req)
+		})
+	mocks.Activate(true)
+
+	httpClient := &http.Client{Transport: mocks.DefaultMockTransport}
+	client.Photonclient = photon.NewTestClient(server.URL, nil, httpClient)
+
+	hostFile, err := ioutil.TempFile("", "hosts")
+	if err != nil {
+		t.Fatal("Not expecting error creating host file: ", err)
+	}
+	defer os.Remove(hostFile.Name())
+	_, err = hostFile.WriteString(`hosts:
+- address_ranges: 10.0.0.1-10.0.0.3
+  username: root
+  password: secret
+  availability_zone: zone1
+  usage_tags: [CLOUD]
+- address_ranges: 10.0.0.4
+  username: root
+  password: secret
+  availability_zone: zone2
+  usage_tags: [CLOUD]
+`)
+	if err != nil {
+		t.Fatal("Not expecting error writing host file: ", err)
+	}
+	hostFile.Close()
+
+	globalSet := flag.NewFlagSet("test", 0)
+	globalSet.String("output", "", "output")
+	err = globalSet.Parse([]string{"--output", "json"})
+	if err != nil {
+		t.Error("Not expecting global argument parsing to fail")
+	}
+	set := flag.NewFlagSet("test", 0)
+	err = set.Parse([]string{hostFile.Name()})
+	if err != nil {
+		t.Error("Not expecting argument parsing to fail")
+	}
+	ctx := cli.NewContext(nil, set, cli.NewContext(nil, globalSet, nil))
+
+	var output bytes.Buffer
+	err = addHosts(ctx, &output)
+	if err == nil || err.Error() != "1 of 6 zones and hosts could not be added" {
+		t.Errorf("Expecting the failure of one host to be reported, got %v", err)
+	}
+	var results []addHostsResult
+	err = json.Unmarshal(output.Bytes(), &results)
+	if err != nil {
+		t.Fatal("Not expecting error parsing the results: ", err)
+	}
+	expected := []addHostsResult{
+		{Kind: "zone", Name: "zone1", ID: "zone1_id", Result: addHostsSkipped},
+		{Kind: "zone", Name: "zone2", ID: "zone2_id", Result: addHostsCreated},
+		{Kind: "host", Name: "10.0.0.1", ID: "host1_id", Result: addHostsSkipped},
+		{Kind: "host", Name: "10.0.0.2", ID: "10.0.0.2_id", Result: addHostsCreated},
+		{Kind: "host", Name: "10.0.0.3", Result: addHostsFailed},
+		{Kind: "host", Name: "10.0.0.4", ID: "10.0.0.4_id", Result: addHostsCreated},
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("Expecting %d results, got %+v", len(expected), results)
+	}
+	for i, result := range results {
+		result.Error = ""
+		if result != expected[i] {
+			t.Errorf("Expecting %+v, got %+v", expected[i], results[i])
+		}
+	}
+	if len(results[4].Error) == 0 {
+		t.Error("Expecting the error of the failed host")
+	}
+	if _, ok := created["10.0.0.1"]; ok || created["10.0.0.4"] != "zone2_id" || created["10.0.0.2"] != "zone1_id" {
+		t.Errorf("Expecting only the missing hosts to be created in their zones, got %v", created)
+	}
+}
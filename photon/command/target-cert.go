@@ -0,0 +1,429 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package command
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli"
+
+	cf "github.com/vmware/photon-controller-cli/photon/configuration"
+	"github.com/vmware/photon-controller-cli/photon/utils"
+)
+
+// Certificates expiring within this many days are reported by the cert commands
+const defaultCertWarningDays = 30
+
+// Creates a cli.Command for target cert
+// Subcommands: list;   Usage: target cert list [<options>]
+//              show;   Usage: target cert show <fingerprint>
+//              add;    Usage: target cert add <host[:port]>
+//              remove; Usage: target cert remove <fingerprint>
+//              import; Usage: target cert import <file>
+func getTargetCertCommand() cli.Command {
+	warningFlag := cli.IntFlag{
+		Name:  "warn-days",
+		Value: defaultCertWarningDays,
+		Usage: "warn about certificates expiring within this number of days",
+	}
+	certCommand := cli.Command{
+		Name:  "cert",
+		Usage: "options for trusted server certificates",
+		Subcommands: []cli.Command{
+			{
+				Name:      "list",
+				Usage:     "List trusted certificates",
+				ArgsUsage: " ",
+				Flags:     []cli.Flag{warningFlag},
+				Action: func(c *cli.Context) {
+					err := listCerts(c, os.Stdout)
+					if err != nil {
+						log.Fatal("Error: ", err)
+					}
+				},
+			},
+			{
+				Name:      "show",
+				Usage:     "Show a trusted certificate",
+				ArgsUsage: "<fingerprint>",
+				Description: "Shows the trusted certificate with the given SHA-256 fingerprint. A unique prefix\n" +
+					"   of the fingerprint is enough, e.g. the first bytes shown by 'target cert list'.",
+				Flags: []cli.Flag{warningFlag},
+				Action: func(c *cli.Context) {
+					err := showCert(c, os.Stdout)
+					if err != nil {
+						log.Fatal("Error: ", err)
+					}
+				},
+			},
+			{
+				Name:      "add",
+				Usage:     "Pin the certificate presented by a server",
+				ArgsUsage: "<host[:port]>",
+				Description: "Connects to the server, shows the certificate it presents and, once confirmed,\n" +
+					"   trusts that certificate for this server only. It replaces the certificate pinned\n" +
+					"   for the server before, e.g. after the server certificate was renewed.\n" +
+					"   The port defaults to 443.\n" +
+					"   Example:\n" +
+					"      photon target cert add 192.0.2.42:443",
+				Flags: []cli.Flag{warningFlag},
+				Action: func(c *cli.Context) {
+					err := addServerCert(c, os.Stdout)
+					if err != nil {
+						log.Fatal("Error: ", err)
+					}
+				},
+			},
+			{
+				Name:      "remove",
+				Usage:     "Stop trusting a certificate",
+				ArgsUsage: "<fingerprint>",
+				Description: "Removes the certificate from the trusted certificates and from the servers it\n" +
+					"   is pinned for.",
+				Action: func(c *cli.Context) {
+					err := removeCert(c, os.Stdout)
+					if err != nil {
+						log.Fatal("Error: ", err)
+					}
+				},
+			},
+			{
+				Name:      "import",
+				Usage:     "Trust the certificates of a PEM or DER file",
+				ArgsUsage: "<file>",
+				Description: "Adds every certificate of the file to the trusted certificates. The file can be a\n" +
+					"   single certificate in PEM or DER format, or a PEM bundle such as a CA chain.\n" +
+					"   Example:\n" +
+					"      photon target cert import corporate-ca-bundle.pem",
+				Flags: []cli.Flag{warningFlag},
+				Action: func(c *cli.Context) {
+					err := importCerts(c, os.Stdout)
+					if err != nil {
+						log.Fatal("Error: ", err)
+					}
+				},
+			},
+		},
+	}
+	return certCommand
+}
+
+// Certificate as shown by the cert commands with --output
+type certListItem struct {
+	Fingerprint string    `json:"fingerprint"`
+	Subject     string    `json:"subject"`
+	Issuer      string    `json:"issuer"`
+	NotBefore   time.Time `json:"notBefore"`
+	NotAfter    time.Time `json:"notAfter"`
+	IsCA        bool      `json:"isCA"`
+	DNSNames    []string  `json:"dnsNames,omitempty"`
+	File        string    `json:"file,omitempty"`
+}
+
+func newCertListItem(cert *x509.Certificate, file string) certListItem {
+	return certListItem{
+		Fingerprint: cf.CertFingerprint(cert),
+		Subject:     certName(cert.Subject),
+		Issuer:      certName(cert.Issuer),
+		NotBefore:   cert.NotBefore,
+		NotAfter:    cert.NotAfter,
+		IsCA:        cert.IsCA,
+		DNSNames:    cert.DNSNames,
+		File:        file,
+	}
+}
+
+func listCerts(c *cli.Context, w io.Writer) error {
+	err := checkArgCount(c, 0)
+	if err != nil {
+		return err
+	}
+
+	certs, err := cf.GetTrustedCerts()
+	if err != nil {
+		return err
+	}
+
+	if c.GlobalIsSet("non-interactive") {
+		for _, trusted := range certs {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", cf.CertFingerprint(trusted.Cert), certName(trusted.Cert.Subject),
+				certName(trusted.Cert.Issuer), trusted.Cert.NotAfter.Format(time.RFC3339))
+		}
+		return nil
+	} else if utils.NeedsFormatting(c) {
+		items := []certListItem{}
+		for _, trusted := range certs {
+			items = append(items, newCertListItem(trusted.Cert, trusted.File))
+		}
+		utils.FormatObjects(items, w, c)
+		return nil
+	}
+
+	tw := new(tabwriter.Writer)
+	tw.Init(w, 4, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Fingerprint\tSubject\tIssuer\tExpires\n")
+	for _, trusted := range certs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", shortFingerprint(trusted.Cert), certName(trusted.Cert.Subject),
+			certName(trusted.Cert.Issuer), trusted.Cert.NotAfter.Format("2006-01-02"))
+	}
+	err = tw.Flush()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\nTotal: %d\n", len(certs))
+	for _, trusted := range certs {
+		printCertExpiryWarning(trusted.Cert, c, w)
+	}
+
+	config, err := cf.LoadConfig()
+	if err != nil {
+		return err
+	}
+	if len(config.CertPins) != 0 {
+		hosts := []string{}
+		for host := range config.CertPins {
+			hosts = append(hosts, host)
+		}
+		sort.Strings(hosts)
+
+		fmt.Fprintf(w, "\nPinned certificates:\n")
+		tw.Init(w, 4, 4, 2, ' ', 0)
+		fmt.Fprintf(tw, "Host\tFingerprint\n")
+		for _, host := range hosts {
+			for _, pin := range config.CertPins[host] {
+				fmt.Fprintf(tw, "%s\t%s\n", host, pin)
+			}
+		}
+		err = tw.Flush()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func showCert(c *cli.Context, w io.Writer) error {
+	err := checkArgCount(c, 1)
+	if err != nil {
+		return err
+	}
+
+	trusted, err := cf.FindTrustedCert(c.Args().First())
+	if err != nil {
+		return err
+	}
+	cert := trusted.Cert
+
+	if c.GlobalIsSet("non-interactive") {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%t\t%s\n", cf.CertFingerprint(cert), certName(cert.Subject),
+			certName(cert.Issuer), cert.NotBefore.Format(time.RFC3339), cert.NotAfter.Format(time.RFC3339),
+			cert.IsCA, trusted.File)
+	} else if utils.NeedsFormatting(c) {
+		utils.FormatObject(newCertListItem(cert, trusted.File), w, c)
+	} else {
+		err = printCertDetails(cert, trusted.File, w)
+		if err != nil {
+			return err
+		}
+		printCertExpiryWarning(cert, c, w)
+	}
+	return nil
+}
+
+// Trusts the certificate presented by a server after showing it
+func addServerCert(c *cli.Context, w io.Writer) error {
+	err := checkArgCount(c, 1)
+	if err != nil {
+		return err
+	}
+	host := cf.PinnedHost(c.Args().First())
+
+	cert, err := getServerCert(host)
+	if err != nil {
+		return err
+	}
+
+	if !c.GlobalIsSet("non-interactive") && !utils.NeedsFormatting(c) {
+		fmt.Fprintf(w, "Certificate presented by %s:\n", host)
+		err = printCertDetails(cert, "", w)
+		if err != nil {
+			return err
+		}
+		printCertExpiryWarning(cert, c, w)
+		if !confirmed(c) {
+			fmt.Fprintf(w, "OK. Canceled\n")
+			return nil
+		}
+	}
+
+	err = pinServerCert(host, cert)
+	if err != nil {
+		return err
+	}
+	printAddedCerts([]*x509.Certificate{cert}, c, w)
+	return nil
+}
+
+func removeCert(c *cli.Context, w io.Writer) error {
+	err := checkArgCount(c, 1)
+	if err != nil {
+		return err
+	}
+
+	fingerprint := c.Args().First()
+
+	config, err := cf.LoadConfig()
+	if err != nil {
+		return err
+	}
+	trusted, findErr := cf.FindTrustedCert(fingerprint)
+	hosts := config.UnpinCert(fingerprint)
+	if findErr != nil && len(hosts) == 0 {
+		return findErr
+	}
+
+	if !c.GlobalIsSet("non-interactive") && !utils.NeedsFormatting(c) {
+		if trusted != nil {
+			fmt.Fprintf(w, "Removing trusted certificate %s (%s)\n",
+				shortFingerprint(trusted.Cert), certName(trusted.Cert.Subject))
+		}
+		for _, host := range hosts {
+			fmt.Fprintf(w, "Removing the certificate pinned for %s\n", host)
+		}
+	}
+	if !confirmed(c) {
+		fmt.Fprintf(w, "OK. Canceled\n")
+		return nil
+	}
+
+	if trusted != nil {
+		err = cf.RemoveCertFromLocalStore(trusted.Cert)
+		if err != nil {
+			return err
+		}
+	}
+	if len(hosts) != 0 {
+		err = cf.SaveConfig(config)
+		if err != nil {
+			return err
+		}
+	}
+	if !c.GlobalIsSet("non-interactive") && !utils.NeedsFormatting(c) {
+		fmt.Fprintf(w, "Certificate %s is no longer trusted\n", fingerprint)
+	}
+	return nil
+}
+
+// Trusts every certificate of a PEM bundle or DER file
+func importCerts(c *cli.Context, w io.Writer) error {
+	err := checkArgCount(c, 1)
+	if err != nil {
+		return err
+	}
+	file := c.Args().First()
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	certs, err := cf.ParseCerts(data)
+	if err != nil {
+		return fmt.Errorf("Could not read certificates from %s: %v", file, err)
+	}
+
+	for _, cert := range certs {
+		err = cf.AddCertToLocalStore(cert)
+		if err != nil {
+			return err
+		}
+	}
+	printAddedCerts(certs, c, w)
+	return nil
+}
+
+func printAddedCerts(certs []*x509.Certificate, c *cli.Context, w io.Writer) {
+	if c.GlobalIsSet("non-interactive") {
+		for _, cert := range certs {
+			fmt.Fprintf(w, "%s\n", cf.CertFingerprint(cert))
+		}
+	} else if utils.NeedsFormatting(c) {
+		items := []certListItem{}
+		for _, cert := range certs {
+			items = append(items, newCertListItem(cert, ""))
+		}
+		utils.FormatObjects(items, w, c)
+	} else {
+		for _, cert := range certs {
+			fmt.Fprintf(w, "Trusted certificate %s (%s)\n", shortFingerprint(cert), certName(cert.Subject))
+			printCertExpiryWarning(cert, c, w)
+		}
+	}
+}
+
+func printCertDetails(cert *x509.Certificate, file string, w io.Writer) error {
+	tw := new(tabwriter.Writer)
+	tw.Init(w, 4, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Subject:\t%s\n", certName(cert.Subject))
+	fmt.Fprintf(tw, "Issuer:\t%s\n", certName(cert.Issuer))
+	fmt.Fprintf(tw, "SHA-256:\t%s\n", cf.CertFingerprint(cert))
+	fmt.Fprintf(tw, "Serial:\t%s\n", cert.SerialNumber)
+	fmt.Fprintf(tw, "Valid from:\t%s\n", cert.NotBefore.Format(time.RFC3339))
+	fmt.Fprintf(tw, "Valid until:\t%s\n", cert.NotAfter.Format(time.RFC3339))
+	fmt.Fprintf(tw, "CA:\t%t\n", cert.IsCA)
+	if len(cert.DNSNames) != 0 {
+		fmt.Fprintf(tw, "DNS names:\t%s\n", strings.Join(cert.DNSNames, ", "))
+	}
+	if len(file) != 0 {
+		fmt.Fprintf(tw, "File:\t%s\n", file)
+	}
+	return tw.Flush()
+}
+
+// Prints a warning if the certificate has expired or expires within --warn-days
+func printCertExpiryWarning(cert *x509.Certificate, c *cli.Context, w io.Writer) {
+	days := defaultCertWarningDays
+	if c.IsSet("warn-days") {
+		days = c.Int("warn-days")
+	}
+	if time.Now().After(cert.NotAfter) {
+		fmt.Fprintf(w, "Warning: certificate %s (%s) expired on %s\n",
+			shortFingerprint(cert), certName(cert.Subject), cert.NotAfter.Format("2006-01-02"))
+	} else if cf.CertExpiresWithin(cert, time.Duration(days)*24*time.Hour) {
+		fmt.Fprintf(w, "Warning: certificate %s (%s) expires on %s\n",
+			shortFingerprint(cert), certName(cert.Subject), cert.NotAfter.Format("2006-01-02"))
+	}
+}
+
+// Returns the first bytes of the SHA-256 fingerprint, enough to tell certificates apart
+func shortFingerprint(cert *x509.Certificate) string {
+	return cf.CertFingerprint(cert)[:23]
+}
+
+// Returns the common name, or the organization if there is none
+func certName(name pkix.Name) string {
+	if len(name.CommonName) != 0 {
+		return name.CommonName
+	}
+	parts := append(append([]string{}, name.Organization...), name.OrganizationalUnit...)
+	if len(parts) != 0 {
+		return strings.Join(parts, ", ")
+	}
+	return "-"
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package command
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"flag"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	cf "github.com/vmware/photon-controller-cli/photon/configuration"
+
+	"github.com/urfave/cli"
+)
+
+func TestImportListRemoveCerts(t *testing.T) {
+	configDir, err := ioutil.TempDir("", "cert-test-")
+	if err != nil {
+		t.Error("Not expecting error creating config directory")
+	}
+	defer os.RemoveAll(configDir)
+	cf.UserConfigDir = configDir
+	defer func() { cf.UserConfigDir = "" }()
+
+	// A bundle with two certificates, both expiring within a day
+	var bundle bytes.Buffer
+	var certs []*x509.Certificate
+	for i := 0; i < 2; i++ {
+		cert_b, _ := genTestRootCert()
+		cert, err := x509.ParseCertificate(cert_b)
+		if err != nil {
+			t.Error("Not expecting error parsing test certificate")
+		}
+		certs = append(certs, cert)
+		err = pem.Encode(&bundle, &pem.Block{Type: "CERTIFICATE", Bytes: cert_b})
+		if err != nil {
+			t.Error("Not expecting error encoding test certificate")
+		}
+	}
+	file, err := ioutil.TempFile("", "bundle_")
+	if err != nil {
+		t.Error("Not expecting error creating bundle file")
+	}
+	defer os.Remove(file.Name())
+	_, err = file.Write(bundle.Bytes())
+	if err != nil {
+		t.Error("Not expecting error writing bundle file")
+	}
+	_ = file.Close()
+
+	set := flag.NewFlagSet("test", 0)
+	err = set.Parse([]string{file.Name()})
+	if err != nil {
+		t.Error("Not expecting arguments parsing to fail")
+	}
+	var buf bytes.Buffer
+	err = importCerts(cli.NewContext(nil, set, nil), &buf)
+	if err != nil {
+		t.Error("Not expecting error importing certificates: ", err)
+	}
+
+	set = flag.NewFlagSet("test", 0)
+	set.Int("warn-days", defaultCertWarningDays, "")
+	buf.Reset()
+	err = listCerts(cli.NewContext(nil, set, nil), &buf)
+	if err != nil {
+		t.Error("Not expecting error listing certificates: ", err)
+	}
+	err = checkRegExp(`Total: 2`, buf)
+	if err != nil {
+		t.Errorf("Expecting both certificates to be trusted: %s", err)
+	}
+	err = checkRegExp(`Warning: certificate .* expires on`, buf)
+	if err != nil {
+		t.Errorf("Expecting an expiry warning: %s", err)
+	}
+
+	// Certificates are found by a prefix of their fingerprint, in any case and without colons
+	fingerprint := cf.CertFingerprint(certs[0])
+	set = flag.NewFlagSet("test", 0)
+	err = set.Parse([]string{fingerprint[:8]})
+	if err != nil {
+		t.Error("Not expecting arguments parsing to fail")
+	}
+	buf.Reset()
+	err = showCert(cli.NewContext(nil, set, nil), &buf)
+	if err != nil {
+		t.Error("Not expecting error showing certificate: ", err)
+	}
+	err = checkRegExp(`SHA-256:\s+`+fingerprint, buf)
+	if err != nil {
+		t.Errorf("Expecting the fingerprint in the details: %s", err)
+	}
+
+	globalSet := flag.NewFlagSet("test", 0)
+	globalSet.Bool("non-interactive", true, "")
+	err = globalSet.Parse([]string{"--non-interactive"})
+	if err != nil {
+		t.Error("Not expecting arguments parsing to fail")
+	}
+	set = flag.NewFlagSet("test", 0)
+	err = set.Parse([]string{fingerprint})
+	if err != nil {
+		t.Error("Not expecting arguments parsing to fail")
+	}
+	err = removeCert(cli.NewContext(nil, set, cli.NewContext(nil, globalSet, nil)), &buf)
+	if err != nil {
+		t.Error("Not expecting error removing certificate: ", err)
+	}
+
+	trusted, err := cf.GetTrustedCerts()
+	if err != nil {
+		t.Error("Not expecting error reading trusted certificates: ", err)
+	}
+	if len(trusted) != 1 || cf.CertFingerprint(trusted[0].Cert) != cf.CertFingerprint(certs[1]) {
+		t.Errorf("Expecting only the second certificate to be trusted, got %d certificates", len(trusted))
+	}
+}
@@ -0,0 +1,286 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package command
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/urfave/cli"
+
+	cf "github.com/vmware/photon-controller-cli/photon/configuration"
+	"github.com/vmware/photon-controller-cli/photon/utils"
+)
+
+// Creates a cli.Command for target profile
+// Subcommands: add;    Usage: target profile add <name> [<options>]
+//              use;    Usage: target profile use <name>
+//              list;   Usage: target profile list
+//              delete; Usage: target profile delete <name>
+func getTargetProfileCommand() cli.Command {
+	profileCommand := cli.Command{
+		Name:  "profile",
+		Usage: "options for named target profiles",
+		Subcommands: []cli.Command{
+			{
+				Name:      "add",
+				Usage:     "Save a named profile",
+				ArgsUsage: "<name>",
+				Description: "Saves the current target, tokens, tenant and project under a name, so that you\n" +
+					"   can switch back to them later with 'target profile use' or the global --profile flag.\n" +
+					"   If an endpoint is given, the profile is created for that endpoint instead, and you can\n" +
+					"   log in and set the tenant and project for it after switching to it.\n" +
+					"   Examples:\n" +
+					"      photon target profile add staging\n" +
+					"      photon target profile add production --endpoint https://192.0.2.42:443",
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "endpoint, e",
+						Usage: "endpoint of the new profile, the current target is used if not set",
+					},
+					cli.BoolFlag{
+						Name:  "nocertcheck, c",
+						Usage: "flag to avoid validating the server's certificate, only used with --endpoint",
+					},
+				},
+				Action: func(c *cli.Context) {
+					err := addProfile(c)
+					if err != nil {
+						log.Fatal("Error: ", err)
+					}
+				},
+			},
+			{
+				Name:      "use",
+				Usage:     "Switch to a named profile",
+				ArgsUsage: "<name>",
+				Description: "Makes the named profile current. All later commands use its target, tokens,\n" +
+					"   tenant and project, unless another profile is selected with the global --profile flag.",
+				Action: func(c *cli.Context) {
+					err := useProfile(c)
+					if err != nil {
+						log.Fatal("Error: ", err)
+					}
+				},
+			},
+			{
+				Name:      "list",
+				Usage:     "List named profiles",
+				ArgsUsage: " ",
+				Action: func(c *cli.Context) {
+					err := listProfiles(c, os.Stdout)
+					if err != nil {
+						log.Fatal("Error: ", err)
+					}
+				},
+			},
+			{
+				Name:      "delete",
+				Usage:     "Delete a named profile",
+				ArgsUsage: "<name>",
+				Action: func(c *cli.Context) {
+					err := deleteProfile(c)
+					if err != nil {
+						log.Fatal("Error: ", err)
+					}
+				},
+			},
+		},
+	}
+	return profileCommand
+}
+
+// Profile as shown by 'target profile list', tokens are left out on purpose
+type profileListItem struct {
+	Name    string                   `json:"name"`
+	Target  string                   `json:"target"`
+	Tenant  *cf.TenantConfiguration  `json:"tenant,omitempty"`
+	Project *cf.ProjectConfiguration `json:"project,omitempty"`
+	Current bool                     `json:"current"`
+}
+
+// Stores the current settings, or a new endpoint, under the given profile name
+func addProfile(c *cli.Context) error {
+	err := checkArgCount(c, 1)
+	if err != nil {
+		return err
+	}
+	name := c.Args().First()
+	endpoint := c.String("endpoint")
+
+	config, err := cf.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	_, err = config.GetProfile(name)
+	if err == nil {
+		return fmt.Errorf("Profile '%s' already exists", name)
+	}
+
+	profile := config.CurrentSettings()
+	if len(endpoint) != 0 {
+		profile = &cf.Profile{
+			CloudTarget:       endpoint,
+			IgnoreCertificate: c.Bool("nocertcheck"),
+		}
+	} else if len(profile.CloudTarget) == 0 {
+		return fmt.Errorf("Specify an endpoint with --endpoint or run 'target set' first")
+	}
+	config.SetProfile(name, profile)
+
+	// The first profile takes over the settings in use
+	if len(config.CurrentProfile) == 0 && len(endpoint) == 0 {
+		config.CurrentProfile = name
+	}
+
+	err = cf.SaveConfig(config)
+	if err != nil {
+		return err
+	}
+
+	if len(endpoint) != 0 {
+		// Establish trust with the new endpoint through the profile just added
+		cf.ProfileName = name
+		err = configureServerCerts(endpoint, c.Bool("nocertcheck"), c)
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Profile '%s' added for target '%s'\n", name, profile.CloudTarget)
+	return nil
+}
+
+// Makes the given profile current
+func useProfile(c *cli.Context) error {
+	err := checkArgCount(c, 1)
+	if err != nil {
+		return err
+	}
+	name := c.Args().First()
+
+	config, err := cf.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	err = config.UseProfile(name)
+	if err != nil {
+		return err
+	}
+
+	err = cf.SaveConfig(config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Using profile '%s' with target '%s'\n", name, config.CloudTarget)
+	return nil
+}
+
+// Lists all profiles, the current one is marked with '*'
+func listProfiles(c *cli.Context, w io.Writer) error {
+	err := checkArgCount(c, 0)
+	if err != nil {
+		return err
+	}
+
+	config, err := cf.LoadConfig()
+	if err != nil {
+		return err
+	}
+	names := config.ProfileNames()
+
+	if c.GlobalIsSet("non-interactive") {
+		for _, name := range names {
+			profile := config.Profiles[name]
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\n", name, profile.CloudTarget,
+				profileTenantName(profile), profileProjectName(profile), name == config.CurrentProfile)
+		}
+	} else if utils.NeedsFormatting(c) {
+		profileList := []profileListItem{}
+		for _, name := range names {
+			profile := config.Profiles[name]
+			profileList = append(profileList, profileListItem{
+				Name:    name,
+				Target:  profile.CloudTarget,
+				Tenant:  profile.Tenant,
+				Project: profile.Project,
+				Current: name == config.CurrentProfile,
+			})
+		}
+		utils.FormatObjects(profileList, w, c)
+	} else {
+		tw := new(tabwriter.Writer)
+		tw.Init(w, 4, 4, 2, ' ', 0)
+		fmt.Fprintf(tw, "\tName\tTarget\tTenant\tProject\n")
+		for _, name := range names {
+			profile := config.Profiles[name]
+			current := ""
+			if name == config.CurrentProfile {
+				current = "*"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", current, name, profile.CloudTarget,
+				profileTenantName(profile), profileProjectName(profile))
+		}
+		err = tw.Flush()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "\nTotal: %d\n", len(names))
+	}
+
+	return nil
+}
+
+// Deletes the given profile
+func deleteProfile(c *cli.Context) error {
+	err := checkArgCount(c, 1)
+	if err != nil {
+		return err
+	}
+	name := c.Args().First()
+
+	config, err := cf.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	err = config.DeleteProfile(name)
+	if err != nil {
+		return err
+	}
+
+	err = cf.SaveConfig(config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Profile '%s' deleted\n", name)
+	return nil
+}
+
+func profileTenantName(profile *cf.Profile) string {
+	if profile.Tenant == nil {
+		return "-"
+	}
+	return profile.Tenant.Name
+}
+
+func profileProjectName(profile *cf.Profile) string {
+	if profile.Project == nil {
+		return "-"
+	}
+	return profile.Project.Name
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package command
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+
+	cf "github.com/vmware/photon-controller-cli/photon/configuration"
+
+	"github.com/urfave/cli"
+)
+
+func TestTargetProfiles(t *testing.T) {
+	configOri, err := cf.LoadConfig()
+	if err != nil {
+		t.Error("Not expecting error loading config file")
+	}
+
+	err = cf.SaveConfig(&cf.Configuration{
+		CloudTarget: "http://staging:9000",
+		Token:       "staging-token",
+		Tenant:      &cf.TenantConfiguration{Name: "staging-tenant", ID: "1"},
+	})
+	if err != nil {
+		t.Error("Not expecting error when saving config file")
+	}
+
+	set := flag.NewFlagSet("test", 0)
+	err = set.Parse([]string{"staging"})
+	if err != nil {
+		t.Error("Not expecting arguments parsing to fail")
+	}
+	cxt := cli.NewContext(nil, set, nil)
+	err = addProfile(cxt)
+	if err != nil {
+		t.Error("Not expecting error when adding profile: ", err)
+	}
+
+	err = addProfile(cxt)
+	if err == nil {
+		t.Error("Expecting error when adding an existing profile")
+	}
+
+	// Copy the staging profile under another name and change its target
+	set = flag.NewFlagSet("test", 0)
+	err = set.Parse([]string{"production"})
+	if err != nil {
+		t.Error("Not expecting arguments parsing to fail")
+	}
+	cxt = cli.NewContext(nil, set, nil)
+	err = addProfile(cxt)
+	if err != nil {
+		t.Error("Not expecting error when adding profile: ", err)
+	}
+	config, err := cf.LoadConfig()
+	if err != nil {
+		t.Error("Not expecting error loading config file")
+	}
+	config.Profiles["production"].CloudTarget = "http://production:9000"
+	err = cf.SaveConfig(config)
+	if err != nil {
+		t.Error("Not expecting error when saving config file")
+	}
+
+	err = useProfile(cxt)
+	if err != nil {
+		t.Error("Not expecting error when using profile: ", err)
+	}
+	config, err = cf.LoadConfig()
+	if err != nil {
+		t.Error("Not expecting error loading config file")
+	}
+	if config.CurrentProfile != "production" || config.CloudTarget != "http://production:9000" {
+		t.Error("Settings in use do not match the selected profile")
+	}
+	if config.Tenant == nil || config.Tenant.Name != "staging-tenant" {
+		t.Error("Tenant was not copied into the new profile")
+	}
+
+	set = flag.NewFlagSet("test", 0)
+	cxt = cli.NewContext(nil, set, nil)
+	var buf bytes.Buffer
+	err = listProfiles(cxt, &buf)
+	if err != nil {
+		t.Error("Not expecting error when listing profiles: ", err)
+	}
+	err = checkRegExp(`\*\s+production\s+http://production:9000`, buf)
+	if err != nil {
+		t.Errorf("Current profile not marked in list: %s", err)
+	}
+	err = checkRegExp(`staging\s+http://staging:9000\s+staging-tenant`, buf)
+	if err != nil {
+		t.Errorf("Profile missing from list: %s", err)
+	}
+
+	set = flag.NewFlagSet("test", 0)
+	err = set.Parse([]string{"staging"})
+	if err != nil {
+		t.Error("Not expecting arguments parsing to fail")
+	}
+	cxt = cli.NewContext(nil, set, nil)
+	err = deleteProfile(cxt)
+	if err != nil {
+		t.Error("Not expecting error when deleting profile: ", err)
+	}
+	err = deleteProfile(cxt)
+	if err == nil {
+		t.Error("Expecting error when deleting a missing profile")
+	}
+	config, err = cf.LoadConfig()
+	if err != nil {
+		t.Error("Not expecting error loading config file")
+	}
+	if len(config.Profiles) != 1 {
+		t.Error("Expecting exactly one profile after delete")
+	}
+
+	err = cf.SaveConfig(configOri)
+	if err != nil {
+		t.Error("Not expecting error when saving config file")
+	}
+}
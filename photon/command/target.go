@@ -18,6 +18,7 @@ import (
 	"log"
 	"net/url"
 	"os"
+	"path/filepath"
 	"syscall"
 
 	"github.com/urfave/cli"
@@ -36,6 +37,8 @@ import (
 //              login;  Usage: target login <token>
 //              logout; Usage: target logout
 //              show;   Usage: target show
+//              profile; Usage: target profile <add|use|list|delete>
+//              cert;   Usage: target cert <list|show|add|remove|import>
 func GetTargetCommand() cli.Command {
 	command := cli.Command{
 		Name:  "target",
@@ -92,6 +95,9 @@ func GetTargetCommand() cli.Command {
 				Description: "The typical usage is to provide a username and password.\n" +
 					"   If you do not provide any arguments, you will be prompted for the username and password.\n" +
 					"   On Windows you can login using logged in Windows credentials.\n" +
+					"   Automation accounts log in with a client ID and a file holding their secret, which is\n" +
+					"   read again whenever the tokens need to be renewed:\n" +
+					"      photon target login -u ci@tenant --client-id ci-runner --client-secret-file ~/.ci-secret\n" +
 					"   Logging in will result in you receiving a token, which is stored in the CLI configuration file\n" +
 					"   in ~/.photon-cli/.photon-config. You can see it with the 'photon auth show-login-token'.",
 				Flags: []cli.Flag{
@@ -112,6 +118,14 @@ func GetTargetCommand() cli.Command {
 						Usage: "flag to use logged in Windows credentials to authenticate. Can be " +
 							"used only on Windows OS",
 					},
+					cli.StringFlag{
+						Name:  "client-id",
+						Usage: "OIDC client ID of an automation account, used with --username and --client-secret-file",
+					},
+					cli.StringFlag{
+						Name:  "client-secret-file",
+						Usage: "file holding the secret of the automation account",
+					},
 				},
 				Action: func(c *cli.Context) {
 					err := login(c)
@@ -131,6 +145,10 @@ func GetTargetCommand() cli.Command {
 					}
 				},
 			},
+			// Load target profile related logic from separated file.
+			getTargetProfileCommand(),
+			// Load trusted certificate related logic from separated file.
+			getTargetCertCommand(),
 		},
 	}
 	return command
@@ -188,7 +206,7 @@ func showEndpoint(c *cli.Context) error {
 	if len(config.CloudTarget) == 0 {
 		fmt.Printf("No API target set\n")
 	} else {
-		fmt.Printf("Current API target is '%s'\n", config.CloudTarget)
+		fmt.Printf("Current API target is '%s' (from %s)\n", config.CloudTarget, config.Source(cf.TargetSetting))
 	}
 	return nil
 }
@@ -240,6 +258,10 @@ func login(c *cli.Context) error {
 		return nil
 	}
 
+	if len(c.String("client-id")) != 0 || len(c.String("client-secret-file")) != 0 {
+		return loginWithClientCredentials(c)
+	}
+
 	if !c.GlobalIsSet("non-interactive") && len(token) == 0 {
 		username, err = askForInput("User name (username@tenant): ", username)
 		if err != nil {
@@ -267,12 +289,19 @@ func login(c *cli.Context) error {
 	if err != nil {
 		return err
 	}
+	err = checkLoginTarget(config)
+	if err != nil {
+		return err
+	}
 
 	if len(token) > 0 {
+		// The refresh token of a previous login does not go with this token
 		config.Token = token
+		config.RefreshToken = ""
+		config.Login = &cf.LoginConfiguration{Flow: cf.TokenLogin}
 
 	} else {
-		client.Photonclient, err = client.GetClient(c)
+		client.Photonclient, err = client.GetLoginClient(c)
 		if err != nil {
 			return err
 		}
@@ -284,6 +313,7 @@ func login(c *cli.Context) error {
 
 		config.Token = options.AccessToken
 		config.RefreshToken = options.RefreshToken
+		config.Login = &cf.LoginConfiguration{Flow: cf.PasswordLogin}
 	}
 
 	err = cf.SaveConfig(config)
@@ -301,8 +331,12 @@ func loginUsingWindowsCredentials(c *cli.Context) error {
 	if err != nil {
 		return err
 	}
+	err = checkLoginTarget(config)
+	if err != nil {
+		return err
+	}
 
-	client.Photonclient, err = client.GetClient(c)
+	client.Photonclient, err = client.GetLoginClient(c)
 	if err != nil {
 		return err
 	}
@@ -314,6 +348,62 @@ func loginUsingWindowsCredentials(c *cli.Context) error {
 
 	config.Token = options.AccessToken
 	config.RefreshToken = options.RefreshToken
+	config.Login = &cf.LoginConfiguration{Flow: cf.WindowsLogin}
+
+	err = cf.SaveConfig(config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Login successful")
+	return nil
+}
+
+// Logs in an automation account. The path of the secret file is saved, not the secret,
+// so that the tokens can be renewed without a human user.
+func loginWithClientCredentials(c *cli.Context) error {
+	username := c.String("username")
+	clientID := c.String("client-id")
+	secretFile := c.String("client-secret-file")
+	if len(username) == 0 || len(clientID) == 0 || len(secretFile) == 0 {
+		return fmt.Errorf("Please provide --username, --client-id and --client-secret-file together")
+	}
+	if len(c.String("password")) != 0 || len(c.String("access_token")) != 0 || c.Bool("windows") {
+		return fmt.Errorf("You cannot use client credentials with a password, a token or --windows")
+	}
+	secretFile, err := filepath.Abs(secretFile)
+	if err != nil {
+		return err
+	}
+
+	config, err := cf.LoadConfig()
+	if err != nil {
+		return err
+	}
+	err = checkLoginTarget(config)
+	if err != nil {
+		return err
+	}
+
+	client.Photonclient, err = client.GetLoginClient(c)
+	if err != nil {
+		return err
+	}
+
+	login := &cf.LoginConfiguration{
+		Flow:             cf.ClientCredentialsLogin,
+		Username:         username,
+		ClientID:         clientID,
+		ClientSecretFile: secretFile,
+	}
+	options, err := client.LoginWithClientCredentials(client.Photonclient, login)
+	if err != nil {
+		return err
+	}
+
+	config.Token = options.AccessToken
+	config.RefreshToken = options.RefreshToken
+	config.Login = login
 
 	err = cf.SaveConfig(config)
 	if err != nil {
@@ -324,6 +414,16 @@ func loginUsingWindowsCredentials(c *cli.Context) error {
 	return nil
 }
 
+// Tokens are only saved for the target of the config file, not for one given with
+// --target or PHOTON_TARGET
+func checkLoginTarget(config *cf.Configuration) error {
+	if config.Overridden(cf.TargetSetting) {
+		return fmt.Errorf("Cannot save a login for the target given with the %s, "+
+			"use 'photon target set' or a profile instead", config.Source(cf.TargetSetting))
+	}
+	return nil
+}
+
 // Remove token from the config file
 func logout(c *cli.Context) error {
 	err := checkArgCount(c, 0)
@@ -336,13 +436,15 @@ func logout(c *cli.Context) error {
 	}
 
 	config.Token = ""
+	config.RefreshToken = ""
+	config.Login = nil
 
 	err = cf.SaveConfig(config)
 	if err != nil {
 		return err
 	}
 
-	fmt.Println("Token removed from config file")
+	fmt.Printf("Token removed from %s\n", cf.DescribeCredentialStore(config.CredentialStore))
 
 	return nil
 }
@@ -364,13 +466,13 @@ func configureServerCerts(endpoint string, noChertCheck bool, c *cli.Context) (e
 	// noCertCheck == false -> User wants server cert validation
 	// bTrusted = true -> Server cert is trusted
 	if u.Scheme == "https" {
-		err = setupApiServerCert(u.Host, c.GlobalIsSet("non-interactive"))
+		err = setupApiServerCert(cf.PinnedHost(endpoint), c.GlobalIsSet("non-interactive"))
 		if err != nil {
 			return
 		}
 	}
 
-	client.Photonclient, err = client.GetClient(c)
+	client.Photonclient, err = client.GetLoginClient(c)
 	if err != nil {
 		return
 	}
@@ -385,7 +487,11 @@ func configureServerCerts(endpoint string, noChertCheck bool, c *cli.Context) (e
 		port = 443
 	}
 
-	host := fmt.Sprintf("%s:%v", authInfo.Endpoint, authInfo.Port)
+	host := fmt.Sprintf("%s:%v", authInfo.Endpoint, port)
+	err = setAuthServer(endpoint, host)
+	if err != nil {
+		return
+	}
 	err = setupLightWaveCerts(host, c.GlobalIsSet("non-interactive"))
 	if err != nil {
 		return
@@ -394,9 +500,10 @@ func configureServerCerts(endpoint string, noChertCheck bool, c *cli.Context) (e
 	return
 }
 
+// The certificate of the API server is pinned for its host only
 func setupApiServerCert(host string, isNonInterractive bool) (err error) {
-	err = verifyServerTrust("API", host, isNonInterractive)
-	if err != nil {
+	bTrusted, err := verifyServerTrust("API", host, isNonInterractive)
+	if err != nil || bTrusted {
 		return
 	}
 
@@ -406,7 +513,9 @@ func setupApiServerCert(host string, isNonInterractive bool) (err error) {
 		return
 	}
 
-	err = processCert(cert, "API", host)
+	err = processCert(cert, "API", host, func(cert *x509.Certificate) error {
+		return pinServerCert(host, cert)
+	})
 	if err != nil {
 		return
 	}
@@ -414,9 +523,26 @@ func setupApiServerCert(host string, isNonInterractive bool) (err error) {
 	return
 }
 
-func setupLightWaveCerts(host string, isNonInterractive bool) (err error) {
-	err = verifyServerTrust("Authentication", host, isNonInterractive)
+// Records the authentication server of the target, whose pinned certificates are the only
+// ones of the local store, besides those pinned for no host, that the SDK reaches it with
+func setAuthServer(endpoint string, host string) error {
+	config, err := cf.LoadConfig()
 	if err != nil {
+		return err
+	}
+	if config.AuthServers == nil {
+		config.AuthServers = map[string]string{}
+	}
+	config.AuthServers[cf.PinnedHost(endpoint)] = host
+	return cf.SaveConfig(config)
+}
+
+// The root certificates of the authentication server go to the local certificate store.
+// They are pinned for this host, along with the certificate it presents, so that the
+// SDK only trusts them to reach this server.
+func setupLightWaveCerts(host string, isNonInterractive bool) (err error) {
+	bTrusted, err := verifyServerTrust("Authentication", host, isNonInterractive)
+	if err != nil || bTrusted {
 		return
 	}
 
@@ -426,23 +552,43 @@ func setupLightWaveCerts(host string, isNonInterractive bool) (err error) {
 		return
 	}
 
+	accepted := []*x509.Certificate{}
 	for _, cert := range certs {
-		err = processCert(cert, "Authentication", host)
+		err = processCert(cert, "Authentication", host, func(cert *x509.Certificate) error {
+			accepted = append(accepted, cert)
+			return cf.AddCertToLocalStore(cert)
+		})
 		if err != nil {
 			return
 		}
 	}
+	if len(accepted) == 0 {
+		return
+	}
 
-	return
+	cert, err := getServerCert(host)
+	if err != nil {
+		return
+	}
+	return pinServerCert(host, append(accepted, cert)...)
 }
 
-func verifyServerTrust(serverName string, host string, isNonInterractive bool) (err error) {
+func verifyServerTrust(serverName string, host string, isNonInterractive bool) (bTrusted bool, err error) {
 	//check if we already trust the server
-	bTrusted, _ := isServerTrusted(host)
+	bTrusted, trustErr := isServerTrusted(host)
 	if bTrusted {
 		return
 	}
 
+	if changedErr, ok := trustErr.(client.CertificateChangedError); ok {
+		if isNonInterractive {
+			err = changedErr
+			return
+		}
+		fmt.Printf("Warning: the certificate presented by %s server (%s) has changed since it was trusted.\n",
+			serverName, host)
+	}
+
 	if isNonInterractive {
 		err = fmt.Errorf(
 			"Could not establish trust with API server : %s.\nEither skip certificate validation or accept the server certificate in interactive mode\n",
@@ -453,21 +599,24 @@ func verifyServerTrust(serverName string, host string, isNonInterractive bool) (
 	return
 }
 
-func processCert(cert *x509.Certificate, serverName string, host string) (err error) {
+func processCert(cert *x509.Certificate, serverName string, host string,
+	trust func(*x509.Certificate) error) (err error) {
+
 	trustSrvCrt := ""
 	if cert != nil {
 		fmt.Printf(
-			"Certificate (with below fingerprint) presented by %s server (%s) isn't trusted.\nMD5 = %X\nSHA1  = %X\n",
+			"Certificate (with below fingerprint) presented by %s server (%s) isn't trusted.\nMD5 = %X\nSHA1  = %X\nSHA-256 = %s\n",
 			serverName,
 			host,
 			md5.Sum(cert.Raw),
-			sha1.Sum(cert.Raw))
+			sha1.Sum(cert.Raw),
+			cf.CertFingerprint(cert))
 		//Get the user input on whether to trust the certificate
 		trustSrvCrt, err = askForInput("Do you trust this certificate for future communication? (yes/no): ", trustSrvCrt)
 	}
 
 	if err == nil && cert != nil && trustSrvCrt == "yes" {
-		err = cf.AddCertToLocalStore(cert)
+		err = trust(cert)
 		if err == nil {
 			fmt.Printf(
 				"Saved your preference for future communication with %s server %s\n", serverName, host)
@@ -13,8 +13,14 @@ import (
 	"bytes"
 	"encoding/json"
 	"flag"
+	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
 	"testing"
 
 	"github.com/vmware/photon-controller-cli/photon/client"
@@ -95,7 +101,7 @@ func TestSetEndpoint(t *testing.T) {
 	}
 
 	configRead.CloudTarget = configExpected.CloudTarget
-	if *configRead != *configExpected {
+	if !reflect.DeepEqual(configRead, configExpected) {
 		t.Error("Other configurations changed when setting only cloudtarget")
 	}
 
@@ -247,8 +253,12 @@ func TestLogin(t *testing.T) {
 		t.Error("Token read from file not match what's written to file")
 	}
 
+	if configRead.Login == nil || configRead.Login.Flow != cf.TokenLogin {
+		t.Error("Expecting the login with a token to be recorded")
+	}
 	configRead.Token = configExpected.Token
-	if *configRead != *configExpected {
+	configRead.Login = configExpected.Login
+	if !reflect.DeepEqual(configRead, configExpected) {
 		t.Error("Other configurations changed when setting only token")
 	}
 
@@ -258,6 +268,77 @@ func TestLogin(t *testing.T) {
 	}
 }
 
+func TestLoginWithClientCredentials(t *testing.T) {
+	configOri, err := cf.LoadConfig()
+	if err != nil {
+		t.Error("Not expecting error loading config file")
+	}
+	defer func() {
+		err = cf.SaveConfig(configOri)
+		if err != nil {
+			t.Error("Not expecting error when saving config file")
+		}
+	}()
+	err = cf.SaveConfig(&cf.Configuration{CloudTarget: "test-login"})
+	if err != nil {
+		t.Error("Not expecting error when saving config file")
+	}
+
+	// The authentication server only grants tokens to the automation account
+	oidcServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := r.ParseForm()
+		if err != nil || r.Form.Get("client_id") != "ci-runner" || r.Form.Get("password") != "s3cret" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"access_token":"client-token","refresh_token":"client-refresh-token"}`)
+	}))
+	defer oidcServer.Close()
+	oidcURL, err := url.Parse(oidcServer.URL)
+	if err != nil {
+		t.Error("Not expecting error parsing authentication server URL")
+	}
+	port, _ := strconv.Atoi(oidcURL.Port())
+
+	server := mocks.NewTestServer()
+	defer server.Close()
+	registerJSONResponder(t, "GET", server.URL+rootUrl+"/system/auth",
+		&photon.AuthInfo{Endpoint: oidcURL.Hostname(), Port: port})
+
+	mocks.Activate(true)
+	httpClient := &http.Client{Transport: mocks.DefaultMockTransport}
+	client.Photonclient = photon.NewTestClient(server.URL, &photon.ClientOptions{IgnoreCertificate: true}, httpClient)
+
+	secretFile, err := ioutil.TempFile("", "client-secret-")
+	if err != nil {
+		t.Error("Not expecting error creating secret file")
+	}
+	defer os.Remove(secretFile.Name())
+	_, err = secretFile.WriteString("s3cret\n")
+	if err != nil {
+		t.Error("Not expecting error writing secret file")
+	}
+	_ = secretFile.Close()
+
+	set := flag.NewFlagSet("test", 0)
+	set.String("username", "ci@tenant", "")
+	set.String("client-id", "ci-runner", "")
+	set.String("client-secret-file", secretFile.Name(), "")
+	err = login(cli.NewContext(nil, set, nil))
+	if err != nil {
+		t.Error("Not expecting error when logging in: ", err)
+	}
+
+	config, err := cf.LoadConfig()
+	if err != nil {
+		t.Error("Not expecting error loading config file")
+	}
+	if config.Token != "client-token" || config.Login == nil || config.Login.Flow != cf.ClientCredentialsLogin ||
+		config.Login.ClientSecretFile != secretFile.Name() {
+		t.Errorf("Expecting the tokens and the client credentials login to be saved, got %+v", config.Login)
+	}
+}
+
 func TestLogout(t *testing.T) {
 	configOri, err := cf.LoadConfig()
 	if err != nil {
@@ -310,7 +391,7 @@ func TestLogout(t *testing.T) {
 	}
 
 	configRead.Token = configExpected.Token
-	if *configRead != *configExpected {
+	if !reflect.DeepEqual(configRead, configExpected) {
 		t.Error("Other configurations changed when removing only token")
 	}
 
@@ -0,0 +1,122 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package command
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	cf "github.com/vmware/photon-controller-cli/photon/configuration"
+)
+
+const (
+	defaultTaskTimeout  = 30 * time.Minute
+	defaultPollInterval = 500 * time.Millisecond
+	// The delay between two polls of a task doubles up to this value
+	maxTaskPollInterval = 15 * time.Second
+	taskRetryCount      = 3
+
+	// Services take longer to become ready than tasks to complete, so waiting for one
+	// has its own defaults
+	defaultServiceTimeout      = 60 * time.Minute
+	defaultServicePollInterval = 2 * time.Second
+)
+
+// Values of the global --task-timeout and --poll-interval flags, zero when not given.
+// They take precedence over the TaskTimeout and PollInterval settings of the config file.
+var TaskTimeout time.Duration
+var PollInterval time.Duration
+
+// How long to wait for a task and how often to poll it at first
+type taskPollOptions struct {
+	Timeout  time.Duration
+	Interval time.Duration
+}
+
+var jitterRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+var jitterLock sync.Mutex
+
+// Returns the task polling options from the global flags, the config file or the defaults
+func getTaskPollOptions() (*taskPollOptions, error) {
+	return getPollOptions(defaultTaskTimeout, defaultPollInterval)
+}
+
+// Returns the options for waiting on a service to become ready, which default to a
+// longer timeout and interval than tasks but follow the same flags and settings
+func getServicePollOptions() (*taskPollOptions, error) {
+	return getPollOptions(defaultServiceTimeout, defaultServicePollInterval)
+}
+
+func getPollOptions(timeout time.Duration, interval time.Duration) (*taskPollOptions, error) {
+	options := &taskPollOptions{Timeout: timeout, Interval: interval}
+
+	config, err := cf.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	if len(config.TaskTimeout) != 0 {
+		options.Timeout, err = parsePositiveDuration(config.TaskTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid TaskTimeout in configuration: %s", err)
+		}
+	}
+	if len(config.PollInterval) != 0 {
+		options.Interval, err = parsePositiveDuration(config.PollInterval)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid PollInterval in configuration: %s", err)
+		}
+	}
+
+	if TaskTimeout > 0 {
+		options.Timeout = TaskTimeout
+	}
+	if PollInterval > 0 {
+		options.Interval = PollInterval
+	}
+	return options, nil
+}
+
+func parsePositiveDuration(value string) (time.Duration, error) {
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if duration <= 0 {
+		return 0, fmt.Errorf("'%s' is not a positive duration", value)
+	}
+	return duration, nil
+}
+
+// Returns the delay before the next poll: the current interval doubled, up to
+// maxTaskPollInterval, unless the first interval was already longer
+func nextPollInterval(interval time.Duration) time.Duration {
+	if interval >= maxTaskPollInterval {
+		return interval
+	}
+	interval *= 2
+	if interval > maxTaskPollInterval {
+		interval = maxTaskPollInterval
+	}
+	return interval
+}
+
+// Adds up to 20% of random jitter to a poll interval, so that many commands
+// waiting at the same time do not poll in lockstep
+func withJitter(interval time.Duration) time.Duration {
+	spread := int64(interval / 5)
+	if spread <= 0 {
+		return interval
+	}
+	jitterLock.Lock()
+	defer jitterLock.Unlock()
+	return interval + time.Duration(jitterRand.Int63n(spread))
+}
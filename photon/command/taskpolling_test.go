@@ -0,0 +1,161 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package command
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/vmware/photon-controller-cli/photon/client"
+	cf "github.com/vmware/photon-controller-cli/photon/configuration"
+	"github.com/vmware/photon-controller-cli/photon/mocks"
+
+	"github.com/vmware/photon-controller-go-sdk/photon"
+)
+
+func TestGetTaskPollOptions(t *testing.T) {
+	configOri, err := cf.LoadConfig()
+	if err != nil {
+		t.Error("Not expecting error loading config file")
+	}
+	defer func() {
+		TaskTimeout = 0
+		PollInterval = 0
+		err = cf.SaveConfig(configOri)
+		if err != nil {
+			t.Error("Not expecting error when saving config file")
+		}
+	}()
+
+	err = cf.SaveConfig(&cf.Configuration{})
+	if err != nil {
+		t.Error("Not expecting error when saving config file")
+	}
+	options, err := getTaskPollOptions()
+	if err != nil {
+		t.Error("Not expecting error getting poll options: ", err)
+	}
+	if options.Timeout != defaultTaskTimeout || options.Interval != defaultPollInterval {
+		t.Errorf("Expecting default poll options, got %+v", options)
+	}
+	options, err = getServicePollOptions()
+	if err != nil {
+		t.Error("Not expecting error getting service poll options: ", err)
+	}
+	if options.Timeout != 60*time.Minute || options.Interval != 2*time.Second {
+		t.Errorf("Expecting default service poll options, got %+v", options)
+	}
+
+	err = cf.SaveConfig(&cf.Configuration{TaskTimeout: "2h", PollInterval: "2s"})
+	if err != nil {
+		t.Error("Not expecting error when saving config file")
+	}
+	options, err = getTaskPollOptions()
+	if err != nil {
+		t.Error("Not expecting error getting poll options: ", err)
+	}
+	if options.Timeout != 2*time.Hour || options.Interval != 2*time.Second {
+		t.Errorf("Expecting poll options from the config file, got %+v", options)
+	}
+
+	// The global flags take precedence over the config file
+	TaskTimeout = 90 * time.Minute
+	options, err = getTaskPollOptions()
+	if err != nil {
+		t.Error("Not expecting error getting poll options: ", err)
+	}
+	if options.Timeout != 90*time.Minute || options.Interval != 2*time.Second {
+		t.Errorf("Expecting the task timeout from the flag, got %+v", options)
+	}
+	options, err = getServicePollOptions()
+	if err != nil {
+		t.Error("Not expecting error getting service poll options: ", err)
+	}
+	if options.Timeout != 90*time.Minute || options.Interval != 2*time.Second {
+		t.Errorf("Expecting the service timeout from the flag, got %+v", options)
+	}
+
+	err = cf.SaveConfig(&cf.Configuration{PollInterval: "-1s"})
+	if err != nil {
+		t.Error("Not expecting error when saving config file")
+	}
+	_, err = getTaskPollOptions()
+	if err == nil {
+		t.Error("Expecting error for a negative poll interval")
+	}
+}
+
+func TestPollIntervalBackoff(t *testing.T) {
+	interval := defaultPollInterval
+	for i := 0; i < 10; i++ {
+		next := nextPollInterval(interval)
+		if next < interval || next > maxTaskPollInterval {
+			t.Errorf("Unexpected poll interval %s after %s", next, interval)
+		}
+		jittered := withJitter(next)
+		if jittered < next || jittered > next+next/5 {
+			t.Errorf("Jitter out of bounds: %s for %s", jittered, next)
+		}
+		interval = next
+	}
+	if interval != maxTaskPollInterval {
+		t.Errorf("Expecting poll interval to reach %s, got %s", maxTaskPollInterval, interval)
+	}
+}
+
+func TestPollTaskTimeout(t *testing.T) {
+	server := mocks.NewTestServer()
+	defer server.Close()
+
+	registerJSONResponder(t, "GET", server.URL+rootUrl+"/tasks/task-id",
+		&photon.Task{ID: "task-id", Operation: "CREATE_IMAGE", State: "STARTED"})
+
+	mocks.Activate(true)
+	httpClient := &http.Client{Transport: mocks.DefaultMockTransport}
+	client.Photonclient = photon.NewTestClient(server.URL, nil, httpClient)
+
+	options := &taskPollOptions{Timeout: 30 * time.Millisecond, Interval: time.Millisecond}
+	_, err := pollTaskWithOptions(client.Photonclient, "task-id", options, false)
+	if err == nil {
+		t.Error("Expecting polling to time out")
+	}
+}
+
+func TestPollTasksConcurrently(t *testing.T) {
+	server := mocks.NewTestServer()
+	defer server.Close()
+
+	ids := []string{"task-1", "task-2", "task-3", "task-4"}
+	for _, id := range ids {
+		registerJSONResponder(t, "GET", server.URL+rootUrl+"/tasks/"+id,
+			&photon.Task{ID: id, Operation: "STOP_VM", State: "COMPLETED"})
+	}
+
+	mocks.Activate(true)
+	httpClient := &http.Client{Transport: mocks.DefaultMockTransport}
+	client.Photonclient = photon.NewTestClient(server.URL, nil, httpClient)
+
+	// One of the tasks displays its progress, as a command waiting for a task does
+	// while other tasks are waited for in the background
+	options := &taskPollOptions{Timeout: time.Second, Interval: time.Millisecond}
+	errs := make(chan error, len(ids))
+	for i, id := range ids {
+		go func(id string, showProgress bool) {
+			_, err := pollTaskWithOptions(client.Photonclient, id, options, showProgress)
+			errs <- err
+		}(id, i == 0)
+	}
+	for range ids {
+		if err := <-errs; err != nil {
+			t.Error("Not expecting error polling tasks at the same time: ", err)
+		}
+	}
+}
@@ -12,16 +12,19 @@ package command
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"sort"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/urfave/cli"
 	"github.com/vmware/photon-controller-go-sdk/photon"
 
 	"github.com/vmware/photon-controller-cli/photon/client"
+	"github.com/vmware/photon-controller-cli/photon/utils"
 )
 
 type stepSorter []photon.Step
@@ -33,7 +36,7 @@ func (step stepSorter) Less(i, j int) bool { return step[i].Sequence < step[j].S
 // Creates a cli.Command for tasks
 // Subcommands: list; Usage: task list [<options>]
 //              show; Usage: task show <id>
-//              monitor; Usage: task monitor <id>
+//              monitor; Usage: task monitor <id> [<id>...]
 func GetTasksCommand() cli.Command {
 	command := cli.Command{
 		Name:  "task",
@@ -56,6 +59,15 @@ func GetTasksCommand() cli.Command {
 						Name:  "state, s",
 						Usage: "specify task state for filtering",
 					},
+					cli.BoolFlag{
+						Name:  "watch, w",
+						Usage: "keep listing the tasks, printing a line each time one starts or changes",
+					},
+					cli.DurationFlag{
+						Name:  "interval",
+						Value: 2 * time.Second,
+						Usage: "delay between two refreshes of --watch",
+					},
 				},
 				Action: func(c *cli.Context) {
 					err := listTasks(c)
@@ -77,8 +89,11 @@ func GetTasksCommand() cli.Command {
 			},
 			{
 				Name:      "monitor",
-				Usage:     "Monitor task progress with specified ID",
-				ArgsUsage: "<task-id>",
+				Usage:     "Monitor task progress with specified IDs",
+				ArgsUsage: "<task-id> [<task-id>...]",
+				Description: "Follows the progress of one or more tasks until they complete. With several\n" +
+					"   tasks, a line is printed each time one of them moves to another step or state,\n" +
+					"   and the command fails if any of them fails.",
 				Action: func(c *cli.Context) {
 					err := monitorTask(c)
 					if err != nil {
@@ -111,6 +126,9 @@ func listTasks(c *cli.Context) error {
 		EntityID:   entityId,
 		EntityKind: entityKind,
 	}
+	if c.Bool("watch") {
+		return watchTasks(c, os.Stdout, options, nil)
+	}
 	taskList, err := client.Photonclient.Tasks.GetAll(options)
 	if err != nil {
 		return err
@@ -178,6 +196,9 @@ func showTask(c *cli.Context) error {
 
 // Track the progress of the task, returns an error if one occurred
 func monitorTask(c *cli.Context) error {
+	if len(c.Args()) > 1 {
+		return monitorTasks(c, os.Stdout)
+	}
 	err := checkArgCount(c, 1)
 	if err != nil {
 		return err
@@ -190,13 +211,13 @@ func monitorTask(c *cli.Context) error {
 	}
 
 	if c.GlobalIsSet("non-interactive") {
-		task, err := client.Photonclient.Tasks.Wait(id)
+		task, err := followTask(id, false)
 		if err != nil {
 			return err
 		}
 		fmt.Printf("%s\t%s\t%s\t%s\n", task.ID, task.State, task.Entity.ID, task.Entity.Kind)
 	} else {
-		task, err := pollTask(id)
+		task, err := followTask(id, true)
 		if err != nil {
 			return err
 		}
@@ -213,6 +234,124 @@ func monitorTask(c *cli.Context) error {
 	return nil
 }
 
+// Lists the tasks matching the options every --interval and prints the ones that are new
+// or changed since the previous refresh. Stops when stop is closed, or never if it is nil.
+func watchTasks(c *cli.Context, w io.Writer, options *photon.TaskGetOptions, stop <-chan struct{}) error {
+	interval := c.Duration("interval")
+	if interval <= 0 {
+		return fmt.Errorf("--interval must be a positive duration")
+	}
+
+	if !c.GlobalIsSet("non-interactive") && !utils.NeedsFormatting(c) {
+		fmt.Fprintf(w, taskWatchFormat, "TASK", "OPERATION", "ENTITY", "PROGRESS")
+	}
+	seen := map[string]string{}
+	for i := 0; ; i++ {
+		if i != 0 {
+			select {
+			case <-stop:
+				return nil
+			case <-time.After(interval):
+			}
+		}
+		taskList, err := client.Photonclient.Tasks.GetAll(options)
+		if err != nil {
+			return err
+		}
+		for _, task := range taskList.Items {
+			progress := taskProgress(&task)
+			if seen[task.ID] == progress {
+				continue
+			}
+			seen[task.ID] = progress
+			printTaskChange(task, c, w)
+		}
+	}
+}
+
+// Follows several tasks until all of them are done, printing a line each time one
+// of them changes. Returns an error if any of the tasks fails or does not finish in time.
+func monitorTasks(c *cli.Context, w io.Writer) error {
+	ids := removeDuplicates(c.Args())
+	var err error
+	client.Photonclient, err = client.GetClient(c)
+	if err != nil {
+		return err
+	}
+	options, err := getTaskPollOptions()
+	if err != nil {
+		return err
+	}
+
+	if !c.GlobalIsSet("non-interactive") && !utils.NeedsFormatting(c) {
+		fmt.Fprintf(w, taskWatchFormat, "TASK", "OPERATION", "ENTITY", "PROGRESS")
+	}
+	start := time.Now()
+	interval := options.Interval
+	seen := map[string]string{}
+	done := map[string]bool{}
+	retries := map[string]int{}
+	failed := 0
+	for len(done) < len(ids) {
+		for _, id := range ids {
+			if done[id] {
+				continue
+			}
+			task, err := client.Photonclient.Tasks.Get(id)
+			if task == nil {
+				if _, ok := err.(photon.ApiError); ok {
+					return err
+				}
+				// Other errors, e.g. the server being unreachable, are retried a few times
+				retries[id]++
+				if retries[id] > taskRetryCount {
+					return fmt.Errorf("Could not get task %s: %v", id, err)
+				}
+				continue
+			}
+			retries[id] = 0
+			progress := taskProgress(task)
+			if seen[id] != progress {
+				seen[id] = progress
+				printTaskChange(*task, c, w)
+			}
+			if task.State == "COMPLETED" || task.State == "ERROR" {
+				done[id] = true
+				if task.State == "ERROR" {
+					failed++
+				}
+			}
+		}
+		if len(done) == len(ids) {
+			break
+		}
+		if time.Since(start) >= options.Timeout {
+			return fmt.Errorf("Timed out after %s with %d of %d tasks still running",
+				options.Timeout, len(ids)-len(done), len(ids))
+		}
+		time.Sleep(withJitter(interval))
+		interval = nextPollInterval(interval)
+	}
+
+	if failed != 0 {
+		return fmt.Errorf("%d of %d tasks failed", failed, len(ids))
+	}
+	return nil
+}
+
+// Line format of the tasks printed by 'task list --watch' and 'task monitor'
+const taskWatchFormat = "%-36s  %-28s  %-48s  %s\n"
+
+func printTaskChange(task photon.Task, c *cli.Context, w io.Writer) {
+	if c.GlobalIsSet("non-interactive") {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", task.ID, task.State, task.Operation, task.Entity.Kind, task.Entity.ID)
+	} else if utils.NeedsFormatting(c) {
+		utils.FormatObject(task, w, c)
+	} else {
+		fmt.Fprintf(w, taskWatchFormat, task.ID, task.Operation, task.Entity.Kind+" "+task.Entity.ID, taskProgress(&task))
+	}
+}
+
 func printTaskSteps(task *photon.Task, isScripting bool) error {
 	if isScripting {
 		for _, step := range task.Steps {
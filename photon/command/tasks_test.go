@@ -10,10 +10,13 @@
 package command
 
 import (
+	"bytes"
 	"encoding/json"
 	"flag"
 	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/vmware/photon-controller-cli/photon/client"
 	"github.com/vmware/photon-controller-cli/photon/mocks"
@@ -137,3 +140,122 @@ func TestShowMonitorTask(t *testing.T) {
 		t.Error("Not expecting error monitoring task: " + err.Error())
 	}
 }
+
+func TestWatchTasks(t *testing.T) {
+	server := mocks.NewTestServer()
+	defer server.Close()
+
+	// The task moves to its second step between the two refreshes, watching stops after
+	// the third
+	refreshes := 0
+	stop := make(chan struct{})
+	mocks.RegisterResponder("GET", server.URL+rootUrl+"/tasks?entityKind=host",
+		func(req *http.Request) (*http.Response, error) {
+			refreshes++
+			if refreshes == 3 {
+				close(stop)
+			}
+			task := photon.Task{ID: "host-task-id", Operation: "ENTER_MAINTENANCE_MODE", State: "STARTED",
+				Entity: photon.Entity{ID: "host-id", Kind: "host"},
+				Steps: []photon.Step{{Sequence: 0, Operation: "SUSPEND_HOST", State: "STARTED"},
+					{Sequence: 1, Operation: "ENTER_MAINTENANCE", State: "QUEUED"}}}
+			if refreshes > 1 {
+				task.Steps[0].State = "COMPLETED"
+				task.Steps[1].State = "STARTED"
+			}
+			response, err := json.Marshal(MockTasksPage{Items: []photon.Task{task}})
+			if err != nil {
+				t.Error("Not expecting error serializing expected taskLists")
+			}
+			return mocks.CreateResponder(200, string(response[:]))(req)
+		})
+
+	mocks.Activate(true)
+	httpClient := &http.Client{Transport: mocks.DefaultMockTransport}
+	client.Photonclient = photon.NewTestClient(server.URL, nil, httpClient)
+
+	set := flag.NewFlagSet("test", 0)
+	set.Duration("interval", time.Millisecond, "")
+	cxt := cli.NewContext(nil, set, nil)
+
+	var buf bytes.Buffer
+	err := watchTasks(cxt, &buf, &photon.TaskGetOptions{EntityKind: "host"}, stop)
+	if err != nil {
+		t.Error("Not expecting error watching tasks: ", err)
+	}
+	err = checkRegExp(`host-task-id\s+ENTER_MAINTENANCE_MODE\s+host host-id\s+SUSPEND_HOST \| Step 1/2`, buf)
+	if err != nil {
+		t.Errorf("First step missing from watch output: %s", err)
+	}
+	err = checkRegExp(`ENTER_MAINTENANCE \| Step 2/2`, buf)
+	if err != nil {
+		t.Errorf("Second step missing from watch output: %s", err)
+	}
+	if strings.Count(buf.String(), "host-task-id") != 2 {
+		t.Errorf("Expecting a line per change only, got:\n%s", buf.String())
+	}
+}
+
+func TestMonitorTasks(t *testing.T) {
+	server := mocks.NewTestServer()
+	defer server.Close()
+
+	registerJSONResponder(t, "GET", server.URL+rootUrl+"/tasks/monitor-task-1",
+		&photon.Task{ID: "monitor-task-1", Operation: "STOP_VM", State: "COMPLETED"})
+	registerJSONResponder(t, "GET", server.URL+rootUrl+"/tasks/monitor-task-2",
+		&photon.Task{ID: "monitor-task-2", Operation: "STOP_VM", State: "ERROR"})
+
+	mocks.Activate(true)
+	httpClient := &http.Client{Transport: mocks.DefaultMockTransport}
+	client.Photonclient = photon.NewTestClient(server.URL, nil, httpClient)
+
+	// A task given twice is only waited for once
+	set := flag.NewFlagSet("test", 0)
+	err := set.Parse([]string{"monitor-task-1", "monitor-task-2", "monitor-task-1"})
+	if err != nil {
+		t.Error("Not expecting arguments parsing to fail")
+	}
+	cxt := cli.NewContext(nil, set, nil)
+
+	var buf bytes.Buffer
+	err = monitorTasks(cxt, &buf)
+	if err == nil || err.Error() != "1 of 2 tasks failed" {
+		t.Errorf("Expecting one of the tasks to fail, got: %v", err)
+	}
+	err = checkRegExp(`monitor-task-1\s+STOP_VM\s+.*COMPLETED`, buf)
+	if err != nil {
+		t.Errorf("Completed task missing from output: %s", err)
+	}
+	err = checkRegExp(`monitor-task-2\s+STOP_VM\s+.*ERROR`, buf)
+	if err != nil {
+		t.Errorf("Failed task missing from output: %s", err)
+	}
+}
+
+func TestMonitorTasksUnreachable(t *testing.T) {
+	server := mocks.NewTestServer()
+	defer server.Close()
+
+	// No responder: getting the task fails without an API error, as when the server is down
+	mocks.Activate(true)
+	httpClient := &http.Client{Transport: mocks.DefaultMockTransport}
+	client.Photonclient = photon.NewTestClient(server.URL, nil, httpClient)
+
+	PollInterval = time.Millisecond
+	defer func() {
+		PollInterval = 0
+	}()
+
+	set := flag.NewFlagSet("test", 0)
+	err := set.Parse([]string{"unreachable-task-1", "unreachable-task-2"})
+	if err != nil {
+		t.Error("Not expecting arguments parsing to fail")
+	}
+	cxt := cli.NewContext(nil, set, nil)
+
+	var buf bytes.Buffer
+	err = monitorTasks(cxt, &buf)
+	if err == nil || !strings.HasPrefix(err.Error(), "Could not get task unreachable-task-1") {
+		t.Errorf("Expecting monitoring to give up on the unreachable tasks, got: %v", err)
+	}
+}
@@ -235,7 +235,7 @@ func modifyTenantQuota(c *cli.Context, w io.Writer, operation string) error {
 		}
 
 		_, err = waitOnTaskOperation(createTask.ID, c)
-		if err != nil {
+		if err != nil || isAsync(c) {
 			return err
 		}
 
@@ -333,7 +333,7 @@ func createTenant(c *cli.Context, w io.Writer) error {
 	}
 
 	id, err := waitOnTaskOperation(createTask.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -416,7 +416,7 @@ func deleteTenant(c *cli.Context) error {
 	}
 
 	_, err = waitOnTaskOperation(deleteTask.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -600,7 +600,7 @@ func setSecurityGroups(c *cli.Context) error {
 	}
 
 	_, err = waitOnTaskOperation(task.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -681,7 +681,7 @@ func modifyTenantIamPolicy(c *cli.Context, w io.Writer, action string) error {
 	}
 
 	_, err = waitOnTaskOperation(task.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -0,0 +1,302 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package command
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/urfave/cli"
+	"github.com/vmware/photon-controller-go-sdk/photon"
+
+	"github.com/vmware/photon-controller-cli/photon/client"
+	"github.com/vmware/photon-controller-cli/photon/utils"
+)
+
+// Flags of the VM operations that can act on several VMs at once
+// (start, stop, suspend, resume, restart and delete)
+func getVMSelectorFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  "tenant, t",
+			Usage: "Tenant name, used with the selector flags",
+		},
+		cli.StringFlag{
+			Name:  "project, p",
+			Usage: "Project name, used with the selector flags",
+		},
+		cli.StringFlag{
+			Name:  "name-regex",
+			Usage: "select the VMs of the project whose name matches this regular expression",
+		},
+		cli.StringFlag{
+			Name:  "tag",
+			Usage: "select the VMs of the project that have this tag",
+		},
+		cli.StringFlag{
+			Name:  "state",
+			Usage: "select the VMs of the project in this state, e.g. STARTED",
+		},
+		cli.StringFlag{
+			Name:  "host",
+			Usage: "select the VMs of the project running on this host",
+		},
+		cli.IntFlag{
+			Name:  "parallel",
+			Value: 1,
+			Usage: "number of VM operations to run at the same time",
+		},
+	}
+}
+
+// Description of the selector flags, appended to the description of each bulk VM operation
+const vmSelectorDescription = "\n\n   Several VM IDs can be given, or VMs can be selected in the current project (or the one\n" +
+	"   given with --tenant and --project) with --name-regex, --tag, --state and --host. Selectors\n" +
+	"   are combined, so a VM has to match all of them. Use --parallel to run several operations\n" +
+	"   at the same time; a summary of the result for each VM is printed at the end.\n" +
+	"   Example:\n" +
+	"      photon vm stop --name-regex '^web-' --state STARTED --parallel 10"
+
+var vmSelectorFlagNames = []string{"name-regex", "tag", "state", "host"}
+
+// Result of an operation on one VM of a bulk operation
+type vmOperationResult struct {
+	ID    string `json:"id"`
+	Name  string `json:"name,omitempty"`
+	Task  string `json:"task,omitempty"`
+	State string `json:"state"`
+	Error string `json:"error,omitempty"`
+}
+
+// Tells if the command was asked to act on more than a single VM ID
+func isBulkVMOperation(c *cli.Context) bool {
+	if len(c.Args()) > 1 || c.IsSet("parallel") {
+		return true
+	}
+	for _, name := range vmSelectorFlagNames {
+		if c.IsSet(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Runs the operation on every VM given as argument or matching the selectors, with up to
+// --parallel operations at the same time, and prints a summary of the results
+func bulkVMOperation(c *cli.Context, w io.Writer, operation string,
+	run func(id string) (*photon.Task, error)) error {
+
+	parallel := c.Int("parallel")
+	if parallel < 1 {
+		return fmt.Errorf("--parallel must be at least 1")
+	}
+
+	var err error
+	client.Photonclient, err = client.GetClient(c)
+	if err != nil {
+		return err
+	}
+
+	// Resolved once, the workers wait for their tasks at the same time
+	options, err := getTaskPollOptions()
+	if err != nil {
+		return err
+	}
+
+	vms, err := selectVMs(c)
+	if err != nil {
+		return err
+	}
+	if len(vms) == 0 {
+		return fmt.Errorf("No VMs match the given selectors")
+	}
+
+	if operation == "delete" && !c.GlobalIsSet("non-interactive") && !utils.NeedsFormatting(c) {
+		fmt.Fprintf(w, "The following %d VMs will be deleted:\n", len(vms))
+		for _, vm := range vms {
+			fmt.Fprintf(w, "  %s\t%s\n", vm.ID, vm.Name)
+		}
+		if !confirmed(c) {
+			fmt.Fprintf(w, "OK. Canceled\n")
+			return nil
+		}
+	}
+
+	results := make([]vmOperationResult, len(vms))
+	var printLock sync.Mutex
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				results[index] = runVMOperation(vms[index], run, isAsync(c), options)
+				if !c.GlobalIsSet("non-interactive") && !utils.NeedsFormatting(c) {
+					printLock.Lock()
+					fmt.Fprintf(w, "%s %s: %s\n", operation, vmDisplayName(vms[index]), results[index].State)
+					printLock.Unlock()
+				}
+			}
+		}()
+	}
+	for index := range vms {
+		jobs <- index
+	}
+	close(jobs)
+	wg.Wait()
+
+	return printVMOperationResults(results, operation, w, c)
+}
+
+// Returns the VMs given as arguments, or the VMs of the project matching all the selectors
+func selectVMs(c *cli.Context) ([]photon.VM, error) {
+	hasSelectors := false
+	for _, name := range vmSelectorFlagNames {
+		if c.IsSet(name) {
+			hasSelectors = true
+		}
+	}
+
+	if !hasSelectors {
+		if len(c.Args()) == 0 {
+			return nil, fmt.Errorf("Please provide VM IDs or selectors")
+		}
+		vms := []photon.VM{}
+		for _, id := range c.Args() {
+			vms = append(vms, photon.VM{ID: id})
+		}
+		return vms, nil
+	}
+	if len(c.Args()) != 0 {
+		return nil, fmt.Errorf("VM IDs and selectors cannot be used together")
+	}
+
+	var nameRegex *regexp.Regexp
+	if c.IsSet("name-regex") {
+		var err error
+		nameRegex, err = regexp.Compile(c.String("name-regex"))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid --name-regex: %s", err)
+		}
+	}
+
+	tenant, err := verifyTenant(c.String("tenant"))
+	if err != nil {
+		return nil, err
+	}
+	project, err := verifyProject(tenant.ID, c.String("project"))
+	if err != nil {
+		return nil, err
+	}
+	vmList, err := client.Photonclient.Projects.GetVMs(project.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	vms := []photon.VM{}
+	for _, vm := range vmList.Items {
+		if nameRegex != nil && !nameRegex.MatchString(vm.Name) {
+			continue
+		}
+		if c.IsSet("tag") && !hasTag(vm.Tags, c.String("tag")) {
+			continue
+		}
+		if c.IsSet("state") && !strings.EqualFold(vm.State, c.String("state")) {
+			continue
+		}
+		if c.IsSet("host") && vm.Host != c.String("host") {
+			continue
+		}
+		vms = append(vms, vm)
+	}
+	return vms, nil
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Submits the operation for one VM and, unless async is set, waits for its task to finish
+func runVMOperation(vm photon.VM, run func(id string) (*photon.Task, error), async bool,
+	options *taskPollOptions) vmOperationResult {
+
+	result := vmOperationResult{ID: vm.ID, Name: vm.Name}
+	task, err := run(vm.ID)
+	if err == nil {
+		result.Task = task.ID
+		if async {
+			auditTask(task.ID, nil, nil)
+		} else {
+			task, err = waitForTaskWithOptions(task.ID, options, false)
+		}
+	}
+	if err != nil {
+		result.State = "ERROR"
+		result.Error = err.Error()
+	} else {
+		result.State = task.State
+	}
+	return result
+}
+
+func vmDisplayName(vm photon.VM) string {
+	if len(vm.Name) == 0 {
+		return vm.ID
+	}
+	return fmt.Sprintf("%s (%s)", vm.Name, vm.ID)
+}
+
+func printVMOperationResults(results []vmOperationResult, operation string, w io.Writer, c *cli.Context) error {
+	failed := 0
+	for _, result := range results {
+		if len(result.Error) != 0 {
+			failed++
+		}
+	}
+
+	if c.GlobalIsSet("non-interactive") {
+		for _, result := range results {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", result.ID, result.Task, result.State, result.Error)
+		}
+	} else if utils.NeedsFormatting(c) {
+		utils.FormatObjects(results, w, c)
+	} else {
+		tw := new(tabwriter.Writer)
+		tw.Init(w, 4, 4, 2, ' ', 0)
+		fmt.Fprintf(tw, "\nVM ID\tName\tTask\tResult\n")
+		for _, result := range results {
+			outcome := result.State
+			if len(result.Error) != 0 {
+				outcome = result.Error
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", result.ID, result.Name, result.Task, outcome)
+		}
+		err := tw.Flush()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "\nTotal: %d, succeeded: %d, failed: %d\n", len(results), len(results)-failed, failed)
+	}
+
+	if failed != 0 {
+		return fmt.Errorf("%s failed for %d of %d VMs", operation, failed, len(results))
+	}
+	return nil
+}
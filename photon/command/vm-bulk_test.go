@@ -0,0 +1,112 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package command
+
+import (
+	"bytes"
+	"flag"
+	"net/http"
+	"testing"
+
+	"github.com/vmware/photon-controller-cli/photon/client"
+	cf "github.com/vmware/photon-controller-cli/photon/configuration"
+	"github.com/vmware/photon-controller-cli/photon/mocks"
+
+	"github.com/urfave/cli"
+	"github.com/vmware/photon-controller-go-sdk/photon"
+)
+
+func TestBulkStopVMs(t *testing.T) {
+	configOri, err := cf.LoadConfig()
+	if err != nil {
+		t.Error("Not expecting error loading config file")
+	}
+	err = cf.SaveConfig(&cf.Configuration{
+		Tenant:  &cf.TenantConfiguration{Name: "tenant", ID: "tenant-id"},
+		Project: &cf.ProjectConfiguration{Name: "project", ID: "project-id"},
+	})
+	if err != nil {
+		t.Error("Not expecting error when saving config file")
+	}
+
+	server := mocks.NewTestServer()
+	defer server.Close()
+
+	registerJSONResponder(t, "GET", server.URL+rootUrl+"/projects/project-id/vms", &photon.VMs{Items: []photon.VM{
+		{ID: "web-1", Name: "web-1", State: "STARTED", Tags: []string{"frontend"}},
+		{ID: "web-2", Name: "web-2", State: "STOPPED", Tags: []string{"frontend"}},
+		{ID: "web-3", Name: "web-3", State: "STARTED", Tags: []string{"frontend"}},
+		{ID: "db-1", Name: "db-1", State: "STARTED", Tags: []string{"frontend"}},
+	}})
+	for _, id := range []string{"web-1", "web-3"} {
+		registerJSONResponder(t, "POST", server.URL+rootUrl+"/vms/"+id+"/stop",
+			&photon.Task{ID: "stop-" + id, Operation: "STOP_VM", State: "QUEUED"})
+	}
+	registerJSONResponder(t, "GET", server.URL+rootUrl+"/tasks/stop-web-1",
+		&photon.Task{ID: "stop-web-1", Operation: "STOP_VM", State: "COMPLETED"})
+	registerJSONResponder(t, "GET", server.URL+rootUrl+"/tasks/stop-web-3",
+		&photon.Task{ID: "stop-web-3", Operation: "STOP_VM", State: "ERROR"})
+
+	mocks.Activate(true)
+	httpClient := &http.Client{Transport: mocks.DefaultMockTransport}
+	client.Photonclient = photon.NewTestClient(server.URL, nil, httpClient)
+
+	set := flag.NewFlagSet("test", 0)
+	set.String("name-regex", "", "")
+	set.String("state", "", "")
+	set.String("tag", "", "")
+	set.Int("parallel", 1, "")
+	err = set.Parse([]string{"--name-regex", "^web-", "--state", "started", "--tag", "frontend", "--parallel", "2"})
+	if err != nil {
+		t.Error("Not expecting arguments parsing to fail")
+	}
+	cxt := cli.NewContext(nil, set, nil)
+
+	if !isBulkVMOperation(cxt) {
+		t.Error("Expecting selectors to start a bulk operation")
+	}
+
+	var buf bytes.Buffer
+	err = stopVM(cxt, &buf)
+	if err == nil || err.Error() != "stop failed for 1 of 2 VMs" {
+		t.Errorf("Expecting one of two stop operations to fail, got: %v", err)
+	}
+	err = checkRegExp(`web-1\s+web-1\s+stop-web-1\s+COMPLETED`, buf)
+	if err != nil {
+		t.Errorf("Successful stop missing from summary: %s", err)
+	}
+	err = checkRegExp(`web-3\s+web-3\s+stop-web-3\s+.*stop-web-3`, buf)
+	if err != nil {
+		t.Errorf("Failed stop missing from summary: %s", err)
+	}
+	err = checkRegExp(`Total: 2, succeeded: 1, failed: 1`, buf)
+	if err != nil {
+		t.Errorf("Totals missing from summary: %s", err)
+	}
+
+	// IDs and selectors cannot be combined
+	set = flag.NewFlagSet("test", 0)
+	set.String("state", "", "")
+	set.Int("parallel", 1, "")
+	err = set.Parse([]string{"--state", "STARTED", "web-1"})
+	if err != nil {
+		t.Error("Not expecting arguments parsing to fail")
+	}
+	cxt = cli.NewContext(nil, set, nil)
+	err = stopVM(cxt, &buf)
+	if err == nil {
+		t.Error("Expecting error when combining VM IDs and selectors")
+	}
+
+	err = cf.SaveConfig(configOri)
+	if err != nil {
+		t.Error("Not expecting error when saving config file")
+	}
+}
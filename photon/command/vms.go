@@ -29,15 +29,15 @@ import (
 // Creates a cli.Command for vm
 // Subcommands:
 //      create;       Usage: vm create [<options>]
-//      delete;       Usage: vm delete <id>
+//      delete;       Usage: vm delete <id>...
 //      show;         Usage: vm show <id>
 //      list;         Usage: vm list [<options>]
 //      tasks;        Usage: vm tasks <id> [<options>]
-//      start;        Usage: vm start <id>
-//      stop;         Usage: vm stop <id>
-//      suspend;      Usage: vm suspend <id>
-//      resume;       Usage: vm resume <id>
-//      restart;      Usage: vm restart <id>
+//      start;        Usage: vm start <id>...
+//      stop;         Usage: vm stop <id>...
+//      suspend;      Usage: vm suspend <id>...
+//      resume;       Usage: vm resume <id>...
+//      restart;      Usage: vm restart <id>...
 //      attach-disk;  Usage: vm attach-disk <vm-id> [<options>]
 //      detach-disk;  Usage: vm detach-disk <vm-id> [<options>]
 //      attach-iso;   Usage: vm attach-iso <id> [<options>]
@@ -113,9 +113,11 @@ func GetVMCommand() cli.Command {
 				},
 			},
 			{
-				Name:      "delete",
-				Usage:     "Delete VM with specified ID",
-				ArgsUsage: "<vm-id>",
+				Name:        "delete",
+				Usage:       "Delete VM with specified ID",
+				ArgsUsage:   "<vm-id> [<vm-id>...]",
+				Description: "Deletes the VM with the given ID." + vmSelectorDescription,
+				Flags:       getVMSelectorFlags(),
 				Action: func(c *cli.Context) {
 					err := deleteVM(c)
 					if err != nil {
@@ -181,9 +183,11 @@ func GetVMCommand() cli.Command {
 				},
 			},
 			{
-				Name:      "start",
-				Usage:     "Start a VM",
-				ArgsUsage: "<vm-id>",
+				Name:        "start",
+				Usage:       "Start a VM",
+				ArgsUsage:   "<vm-id> [<vm-id>...]",
+				Description: "Starts the VM with the given ID." + vmSelectorDescription,
+				Flags:       getVMSelectorFlags(),
 				Action: func(c *cli.Context) {
 					err := startVM(c, os.Stdout)
 					if err != nil {
@@ -192,9 +196,11 @@ func GetVMCommand() cli.Command {
 				},
 			},
 			{
-				Name:      "stop",
-				Usage:     "Stop a VM",
-				ArgsUsage: "<vm-id>",
+				Name:        "stop",
+				Usage:       "Stop a VM",
+				ArgsUsage:   "<vm-id> [<vm-id>...]",
+				Description: "Stops the VM with the given ID." + vmSelectorDescription,
+				Flags:       getVMSelectorFlags(),
 				Action: func(c *cli.Context) {
 					err := stopVM(c, os.Stdout)
 					if err != nil {
@@ -203,9 +209,11 @@ func GetVMCommand() cli.Command {
 				},
 			},
 			{
-				Name:      "suspend",
-				Usage:     "Suspend a VM",
-				ArgsUsage: "<vm-id>",
+				Name:        "suspend",
+				Usage:       "Suspend a VM",
+				ArgsUsage:   "<vm-id> [<vm-id>...]",
+				Description: "Suspends the VM with the given ID." + vmSelectorDescription,
+				Flags:       getVMSelectorFlags(),
 				Action: func(c *cli.Context) {
 					err := suspendVM(c, os.Stdout)
 					if err != nil {
@@ -214,9 +222,11 @@ func GetVMCommand() cli.Command {
 				},
 			},
 			{
-				Name:      "resume",
-				Usage:     "Resume a VM",
-				ArgsUsage: "<vm-id>",
+				Name:        "resume",
+				Usage:       "Resume a VM",
+				ArgsUsage:   "<vm-id> [<vm-id>...]",
+				Description: "Resumes the VM with the given ID." + vmSelectorDescription,
+				Flags:       getVMSelectorFlags(),
 				Action: func(c *cli.Context) {
 					err := resumeVM(c, os.Stdout)
 					if err != nil {
@@ -225,9 +235,11 @@ func GetVMCommand() cli.Command {
 				},
 			},
 			{
-				Name:      "restart",
-				Usage:     "Restart a VM",
-				ArgsUsage: "<vm-id>",
+				Name:        "restart",
+				Usage:       "Restart a VM",
+				ArgsUsage:   "<vm-id> [<vm-id>...]",
+				Description: "Restarts the VM with the given ID." + vmSelectorDescription,
+				Flags:       getVMSelectorFlags(),
 				Action: func(c *cli.Context) {
 					err := restartVM(c, os.Stdout)
 					if err != nil {
@@ -565,7 +577,7 @@ func createVM(c *cli.Context, w io.Writer) error {
 			return err
 		}
 		vmID, err := waitOnTaskOperation(createTask.ID, c)
-		if err != nil {
+		if err != nil || isAsync(c) {
 			return err
 		}
 
@@ -583,6 +595,12 @@ func createVM(c *cli.Context, w io.Writer) error {
 // Sends a delete VM task to client based on the cli.Context
 // Returns an error if one occurred
 func deleteVM(c *cli.Context) error {
+	if isBulkVMOperation(c) {
+		return bulkVMOperation(c, os.Stdout, "delete", func(id string) (*photon.Task, error) {
+			return client.Photonclient.VMs.Delete(id)
+		})
+	}
+
 	err := checkArgCount(c, 1)
 	if err != nil {
 		return err
@@ -600,7 +618,7 @@ func deleteVM(c *cli.Context) error {
 	}
 
 	_, err = waitOnTaskOperation(deleteTask.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -791,6 +809,12 @@ func getVMTasks(c *cli.Context, w io.Writer) error {
 }
 
 func startVM(c *cli.Context, w io.Writer) error {
+	if isBulkVMOperation(c) {
+		return bulkVMOperation(c, w, "start", func(id string) (*photon.Task, error) {
+			return client.Photonclient.VMs.Start(id)
+		})
+	}
+
 	err := checkArgCount(c, 1)
 	if err != nil {
 		return err
@@ -809,7 +833,7 @@ func startVM(c *cli.Context, w io.Writer) error {
 	}
 
 	_, err = waitOnTaskOperation(opTask.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -819,6 +843,12 @@ func startVM(c *cli.Context, w io.Writer) error {
 }
 
 func stopVM(c *cli.Context, w io.Writer) error {
+	if isBulkVMOperation(c) {
+		return bulkVMOperation(c, w, "stop", func(id string) (*photon.Task, error) {
+			return client.Photonclient.VMs.Stop(id)
+		})
+	}
+
 	err := checkArgCount(c, 1)
 	if err != nil {
 		return err
@@ -837,7 +867,7 @@ func stopVM(c *cli.Context, w io.Writer) error {
 	}
 
 	_, err = waitOnTaskOperation(opTask.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -847,6 +877,12 @@ func stopVM(c *cli.Context, w io.Writer) error {
 }
 
 func suspendVM(c *cli.Context, w io.Writer) error {
+	if isBulkVMOperation(c) {
+		return bulkVMOperation(c, w, "suspend", func(id string) (*photon.Task, error) {
+			return client.Photonclient.VMs.Suspend(id)
+		})
+	}
+
 	err := checkArgCount(c, 1)
 	if err != nil {
 		return err
@@ -865,7 +901,7 @@ func suspendVM(c *cli.Context, w io.Writer) error {
 	}
 
 	_, err = waitOnTaskOperation(opTask.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -875,6 +911,12 @@ func suspendVM(c *cli.Context, w io.Writer) error {
 }
 
 func resumeVM(c *cli.Context, w io.Writer) error {
+	if isBulkVMOperation(c) {
+		return bulkVMOperation(c, w, "resume", func(id string) (*photon.Task, error) {
+			return client.Photonclient.VMs.Resume(id)
+		})
+	}
+
 	err := checkArgCount(c, 1)
 	if err != nil {
 		return err
@@ -893,7 +935,7 @@ func resumeVM(c *cli.Context, w io.Writer) error {
 	}
 
 	_, err = waitOnTaskOperation(opTask.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -903,6 +945,12 @@ func resumeVM(c *cli.Context, w io.Writer) error {
 }
 
 func restartVM(c *cli.Context, w io.Writer) error {
+	if isBulkVMOperation(c) {
+		return bulkVMOperation(c, w, "restart", func(id string) (*photon.Task, error) {
+			return client.Photonclient.VMs.Restart(id)
+		})
+	}
+
 	err := checkArgCount(c, 1)
 	if err != nil {
 		return err
@@ -921,7 +969,7 @@ func restartVM(c *cli.Context, w io.Writer) error {
 	}
 
 	_, err = waitOnTaskOperation(opTask.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -954,7 +1002,7 @@ func attachDisk(c *cli.Context, w io.Writer) error {
 	}
 
 	_, err = waitOnTaskOperation(task.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -987,7 +1035,7 @@ func detachDisk(c *cli.Context, w io.Writer) error {
 	}
 
 	_, err = waitOnTaskOperation(task.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -1045,7 +1093,7 @@ func attachIso(c *cli.Context, w io.Writer) error {
 	}
 
 	_, err = waitOnTaskOperation(task.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -1073,7 +1121,7 @@ func detachIso(c *cli.Context, w io.Writer) error {
 	}
 
 	_, err = waitOnTaskOperation(task.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -1115,7 +1163,7 @@ func setVMMetadata(c *cli.Context, w io.Writer) error {
 	}
 
 	_, err = waitOnTaskOperation(task.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -1180,7 +1228,7 @@ func setVMTag(c *cli.Context, w io.Writer) error {
 	}
 
 	_, err = waitOnTaskOperation(task.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -1208,14 +1256,14 @@ func getVMMksTicket(c *cli.Context, w io.Writer) error {
 	}
 
 	if c.GlobalIsSet("non-interactive") {
-		task, err := client.Photonclient.Tasks.Wait(task.ID)
+		task, err := waitForTask(task.ID)
 		if err != nil {
 			return err
 		}
 		mksTicket := task.ResourceProperties.(map[string]interface{})
 		fmt.Printf("%s\t%v\n", task.Entity.ID, mksTicket["ticket"])
 	} else if utils.NeedsFormatting(c) {
-		task, err := client.Photonclient.Tasks.Wait(task.ID)
+		task, err := waitForTask(task.ID)
 		if err != nil {
 			return err
 		}
@@ -1281,7 +1329,7 @@ func createVmImage(c *cli.Context, w io.Writer) error {
 	}
 
 	_, err = waitOnTaskOperation(task.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -1314,7 +1362,7 @@ func acquireFloatingIp(c *cli.Context, w io.Writer) error {
 	}
 
 	_, err = waitOnTaskOperation(task.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -1342,7 +1390,7 @@ func releaseFloatingIp(c *cli.Context, w io.Writer) error {
 	}
 
 	_, err = waitOnTaskOperation(task.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -10,13 +10,17 @@
 package command
 
 import (
+	"bytes"
 	"encoding/json"
 	"flag"
+	"io/ioutil"
 	"net/http"
 	"os"
+	"path"
 	"testing"
 
 	"github.com/vmware/photon-controller-cli/photon/client"
+	cf "github.com/vmware/photon-controller-cli/photon/configuration"
 	"github.com/vmware/photon-controller-cli/photon/mocks"
 
 	"github.com/urfave/cli"
@@ -997,6 +1001,54 @@ func TestVMNetworks(t *testing.T) {
 	}
 }
 
+// Looking up the networks of the VM is a task, but it changes nothing and is not audited
+func TestShowVMWritesNoAuditRecord(t *testing.T) {
+	configDir, err := ioutil.TempDir("", "audit-test-")
+	if err != nil {
+		t.Error("Not expecting error creating config directory")
+	}
+	defer os.RemoveAll(configDir)
+	cf.UserConfigDir = configDir
+	defer func() { cf.UserConfigDir = "" }()
+
+	server := mocks.NewTestServer()
+	defer server.Close()
+	registerJSONResponder(t, "GET", server.URL+rootUrl+"/vms/fake_vm_ID",
+		&photon.VM{ID: "fake_vm_ID", Name: "fake_vm_name", State: "STARTED"})
+	task := &photon.Task{ID: "fake-networks-task-ID", Operation: "GET_NETWORKS", State: "COMPLETED",
+		Entity:             photon.Entity{ID: "fake_vm_ID"},
+		ResourceProperties: map[string]interface{}{"networkConnections": []interface{}{}}}
+	registerJSONResponder(t, "GET", server.URL+rootUrl+"/vms/fake_vm_ID/subnets", task)
+	registerJSONResponder(t, "GET", server.URL+rootUrl+"/tasks/"+task.ID, task)
+
+	mocks.Activate(true)
+	httpClient := &http.Client{Transport: mocks.DefaultMockTransport}
+	client.Photonclient = photon.NewTestClient(server.URL, nil, httpClient)
+
+	globalSet := flag.NewFlagSet("test", 0)
+	globalSet.Bool("non-interactive", true, "doc")
+	err = globalSet.Parse([]string{"--non-interactive"})
+	if err != nil {
+		t.Error("Not expecting global argument parsing to fail")
+	}
+	set := flag.NewFlagSet("test", 0)
+	err = set.Parse([]string{"fake_vm_ID"})
+	if err != nil {
+		t.Error("Not expecting arguments parsing to fail")
+	}
+	cxt := cli.NewContext(nil, set, cli.NewContext(nil, globalSet, nil))
+
+	var buf bytes.Buffer
+	err = showVM(cxt, &buf)
+	if err != nil {
+		t.Error("Not expecting error showing VM: ", err)
+	}
+	data, err := ioutil.ReadFile(path.Join(configDir, "audit.log"))
+	if err == nil && len(data) != 0 {
+		t.Errorf("Not expecting an audit record for showing a VM, got %s", data)
+	}
+}
+
 func TestSetVMTag(t *testing.T) {
 	queuedTask := &photon.Task{
 		Operation: "ADD_TAG",
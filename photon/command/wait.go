@@ -0,0 +1,262 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+	"github.com/vmware/photon-controller-go-sdk/photon"
+
+	"github.com/vmware/photon-controller-cli/photon/client"
+	"github.com/vmware/photon-controller-cli/photon/utils"
+)
+
+// Longest delay between two polls of a wait command
+const maxWaitPollInterval = 30 * time.Second
+
+// A condition to wait for: either a field of the entity having a value, or the entity being deleted
+type waitCondition struct {
+	Field   string
+	Value   string
+	Deleted bool
+}
+
+// Creates a cli.Command for wait
+// Subcommands: vm;      Usage: wait vm <id> [<options>]
+//              host;    Usage: wait host <id> [<options>]
+//              disk;    Usage: wait disk <id> [<options>]
+//              service; Usage: wait service <id> [<options>]
+//              task;    Usage: wait task <id> [<options>]
+func GetWaitCommand() cli.Command {
+	command := cli.Command{
+		Name:  "wait",
+		Usage: "wait for an entity to reach a state",
+		Subcommands: []cli.Command{
+			getWaitSubcommand("vm", "VM", "STARTED",
+				func(id string) (interface{}, error) { return client.Photonclient.VMs.Get(id) }),
+			getWaitSubcommand("host", "host", "READY",
+				func(id string) (interface{}, error) { return client.Photonclient.InfraHosts.Get(id) }),
+			getWaitSubcommand("disk", "disk", "ATTACHED",
+				func(id string) (interface{}, error) { return client.Photonclient.Disks.Get(id) }),
+			getWaitSubcommand("service", "service", "READY",
+				func(id string) (interface{}, error) { return client.Photonclient.Services.Get(id) }),
+			getWaitSubcommand("task", "task", "COMPLETED",
+				func(id string) (interface{}, error) { return client.Photonclient.Tasks.Get(id) }),
+		},
+	}
+	return command
+}
+
+func getWaitSubcommand(name string, kind string, defaultState string,
+	get func(id string) (interface{}, error)) cli.Command {
+
+	return cli.Command{
+		Name:      name,
+		Usage:     "Wait for a " + kind + " to reach a state",
+		ArgsUsage: "<" + name + "-id>",
+		Description: "Polls the " + kind + " until the condition given with --for holds, backing off between\n" +
+			"   polls. The condition is either <field>=<value>, compared without regard to case, or\n" +
+			"   'delete' to wait until the " + kind + " no longer exists. Exits with an error if the\n" +
+			"   timeout expires or if the " + kind + " enters the ERROR state.\n\n" +
+			"   Example:\n" +
+			"      photon wait " + name + " <id> --for state=" + defaultState + " --timeout 10m",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "for",
+				Value: "state=" + defaultState,
+				Usage: "condition to wait for: <field>=<value> or delete",
+			},
+			cli.DurationFlag{
+				Name:  "timeout",
+				Value: 10 * time.Minute,
+				Usage: "how long to wait before giving up, e.g. 90s or 10m",
+			},
+			cli.DurationFlag{
+				Name:  "interval",
+				Value: time.Second,
+				Usage: "delay before the first poll is repeated; it doubles on each poll up to 30s",
+			},
+		},
+		Action: func(c *cli.Context) {
+			err := waitForEntity(c, os.Stdout, kind, get)
+			if err != nil {
+				log.Fatal("Error: ", err)
+			}
+		},
+	}
+}
+
+// Polls the entity with the given ID until the condition of the cli.Context holds
+// Returns an error if the timeout expires or the entity fails
+func waitForEntity(c *cli.Context, w io.Writer, kind string, get func(id string) (interface{}, error)) error {
+	err := checkArgCount(c, 1)
+	if err != nil {
+		return err
+	}
+	id := c.Args().First()
+
+	condition, err := parseWaitCondition(c.String("for"))
+	if err != nil {
+		return err
+	}
+	timeout := c.Duration("timeout")
+	interval := c.Duration("interval")
+	if timeout <= 0 || interval <= 0 {
+		return fmt.Errorf("--timeout and --interval must be positive durations")
+	}
+
+	client.Photonclient, err = client.GetClient(c)
+	if err != nil {
+		return err
+	}
+
+	interactive := !c.GlobalIsSet("non-interactive") && !utils.NeedsFormatting(c)
+	lastState := ""
+	onPoll := func(state string) {
+		if interactive && state != lastState {
+			fmt.Fprintf(w, "%s %s: %s\n", kind, id, state)
+			lastState = state
+		}
+	}
+
+	entity, err := pollUntil(func() (interface{}, error) { return get(id) }, condition, timeout, interval, onPoll)
+	if err != nil {
+		return fmt.Errorf("%s %s: %s", kind, id, err)
+	}
+
+	if c.GlobalIsSet("non-interactive") {
+		fmt.Fprintf(w, "%s\n", id)
+	} else if utils.NeedsFormatting(c) {
+		if entity != nil {
+			utils.FormatObject(entity, w, c)
+		}
+	} else if condition.Deleted {
+		fmt.Fprintf(w, "%s %s has been deleted\n", kind, id)
+	} else {
+		fmt.Fprintf(w, "%s %s has %s=%s\n", kind, id, condition.Field, condition.Value)
+	}
+	return nil
+}
+
+func parseWaitCondition(condition string) (waitCondition, error) {
+	if strings.EqualFold(condition, "delete") || strings.EqualFold(condition, "deleted") {
+		return waitCondition{Deleted: true}, nil
+	}
+	parts := strings.SplitN(condition, "=", 2)
+	if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+		return waitCondition{}, fmt.Errorf("Invalid condition '%s', expecting <field>=<value> or delete", condition)
+	}
+	return waitCondition{Field: parts[0], Value: parts[1]}, nil
+}
+
+// Calls get until the condition holds, doubling the delay between polls up to
+// maxWaitPollInterval. Transient errors are retried a few times in a row; an entity
+// in the ERROR state fails the wait unless that is the state waited for.
+func pollUntil(get func() (interface{}, error), condition waitCondition, timeout time.Duration,
+	interval time.Duration, onPoll func(state string)) (interface{}, error) {
+
+	start := time.Now()
+	numErr := 0
+	retryCount := 3
+
+	for {
+		entity, err := get()
+		if err != nil {
+			if apiErr, ok := err.(photon.ApiError); ok && apiErr.HttpStatusCode == http.StatusNotFound {
+				if condition.Deleted {
+					return nil, nil
+				}
+				return nil, err
+			}
+			numErr++
+			if numErr > retryCount {
+				return nil, err
+			}
+		} else {
+			numErr = 0
+			fields, err := entityFields(entity)
+			if err != nil {
+				return nil, err
+			}
+			state := ""
+			if value := lookupField(fields, "state"); value != nil {
+				state = fmt.Sprint(value)
+			}
+			onPoll(state)
+
+			if !condition.Deleted {
+				value := lookupField(fields, condition.Field)
+				if value != nil && strings.EqualFold(fmt.Sprint(value), condition.Value) {
+					return entity, nil
+				}
+			}
+			if strings.EqualFold(state, "ERROR") {
+				return nil, fmt.Errorf("entered ERROR state")
+			}
+		}
+
+		elapsed := time.Since(start)
+		if elapsed >= timeout {
+			return nil, fmt.Errorf("timed out after %s", timeout)
+		}
+		if interval > timeout-elapsed {
+			interval = timeout - elapsed
+		}
+		time.Sleep(interval)
+		interval *= 2
+		if interval > maxWaitPollInterval {
+			interval = maxWaitPollInterval
+		}
+	}
+}
+
+// Returns the JSON representation of the entity as a generic map
+func entityFields(entity interface{}) (map[string]interface{}, error) {
+	buf, err := json.Marshal(entity)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	err = json.Unmarshal(buf, &fields)
+	if err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// Finds a field by name without regard to case; nested fields are separated by dots
+func lookupField(fields map[string]interface{}, name string) interface{} {
+	var value interface{} = fields
+	for _, part := range strings.Split(name, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = nil
+		for key, v := range object {
+			if strings.EqualFold(key, part) {
+				value = v
+				break
+			}
+		}
+		if value == nil {
+			return nil
+		}
+	}
+	return value
+}
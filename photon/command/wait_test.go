@@ -0,0 +1,148 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/vmware/photon-controller-cli/photon/client"
+	"github.com/vmware/photon-controller-cli/photon/mocks"
+
+	"github.com/urfave/cli"
+	"github.com/vmware/photon-controller-go-sdk/photon"
+)
+
+func TestWaitForVM(t *testing.T) {
+	server := mocks.NewTestServer()
+	defer server.Close()
+
+	// The VM is starting on the first poll and started on the next ones
+	polls := 0
+	mocks.RegisterResponder("GET", server.URL+rootUrl+"/vms/vm-id",
+		func(req *http.Request) (*http.Response, error) {
+			polls++
+			vm := photon.VM{ID: "vm-id", Name: "web-1", State: "STARTING"}
+			if polls > 1 {
+				vm.State = "STARTED"
+			}
+			response, err := json.Marshal(vm)
+			if err != nil {
+				t.Error("Not expecting error serializing expected response")
+			}
+			return mocks.CreateResponder(200, string(response[:]))(req)
+		})
+	registerJSONResponder(t, "GET", server.URL+rootUrl+"/vms/error-id",
+		photon.VM{ID: "error-id", State: "ERROR"})
+
+	mocks.Activate(true)
+	httpClient := &http.Client{Transport: mocks.DefaultMockTransport}
+	client.Photonclient = photon.NewTestClient(server.URL, nil, httpClient)
+
+	getVM := func(id string) (interface{}, error) { return client.Photonclient.VMs.Get(id) }
+
+	set := flag.NewFlagSet("test", 0)
+	set.String("for", "state=started", "")
+	set.Duration("timeout", time.Second, "")
+	set.Duration("interval", time.Millisecond, "")
+	err := set.Parse([]string{"vm-id"})
+	if err != nil {
+		t.Error("Not expecting arguments parsing to fail")
+	}
+	cxt := cli.NewContext(nil, set, nil)
+
+	var buf bytes.Buffer
+	err = waitForEntity(cxt, &buf, "VM", getVM)
+	if err != nil {
+		t.Error("Not expecting error waiting for VM: ", err)
+	}
+	if polls != 2 {
+		t.Errorf("Expecting 2 polls, got %d", polls)
+	}
+	err = checkRegExp(`VM vm-id has state=started`, buf)
+	if err != nil {
+		t.Errorf("Wait result missing from output: %s", err)
+	}
+
+	// A condition that never holds times out
+	set = flag.NewFlagSet("test", 0)
+	set.String("for", "name=web-2", "")
+	set.Duration("timeout", 20*time.Millisecond, "")
+	set.Duration("interval", time.Millisecond, "")
+	err = set.Parse([]string{"vm-id"})
+	if err != nil {
+		t.Error("Not expecting arguments parsing to fail")
+	}
+	cxt = cli.NewContext(nil, set, nil)
+	err = waitForEntity(cxt, &buf, "VM", getVM)
+	if err == nil {
+		t.Error("Expecting wait to time out")
+	}
+
+	// An entity in the ERROR state fails the wait right away
+	set = flag.NewFlagSet("test", 0)
+	set.String("for", "state=STARTED", "")
+	set.Duration("timeout", time.Minute, "")
+	set.Duration("interval", time.Millisecond, "")
+	err = set.Parse([]string{"error-id"})
+	if err != nil {
+		t.Error("Not expecting arguments parsing to fail")
+	}
+	cxt = cli.NewContext(nil, set, nil)
+	err = waitForEntity(cxt, &buf, "VM", getVM)
+	if err == nil || err.Error() != "VM error-id: entered ERROR state" {
+		t.Errorf("Expecting wait to fail on ERROR state, got: %v", err)
+	}
+}
+
+func TestWaitForDeletedTask(t *testing.T) {
+	server := mocks.NewTestServer()
+	defer server.Close()
+
+	mocks.RegisterResponder("GET", server.URL+rootUrl+"/tasks/task-id",
+		mocks.CreateResponder(404, `{"code":"TaskNotFound","message":"Task not found"}`))
+
+	mocks.Activate(true)
+	httpClient := &http.Client{Transport: mocks.DefaultMockTransport}
+	client.Photonclient = photon.NewTestClient(server.URL, nil, httpClient)
+
+	set := flag.NewFlagSet("test", 0)
+	set.String("for", "delete", "")
+	set.Duration("timeout", time.Second, "")
+	set.Duration("interval", time.Millisecond, "")
+	err := set.Parse([]string{"task-id"})
+	if err != nil {
+		t.Error("Not expecting arguments parsing to fail")
+	}
+	cxt := cli.NewContext(nil, set, nil)
+
+	var buf bytes.Buffer
+	err = waitForEntity(cxt, &buf, "task", func(id string) (interface{}, error) {
+		return client.Photonclient.Tasks.Get(id)
+	})
+	if err != nil {
+		t.Error("Not expecting error waiting for deleted task: ", err)
+	}
+}
+
+func TestParseWaitCondition(t *testing.T) {
+	condition, err := parseWaitCondition("runtime.state=READY")
+	if err != nil || condition.Field != "runtime.state" || condition.Value != "READY" {
+		t.Errorf("Unexpected condition %+v, error %v", condition, err)
+	}
+	_, err = parseWaitCondition("state")
+	if err == nil {
+		t.Error("Expecting error for condition without value")
+	}
+}
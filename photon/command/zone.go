@@ -147,7 +147,7 @@ func createZone(c *cli.Context, w io.Writer) error {
 	}
 
 	id, err := waitOnTaskOperation(createTask.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -253,7 +253,7 @@ func deleteZone(c *cli.Context) error {
 	}
 
 	_, err = waitOnTaskOperation(deleteTask.ID, c)
-	if err != nil {
+	if err != nil || isAsync(c) {
 		return err
 	}
 
@@ -0,0 +1,249 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package configuration
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// A certificate of the local trust store and the file it is kept in
+type TrustedCert struct {
+	Cert *x509.Certificate
+	File string
+}
+
+// Returns the certificates of the local trust store, ordered by file name
+func GetTrustedCerts() ([]TrustedCert, error) {
+	certsDir, err := getCertsDir()
+	if err != nil {
+		return nil, err
+	}
+	files, err := ioutil.ReadDir(certsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	certs := []TrustedCert{}
+	for _, f := range files {
+		if filepath.Ext(f.Name()) != ".pem" {
+			continue
+		}
+		file := path.Join(certsDir, f.Name())
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := ParseCerts(data)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading trusted certificate %s: %v", file, err)
+		}
+		for _, cert := range parsed {
+			certs = append(certs, TrustedCert{Cert: cert, File: file})
+		}
+	}
+	return certs, nil
+}
+
+// Finds a trusted certificate by its SHA-256 fingerprint. A unique prefix of the
+// fingerprint is enough, and colons and case are ignored.
+func FindTrustedCert(fingerprint string) (*TrustedCert, error) {
+	prefix := normalizeFingerprint(fingerprint)
+	if len(prefix) == 0 {
+		return nil, fmt.Errorf("Please provide a certificate fingerprint")
+	}
+
+	certs, err := GetTrustedCerts()
+	if err != nil {
+		return nil, err
+	}
+	var found *TrustedCert
+	for i := range certs {
+		if strings.HasPrefix(normalizeFingerprint(CertFingerprint(certs[i].Cert)), prefix) {
+			if found != nil {
+				return nil, fmt.Errorf("More than one trusted certificate matches '%s'", fingerprint)
+			}
+			found = &certs[i]
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("No trusted certificate matches '%s'", fingerprint)
+	}
+	return found, nil
+}
+
+// Parses certificates in PEM format, e.g. a CA bundle with a whole chain, or a
+// single certificate in DER format
+func ParseCerts(data []byte) ([]*x509.Certificate, error) {
+	certs := []*x509.Certificate{}
+	rest := data
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) != 0 {
+		return certs, nil
+	}
+
+	cert, err := x509.ParseCertificate(data)
+	if err != nil {
+		return nil, fmt.Errorf("No certificate found in PEM or DER format")
+	}
+	return []*x509.Certificate{cert}, nil
+}
+
+// Returns the SHA-256 fingerprint of the certificate as colon separated hex bytes
+func CertFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	hexBytes := make([]string, len(sum))
+	for i, b := range sum {
+		hexBytes[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(hexBytes, ":")
+}
+
+// Tells if the certificate has expired or will expire within the given duration
+func CertExpiresWithin(cert *x509.Certificate, within time.Duration) bool {
+	return time.Now().Add(within).After(cert.NotAfter)
+}
+
+func normalizeFingerprint(fingerprint string) string {
+	return strings.ToUpper(strings.Replace(fingerprint, ":", "", -1))
+}
+
+// Returns the host:port of an HTTPS endpoint, the key of its certificate pins.
+// The port defaults to 443.
+// The endpoint can also be given without scheme, e.g. "192.0.2.42:443".
+func PinnedHost(endpoint string) string {
+	host := endpoint
+	u, err := url.Parse(endpoint)
+	if err == nil && len(u.Host) != 0 {
+		host = u.Host
+	}
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(strings.Trim(host, "[]"), "443")
+	}
+	return host
+}
+
+// Trusts only the given certificates for the host, replacing the certificates pinned before
+func (config *Configuration) PinCert(host string, certs ...*x509.Certificate) {
+	if config.CertPins == nil {
+		config.CertPins = map[string][]string{}
+	}
+	pins := []string{}
+	for _, cert := range certs {
+		pins = append(pins, CertFingerprint(cert))
+	}
+	config.CertPins[host] = pins
+}
+
+// Returns the certificate authorities the authentication server of the target is verified
+// with: the ones of the system, and the certificates of the local store that are pinned for
+// that server or for no host at all, e.g. imported CA bundles. Certificates pinned for other
+// hosts, such as the root certificates accepted for another target's authentication server,
+// are left out.
+func (config *Configuration) AuthServerRoots() (*x509.CertPool, error) {
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	trusted, err := GetTrustedCerts()
+	if err != nil {
+		return nil, err
+	}
+
+	pinnedHosts := map[string][]string{}
+	for host, pins := range config.CertPins {
+		for _, pin := range pins {
+			fingerprint := normalizeFingerprint(pin)
+			pinnedHosts[fingerprint] = append(pinnedHosts[fingerprint], host)
+		}
+	}
+	authServer := config.AuthServers[PinnedHost(config.CloudTarget)]
+	for _, cert := range trusted {
+		hosts := pinnedHosts[normalizeFingerprint(CertFingerprint(cert.Cert))]
+		if len(hosts) == 0 || (len(authServer) != 0 && stringInList(authServer, hosts)) {
+			roots.AddCert(cert.Cert)
+		}
+	}
+	return roots, nil
+}
+
+func stringInList(s string, list []string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Removes the certificates whose fingerprint starts with the given one from the pins
+// of every host. Returns the hosts they were pinned for.
+func (config *Configuration) UnpinCert(fingerprint string) []string {
+	prefix := normalizeFingerprint(fingerprint)
+	hosts := []string{}
+	if len(prefix) == 0 {
+		return hosts
+	}
+	for host, pins := range config.CertPins {
+		kept := []string{}
+		for _, pin := range pins {
+			if !strings.HasPrefix(normalizeFingerprint(pin), prefix) {
+				kept = append(kept, pin)
+			}
+		}
+		if len(kept) == len(pins) {
+			continue
+		}
+		hosts = append(hosts, host)
+		if len(kept) == 0 {
+			delete(config.CertPins, host)
+		} else {
+			config.CertPins[host] = kept
+		}
+	}
+	if len(config.CertPins) == 0 {
+		config.CertPins = nil
+	}
+	return hosts
+}
+
+// Tells if the certificate is one of the pinned ones
+func IsCertPinned(pins []string, cert *x509.Certificate) bool {
+	fingerprint := normalizeFingerprint(CertFingerprint(cert))
+	for _, pin := range pins {
+		if normalizeFingerprint(pin) == fingerprint {
+			return true
+		}
+	}
+	return false
+}
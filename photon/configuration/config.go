@@ -32,13 +32,48 @@ type ProjectConfiguration struct {
 	ID   string
 }
 
+// How the tokens were obtained, so that they can be renewed the same way
+type LoginConfiguration struct {
+	Flow string
+	// Account, OIDC client and file holding the secret of the client credentials flow
+	Username         string `json:",omitempty"`
+	ClientID         string `json:",omitempty"`
+	ClientSecretFile string `json:",omitempty"`
+}
+
+// Values of LoginConfiguration.Flow
+const (
+	PasswordLogin          = "password"
+	TokenLogin             = "token"
+	WindowsLogin           = "windows"
+	ClientCredentialsLogin = "client-credentials"
+)
+
 type Configuration struct {
 	CloudTarget       string
 	Token             string
 	RefreshToken      string
+	Login             *LoginConfiguration `json:",omitempty"`
 	IgnoreCertificate bool
 	Tenant            *TenantConfiguration
 	Project           *ProjectConfiguration
+	CurrentProfile    string              `json:",omitempty"`
+	Profiles          map[string]*Profile `json:",omitempty"`
+	// How long to wait for tasks and how often to poll them at first, e.g. "45m" and "2s"
+	TaskTimeout  string `json:",omitempty"`
+	PollInterval string `json:",omitempty"`
+	// How long before the access token expires to start warning about it, e.g. "30m"; "0" disables
+	TokenWarning string `json:",omitempty"`
+	// SHA-256 fingerprints of the certificates trusted for each HTTPS host, by host:port
+	CertPins map[string][]string `json:",omitempty"`
+	// Host:port of the authentication server of each HTTPS target, by host:port of the target
+	AuthServers map[string]string `json:",omitempty"`
+	// Where Token and RefreshToken are kept, see GetCredentialStore. In the config file by default.
+	CredentialStore string `json:",omitempty"`
+	// Path of the audit log of the tasks submitted by commands, see GetAuditLogPath
+	AuditLog string `json:",omitempty"`
+	// Settings overridden by flags or environment variables, not saved
+	overrides *overrides
 }
 
 // Load configuration in config file
@@ -53,10 +88,27 @@ func LoadConfig() (*Configuration, error) {
 		if err != nil {
 			return &Configuration{}, err
 		}
+		err = selectProfile(config)
+		if err != nil {
+			return &Configuration{}, err
+		}
+		err = applyOverrides(config)
+		if err != nil {
+			return &Configuration{}, err
+		}
 		return config, nil
 	}
 
-	return &Configuration{}, nil
+	config := &Configuration{}
+	err = selectProfile(config)
+	if err != nil {
+		return &Configuration{}, err
+	}
+	err = applyOverrides(config)
+	if err != nil {
+		return &Configuration{}, err
+	}
+	return config, nil
 }
 
 // Save configuration into config file, will overwrite config file
@@ -66,6 +118,11 @@ func SaveConfig(config *Configuration) error {
 		return err
 	}
 
+	config, err = syncProfile(restoreOverridden(config), filepath)
+	if err != nil {
+		return err
+	}
+
 	err = writeConfigToFile(filepath, config)
 	if err != nil {
 		return err
@@ -81,6 +138,12 @@ func getUserConfigDirectory() (userConfigDir string, err error) {
 		return UserConfigDir, err
 	}
 
+	userConfigDir = os.Getenv(ConfigDirEnv)
+	if len(userConfigDir) != 0 {
+		err = os.MkdirAll(userConfigDir, 0755)
+		return userConfigDir, err
+	}
+
 	var homedir_input = "HOME"
 	if runtime.GOOS == "windows" {
 		homedir_input = "APPDATA"
@@ -108,7 +171,8 @@ func getUserConfigDirectory() (userConfigDir string, err error) {
 	return userConfigDir, err
 }
 
-// Get path of local config file: $HOME_DIR/.photon-cli
+// Get path of local config file: $HOME_DIR/.photon-cli/.photon-config, or
+// $PHOTON_CONFIG_DIR/.photon-config
 func getConfigurationFilePath() (string, error) {
 	userConfigDir, err := getUserConfigDirectory()
 	if err == nil {
@@ -117,6 +181,19 @@ func getConfigurationFilePath() (string, error) {
 	return userConfigDir, err
 }
 
+// Get path of the audit log: the AuditLog setting if any, otherwise audit.log in the
+// config directory
+func GetAuditLogPath(config *Configuration) (string, error) {
+	if len(config.AuditLog) != 0 {
+		return config.AuditLog, nil
+	}
+	userConfigDir, err := getUserConfigDirectory()
+	if err == nil {
+		return path.Join(userConfigDir, "audit.log"), nil
+	}
+	return userConfigDir, err
+}
+
 // Check if file is a directory
 func isFileDirectory(path string) bool {
 	fileInfo, err := os.Stat(path)
@@ -150,11 +227,21 @@ func readConfigFromFile(path string) (*Configuration, error) {
 		return nil, fmt.Errorf("Error loading configuration: %v", err)
 	}
 
+	err = loadCredentials(&config)
+	if err != nil {
+		return nil, err
+	}
+
 	return &config, nil
 }
 
 // Serialize and write configuration to local config file in JSON format
 func writeConfigToFile(path string, config *Configuration) error {
+	config, err := storeCredentials(config)
+	if err != nil {
+		return err
+	}
+
 	data, err := json.Marshal(*config)
 	if err != nil {
 		return fmt.Errorf("Error saving configuration: %v", err)
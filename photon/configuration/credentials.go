@@ -0,0 +1,456 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package configuration
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// Names of the credential stores, the value of Configuration.CredentialStore.
+// A credential helper is given as "helper:<program>", e.g. "helper:docker-credential-pass".
+const (
+	ConfigFileCredentialStore    = ""
+	SecretServiceCredentialStore = "secret-service"
+	EncryptedFileCredentialStore = "file"
+	helperCredentialStorePrefix  = "helper:"
+)
+
+// Environment variable holding the passphrase of the encrypted credentials file.
+// The passphrase is asked for on the terminal when it is not set.
+const CredentialsPassphraseEnv = "PHOTON_CREDENTIALS_PASSPHRASE"
+
+// Keeps the access and refresh tokens out of the config file. All tokens of a config
+// file, those of the settings in use and of every profile, are stored as one secret.
+type CredentialStore interface {
+	// Returns the stored secret, or nil if nothing is stored
+	Get() ([]byte, error)
+	Store(secret []byte) error
+	Erase() error
+	String() string
+}
+
+// Returns the credential store with the given name. The config file store, the
+// default, returns nil as the tokens stay in the config file.
+func GetCredentialStore(name string) (CredentialStore, error) {
+	configFile, err := getConfigurationFilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case name == ConfigFileCredentialStore:
+		return nil, nil
+	case name == SecretServiceCredentialStore:
+		return &secretServiceStore{configFile: configFile}, nil
+	case name == EncryptedFileCredentialStore:
+		return &encryptedFileStore{path: path.Join(filepath.Dir(configFile), ".photon-credentials")}, nil
+	case strings.HasPrefix(name, helperCredentialStorePrefix) && len(name) > len(helperCredentialStorePrefix):
+		return &helperStore{program: strings.TrimPrefix(name, helperCredentialStorePrefix), configFile: configFile}, nil
+	}
+	return nil, fmt.Errorf("Unknown credential store '%s', expecting '%s', '%s' or '%s<program>'",
+		name, SecretServiceCredentialStore, EncryptedFileCredentialStore, helperCredentialStorePrefix)
+}
+
+// Describes where the tokens of the configuration are kept
+func DescribeCredentialStore(name string) string {
+	store, err := GetCredentialStore(name)
+	if err != nil {
+		return name
+	}
+	if store == nil {
+		return "config file"
+	}
+	return store.String()
+}
+
+type storedTokens struct {
+	Token        string `json:",omitempty"`
+	RefreshToken string `json:",omitempty"`
+}
+
+// The secret kept in a credential store
+type storedCredentials struct {
+	Current  storedTokens
+	Profiles map[string]storedTokens `json:",omitempty"`
+}
+
+// Fills in the tokens of a configuration read from the config file
+func loadCredentials(config *Configuration) error {
+	store, err := GetCredentialStore(config.CredentialStore)
+	if err != nil || store == nil {
+		return err
+	}
+	secret, err := store.Get()
+	if err != nil {
+		return fmt.Errorf("Error reading tokens from %s: %v", store, err)
+	}
+	if secret == nil {
+		return nil
+	}
+
+	var creds storedCredentials
+	err = json.Unmarshal(secret, &creds)
+	if err != nil {
+		return fmt.Errorf("Error reading tokens from %s: %v", store, err)
+	}
+	config.Token = creds.Current.Token
+	config.RefreshToken = creds.Current.RefreshToken
+	for name, profile := range config.Profiles {
+		tokens := creds.Profiles[name]
+		profile.Token = tokens.Token
+		profile.RefreshToken = tokens.RefreshToken
+	}
+	return nil
+}
+
+// Moves the tokens of a configuration about to be written to the config file into its
+// credential store. Returns a copy of the configuration without tokens.
+func storeCredentials(config *Configuration) (*Configuration, error) {
+	store, err := GetCredentialStore(config.CredentialStore)
+	if err != nil || store == nil {
+		return config, err
+	}
+
+	stripped := *config
+	creds := storedCredentials{Current: storedTokens{Token: config.Token, RefreshToken: config.RefreshToken}}
+	stripped.Token = ""
+	stripped.RefreshToken = ""
+	empty := creds.Current == storedTokens{}
+	if len(config.Profiles) != 0 {
+		creds.Profiles = map[string]storedTokens{}
+		stripped.Profiles = map[string]*Profile{}
+		for name, profile := range config.Profiles {
+			tokens := storedTokens{Token: profile.Token, RefreshToken: profile.RefreshToken}
+			if tokens != (storedTokens{}) {
+				creds.Profiles[name] = tokens
+				empty = false
+			}
+			strippedProfile := *profile
+			strippedProfile.Token = ""
+			strippedProfile.RefreshToken = ""
+			stripped.Profiles[name] = &strippedProfile
+		}
+	}
+
+	if empty {
+		err = store.Erase()
+	} else {
+		var secret []byte
+		secret, err = json.Marshal(creds)
+		if err == nil {
+			err = store.Store(secret)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error saving tokens to %s: %v", store, err)
+	}
+	return &stripped, nil
+}
+
+// The freedesktop Secret Service (GNOME Keyring, KWallet...), through secret-tool of libsecret
+type secretServiceStore struct {
+	configFile string
+}
+
+func (s *secretServiceStore) attributes() []string {
+	return []string{"service", "photon-cli", "config", s.configFile}
+}
+
+func (s *secretServiceStore) Get() ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("secret-tool", append([]string{"lookup"}, s.attributes()...)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err != nil {
+		// secret-tool fails silently when there is no such secret
+		if _, ok := err.(*exec.ExitError); ok && stderr.Len() == 0 {
+			return nil, nil
+		}
+		return nil, commandError(err, stderr.String())
+	}
+	return stdout.Bytes(), nil
+}
+
+func (s *secretServiceStore) Store(secret []byte) error {
+	args := append([]string{"store", "--label", "Photon Controller CLI tokens"}, s.attributes()...)
+	return runWithInput(exec.Command("secret-tool", args...), secret)
+}
+
+func (s *secretServiceStore) Erase() error {
+	var stderr bytes.Buffer
+	cmd := exec.Command("secret-tool", append([]string{"clear"}, s.attributes()...)...)
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if _, ok := err.(*exec.ExitError); ok && stderr.Len() == 0 {
+		return nil
+	}
+	return commandError(err, stderr.String())
+}
+
+func (s *secretServiceStore) String() string {
+	return "Secret Service keyring"
+}
+
+// An external program following the protocol of the docker credential helpers:
+// "get", "store" and "erase" commands with their input on stdin
+type helperStore struct {
+	program    string
+	configFile string
+}
+
+type helperCredentials struct {
+	ServerURL string
+	Username  string
+	Secret    string
+}
+
+func (s *helperStore) serverURL() string {
+	return "photon-cli://" + filepath.ToSlash(s.configFile)
+}
+
+func (s *helperStore) Get() ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(s.program, "get")
+	cmd.Stdin = strings.NewReader(s.serverURL())
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err != nil {
+		// Helpers report missing credentials on stdout
+		if strings.Contains(stdout.String(), "credentials not found") {
+			return nil, nil
+		}
+		return nil, commandError(err, stderr.String()+stdout.String())
+	}
+
+	var creds helperCredentials
+	err = json.Unmarshal(stdout.Bytes(), &creds)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(creds.Secret), nil
+}
+
+func (s *helperStore) Store(secret []byte) error {
+	input, err := json.Marshal(helperCredentials{ServerURL: s.serverURL(), Username: "tokens", Secret: string(secret)})
+	if err != nil {
+		return err
+	}
+	return runWithInput(exec.Command(s.program, "store"), input)
+}
+
+func (s *helperStore) Erase() error {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(s.program, "erase")
+	cmd.Stdin = strings.NewReader(s.serverURL())
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err != nil && strings.Contains(stdout.String(), "credentials not found") {
+		return nil
+	}
+	return commandError(err, stderr.String()+stdout.String())
+}
+
+func (s *helperStore) String() string {
+	return "credential helper " + s.program
+}
+
+func runWithInput(cmd *exec.Cmd, input []byte) error {
+	var output bytes.Buffer
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	return commandError(cmd.Run(), output.String())
+}
+
+func commandError(err error, output string) error {
+	output = strings.TrimSpace(output)
+	if err == nil || len(output) == 0 {
+		return err
+	}
+	return fmt.Errorf("%v: %s", err, output)
+}
+
+// A local file encrypted with AES-256-GCM, using a key derived from a passphrase
+type encryptedFileStore struct {
+	path string
+}
+
+type encryptedFile struct {
+	Salt  []byte
+	Nonce []byte
+	Data  []byte
+}
+
+const (
+	credentialsKeyIterations = 100000
+	credentialsKeyLength     = 32
+)
+
+// The passphrase and the key derived from it are only asked for and computed once.
+// The lock guards both, so that concurrent commands ask for the passphrase once.
+var (
+	credentialsPassphrase string
+	credentialsKeys       = map[string][]byte{}
+	credentialsLock       sync.Mutex
+)
+
+func (s *encryptedFileStore) Get() ([]byte, error) {
+	if !isFileExist(s.path) {
+		return nil, nil
+	}
+	data, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+	var file encryptedFile
+	err = json.Unmarshal(data, &file)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := credentialsCipher(file.Salt)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := gcm.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt %s, the passphrase may be wrong", s.path)
+	}
+	return secret, nil
+}
+
+func (s *encryptedFileStore) Store(secret []byte) error {
+	file := encryptedFile{Salt: make([]byte, 16)}
+	_, err := rand.Read(file.Salt)
+	if err != nil {
+		return err
+	}
+	gcm, err := credentialsCipher(file.Salt)
+	if err != nil {
+		return err
+	}
+	file.Nonce = make([]byte, gcm.NonceSize())
+	_, err = rand.Read(file.Nonce)
+	if err != nil {
+		return err
+	}
+	file.Data = gcm.Seal(nil, file.Nonce, secret, nil)
+
+	data, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.path, data, 0600)
+}
+
+func (s *encryptedFileStore) Erase() error {
+	if !isFileExist(s.path) {
+		return nil
+	}
+	return os.Remove(s.path)
+}
+
+func (s *encryptedFileStore) String() string {
+	return "encrypted file " + s.path
+}
+
+func credentialsCipher(salt []byte) (cipher.AEAD, error) {
+	credentialsLock.Lock()
+	defer credentialsLock.Unlock()
+	key, ok := credentialsKeys[string(salt)]
+	if !ok {
+		passphrase, err := getCredentialsPassphrase()
+		if err != nil {
+			return nil, err
+		}
+		key = pbkdf2SHA256([]byte(passphrase), salt, credentialsKeyIterations, credentialsKeyLength)
+		credentialsKeys[string(salt)] = key
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Called with credentialsLock held
+func getCredentialsPassphrase() (string, error) {
+	if len(credentialsPassphrase) != 0 {
+		return credentialsPassphrase, nil
+	}
+	credentialsPassphrase = os.Getenv(CredentialsPassphraseEnv)
+	if len(credentialsPassphrase) != 0 {
+		return credentialsPassphrase, nil
+	}
+
+	// Casting syscall.Stdin to int because during
+	// Windows cross-compilation syscall.Stdin is incorrectly
+	// treated as a String.
+	if !terminal.IsTerminal(int(syscall.Stdin)) {
+		return "", fmt.Errorf("Please set %s to the passphrase of the credentials file", CredentialsPassphraseEnv)
+	}
+	fmt.Fprintf(os.Stderr, "Passphrase of the credentials file: ")
+	passphrase, err := terminal.ReadPassword(int(syscall.Stdin))
+	fmt.Fprintf(os.Stderr, "\n")
+	if err != nil {
+		return "", err
+	}
+	if len(passphrase) == 0 {
+		return "", fmt.Errorf("The passphrase of the credentials file cannot be empty")
+	}
+	credentialsPassphrase = string(passphrase)
+	return credentialsPassphrase, nil
+}
+
+// PBKDF2 (RFC 2898) with HMAC-SHA256
+func pbkdf2SHA256(password, salt []byte, iterations, keyLength int) []byte {
+	prf := hmac.New(sha256.New, password)
+	blocks := (keyLength + prf.Size() - 1) / prf.Size()
+	key := make([]byte, 0, blocks*prf.Size())
+	for block := 1; block <= blocks; block++ {
+		prf.Reset()
+		_, _ = prf.Write(salt)
+		_ = binary.Write(prf, binary.BigEndian, uint32(block))
+		u := prf.Sum(nil)
+		t := append([]byte{}, u...)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			_, _ = prf.Write(u)
+			u = prf.Sum(nil)
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLength]
+}
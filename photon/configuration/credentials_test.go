@@ -0,0 +1,151 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package configuration_test
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path"
+	"runtime"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/vmware/photon-controller-cli/photon/configuration"
+)
+
+// A credential helper keeping what it is given in a file next to it
+const fakeCredentialHelper = `#!/bin/sh
+store="$(dirname "$0")/helper-store"
+case "$1" in
+get) if [ -f "$store" ]; then cat "$store"; else echo "credentials not found in native keychain"; exit 1; fi;;
+store) cat > "$store";;
+erase) rm -f "$store";;
+esac
+`
+
+var _ = Describe("Credentials", func() {
+	var config *Configuration
+
+	BeforeEach(func() {
+		var err error
+		UserConfigDir, err = ioutil.TempDir("", "config-test-")
+		Expect(err).To(BeNil())
+
+		config = &Configuration{
+			CloudTarget:  "https://192.0.2.42:443",
+			Token:        "access-token",
+			RefreshToken: "refresh-token",
+		}
+		config.SetProfile("staging", &Profile{CloudTarget: "http://staging:9000", Token: "staging-token"})
+	})
+
+	AfterEach(func() {
+		err := os.RemoveAll(UserConfigDir)
+		Expect(err).To(BeNil())
+	})
+
+	readConfigFile := func() string {
+		data, err := ioutil.ReadFile(path.Join(UserConfigDir, ".photon-config"))
+		Expect(err).To(BeNil())
+		return string(data)
+	}
+
+	Context("with the encrypted file store", func() {
+		BeforeEach(func() {
+			err := os.Setenv(CredentialsPassphraseEnv, "correct horse battery staple")
+			Expect(err).To(BeNil())
+			config.CredentialStore = EncryptedFileCredentialStore
+		})
+
+		AfterEach(func() {
+			err := os.Unsetenv(CredentialsPassphraseEnv)
+			Expect(err).To(BeNil())
+		})
+
+		It("keeps the tokens out of the config file", func() {
+			err := SaveConfig(config)
+			Expect(err).To(BeNil())
+			Expect(readConfigFile()).NotTo(ContainSubstring("access-token"))
+
+			data, err := ioutil.ReadFile(path.Join(UserConfigDir, ".photon-credentials"))
+			Expect(err).To(BeNil())
+			Expect(string(data)).NotTo(ContainSubstring("access-token"))
+
+			loaded, err := LoadConfig()
+			Expect(err).To(BeNil())
+			Expect(loaded.Token).To(Equal("access-token"))
+			Expect(loaded.RefreshToken).To(Equal("refresh-token"))
+			Expect(loaded.Profiles["staging"].Token).To(Equal("staging-token"))
+		})
+
+		It("removes the file once there are no tokens left", func() {
+			err := SaveConfig(config)
+			Expect(err).To(BeNil())
+
+			config.Token = ""
+			config.RefreshToken = ""
+			config.Profiles["staging"].Token = ""
+			err = SaveConfig(config)
+			Expect(err).To(BeNil())
+
+			_, err = os.Stat(path.Join(UserConfigDir, ".photon-credentials"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+	})
+
+	Context("with a credential helper", func() {
+		BeforeEach(func() {
+			if runtime.GOOS == "windows" {
+				Skip("the fake credential helper is a shell script")
+			}
+			helper := path.Join(UserConfigDir, "fake-credential-helper")
+			err := ioutil.WriteFile(helper, []byte(fakeCredentialHelper), 0755)
+			Expect(err).To(BeNil())
+			config.CredentialStore = "helper:" + helper
+		})
+
+		It("stores and erases the tokens through the helper", func() {
+			err := SaveConfig(config)
+			Expect(err).To(BeNil())
+			Expect(readConfigFile()).NotTo(ContainSubstring("access-token"))
+
+			loaded, err := LoadConfig()
+			Expect(err).To(BeNil())
+			Expect(loaded.Token).To(Equal("access-token"))
+			Expect(loaded.Profiles["staging"].Token).To(Equal("staging-token"))
+
+			store, err := GetCredentialStore(config.CredentialStore)
+			Expect(err).To(BeNil())
+			err = store.Erase()
+			Expect(err).To(BeNil())
+
+			loaded, err = LoadConfig()
+			Expect(err).To(BeNil())
+			Expect(loaded.Token).To(BeEmpty())
+		})
+	})
+
+	It("rejects unknown stores", func() {
+		_, err := GetCredentialStore("vault")
+		Expect(err).NotTo(BeNil())
+	})
+
+	// PBKDF2-HMAC-SHA256 test vectors of RFC 7914, section 11
+	It("derives the key of the encrypted file with PBKDF2-HMAC-SHA256", func() {
+		key := PBKDF2SHA256([]byte("passwd"), []byte("salt"), 1, 64)
+		Expect(hex.EncodeToString(key)).To(Equal("55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc" +
+			"49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"))
+
+		key = PBKDF2SHA256([]byte("Password"), []byte("NaCl"), 80000, 64)
+		Expect(hex.EncodeToString(key)).To(Equal("4ddcd8f60b98be21830cee5ef22701f9641a4418d04c0414aeff08876b34ab56" +
+			"a1d425a1225833549adb841b51c9b3176a272bdebba1d078478f62b397f33c8d"))
+	})
+})
@@ -0,0 +1,173 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package configuration
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// Environment variables overriding the settings of the config file, e.g. in CI jobs.
+// Tenant and project are given by name.
+const (
+	TargetEnv     = "PHOTON_TARGET"
+	TokenEnv      = "PHOTON_TOKEN"
+	TenantEnv     = "PHOTON_TENANT"
+	ProjectEnv    = "PHOTON_PROJECT"
+	IgnoreCertEnv = "PHOTON_IGNORE_CERT"
+	ConfigDirEnv  = "PHOTON_CONFIG_DIR"
+)
+
+// Values of the global --target and --token flags, which take precedence over the
+// environment variables
+var (
+	TargetOverride string
+	TokenOverride  string
+)
+
+// Names of the settings that can be overridden, see Configuration.Source
+const (
+	TargetSetting     = "target"
+	TokenSetting      = "token"
+	TenantSetting     = "tenant"
+	ProjectSetting    = "project"
+	IgnoreCertSetting = "ignore-certificate"
+)
+
+// The settings of a configuration before and after applying the overrides, so that
+// overridden values are not saved to the config file
+type overrides struct {
+	file    *Profile
+	applied *Profile
+	sources map[string]string
+}
+
+// Tells where a setting in use comes from: a flag, an environment variable, a
+// profile or the config file. Precedence is in that order.
+func (config *Configuration) Source(setting string) string {
+	if config.overrides != nil {
+		if source, ok := config.overrides.sources[setting]; ok {
+			return source
+		}
+	}
+	if len(ProfileName) != 0 {
+		return fmt.Sprintf("profile '%s'", ProfileName)
+	}
+	if len(config.CurrentProfile) != 0 {
+		return fmt.Sprintf("profile '%s'", config.CurrentProfile)
+	}
+	return "config file"
+}
+
+// Tells if a setting comes from a flag or an environment variable
+func (config *Configuration) Overridden(setting string) bool {
+	if config.overrides == nil {
+		return false
+	}
+	_, ok := config.overrides.sources[setting]
+	return ok
+}
+
+func lookupOverride(flagValue string, flagName string, env string) (value string, source string) {
+	if len(flagValue) != 0 {
+		return flagValue, fmt.Sprintf("--%s flag", flagName)
+	}
+	value = os.Getenv(env)
+	if len(value) != 0 {
+		return value, "environment variable " + env
+	}
+	return "", ""
+}
+
+// Layers the flags and environment variables over a freshly loaded configuration
+func applyOverrides(config *Configuration) error {
+	file := config.CurrentSettings()
+	sources := map[string]string{}
+
+	if target, source := lookupOverride(TargetOverride, "target", TargetEnv); len(source) != 0 {
+		config.CloudTarget = target
+		sources[TargetSetting] = source
+	}
+
+	if token, source := lookupOverride(TokenOverride, "token", TokenEnv); len(source) != 0 {
+		// The refresh token of the config file belongs to another login
+		config.Token = token
+		config.RefreshToken = ""
+		config.Login = &LoginConfiguration{Flow: TokenLogin}
+		sources[TokenSetting] = source
+	}
+
+	if ignoreCert, source := lookupOverride("", "", IgnoreCertEnv); len(source) != 0 {
+		value, err := strconv.ParseBool(ignoreCert)
+		if err != nil {
+			return fmt.Errorf("Invalid value '%s' for %s, expecting true or false", ignoreCert, IgnoreCertEnv)
+		}
+		config.IgnoreCertificate = value
+		sources[IgnoreCertSetting] = source
+	}
+
+	if tenant, source := lookupOverride("", "", TenantEnv); len(source) != 0 {
+		if config.Tenant == nil || config.Tenant.Name != tenant {
+			// The ID is looked up when needed; the project of the config file
+			// belongs to another tenant
+			config.Tenant = &TenantConfiguration{Name: tenant}
+			config.Project = nil
+		}
+		sources[TenantSetting] = source
+	}
+
+	if project, source := lookupOverride("", "", ProjectEnv); len(source) != 0 {
+		if config.Project == nil || config.Project.Name != project {
+			config.Project = &ProjectConfiguration{Name: project}
+		}
+		sources[ProjectSetting] = source
+	}
+
+	if len(sources) != 0 {
+		config.overrides = &overrides{file: file, applied: config.CurrentSettings(), sources: sources}
+	}
+	return nil
+}
+
+// Returns a copy of the configuration in which the settings still holding their
+// overridden value are set back to the value of the config file. Settings changed
+// since loading, e.g. by 'target login', are kept, except for tokens obtained while
+// the target is overridden: they are for another target than the one of the file.
+func restoreOverridden(config *Configuration) *Configuration {
+	if config.overrides == nil {
+		return config
+	}
+	file := config.overrides.file
+	applied := config.overrides.applied
+
+	restored := *config
+	restored.overrides = nil
+	if config.CloudTarget == applied.CloudTarget {
+		restored.CloudTarget = file.CloudTarget
+	}
+	tokensChanged := config.Token != applied.Token || config.RefreshToken != applied.RefreshToken ||
+		config.Login != applied.Login
+	if !tokensChanged || config.Overridden(TargetSetting) {
+		restored.Token = file.Token
+		restored.RefreshToken = file.RefreshToken
+		restored.Login = file.Login
+	}
+	if config.IgnoreCertificate == applied.IgnoreCertificate {
+		restored.IgnoreCertificate = file.IgnoreCertificate
+	}
+	if config.Tenant == applied.Tenant {
+		restored.Tenant = file.Tenant
+	}
+	if config.Project == applied.Project {
+		restored.Project = file.Project
+	}
+	return &restored
+}
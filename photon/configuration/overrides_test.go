@@ -0,0 +1,129 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package configuration_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/vmware/photon-controller-cli/photon/configuration"
+)
+
+var _ = Describe("Overrides", func() {
+	BeforeEach(func() {
+		var err error
+		UserConfigDir, err = ioutil.TempDir("", "config-test-")
+		Expect(err).To(BeNil())
+
+		err = SaveConfig(&Configuration{
+			CloudTarget:  "https://192.0.2.42:443",
+			Token:        "file-token",
+			RefreshToken: "file-refresh-token",
+			Tenant:       &TenantConfiguration{Name: "file-tenant", ID: "1"},
+			Project:      &ProjectConfiguration{Name: "file-project", ID: "2"},
+		})
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		TargetOverride = ""
+		TokenOverride = ""
+		for _, env := range []string{TargetEnv, TokenEnv, TenantEnv, ProjectEnv, IgnoreCertEnv, ConfigDirEnv} {
+			Expect(os.Unsetenv(env)).To(BeNil())
+		}
+		err := os.RemoveAll(UserConfigDir)
+		Expect(err).To(BeNil())
+	})
+
+	It("layers environment variables and flags over the config file", func() {
+		Expect(os.Setenv(TargetEnv, "https://198.51.100.1:443")).To(BeNil())
+		Expect(os.Setenv(TokenEnv, "env-token")).To(BeNil())
+		Expect(os.Setenv(TenantEnv, "env-tenant")).To(BeNil())
+		Expect(os.Setenv(IgnoreCertEnv, "true")).To(BeNil())
+		TargetOverride = "https://198.51.100.2:443"
+
+		config, err := LoadConfig()
+		Expect(err).To(BeNil())
+		Expect(config.CloudTarget).To(Equal("https://198.51.100.2:443"))
+		Expect(config.Source(TargetSetting)).To(Equal("--target flag"))
+		Expect(config.Token).To(Equal("env-token"))
+		Expect(config.RefreshToken).To(BeEmpty())
+		Expect(config.Source(TokenSetting)).To(Equal("environment variable " + TokenEnv))
+		Expect(config.IgnoreCertificate).To(BeTrue())
+		Expect(config.Tenant).To(Equal(&TenantConfiguration{Name: "env-tenant"}))
+		Expect(config.Project).To(BeNil())
+		Expect(config.Source(ProjectSetting)).To(Equal("config file"))
+	})
+
+	It("does not save overridden settings to the config file", func() {
+		Expect(os.Setenv(TargetEnv, "https://198.51.100.1:443")).To(BeNil())
+		Expect(os.Setenv(TokenEnv, "env-token")).To(BeNil())
+
+		config, err := LoadConfig()
+		Expect(err).To(BeNil())
+		config.Project = &ProjectConfiguration{Name: "new-project", ID: "3"}
+		err = SaveConfig(config)
+		Expect(err).To(BeNil())
+
+		Expect(os.Unsetenv(TargetEnv)).To(BeNil())
+		Expect(os.Unsetenv(TokenEnv)).To(BeNil())
+		config, err = LoadConfig()
+		Expect(err).To(BeNil())
+		Expect(config.CloudTarget).To(Equal("https://192.0.2.42:443"))
+		Expect(config.Token).To(Equal("file-token"))
+		Expect(config.RefreshToken).To(Equal("file-refresh-token"))
+		Expect(config.Project.Name).To(Equal("new-project"))
+	})
+
+	It("does not save tokens obtained for an overridden target", func() {
+		TargetOverride = "https://198.51.100.2:443"
+
+		config, err := LoadConfig()
+		Expect(err).To(BeNil())
+		Expect(config.Overridden(TargetSetting)).To(BeTrue())
+		Expect(config.Overridden(TokenSetting)).To(BeFalse())
+		config.Token = "renewed-token"
+		config.RefreshToken = "renewed-refresh-token"
+		err = SaveConfig(config)
+		Expect(err).To(BeNil())
+
+		TargetOverride = ""
+		config, err = LoadConfig()
+		Expect(err).To(BeNil())
+		Expect(config.Token).To(Equal("file-token"))
+		Expect(config.RefreshToken).To(Equal("file-refresh-token"))
+	})
+
+	It("rejects invalid values of PHOTON_IGNORE_CERT", func() {
+		Expect(os.Setenv(IgnoreCertEnv, "maybe")).To(BeNil())
+
+		_, err := LoadConfig()
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("reads the config file from PHOTON_CONFIG_DIR", func() {
+		configDir := UserConfigDir
+		UserConfigDir = ""
+		defer func() { UserConfigDir = configDir }()
+		Expect(os.Setenv(ConfigDirEnv, path.Join(configDir, "ci"))).To(BeNil())
+
+		config, err := LoadConfig()
+		Expect(err).To(BeNil())
+		Expect(config.CloudTarget).To(BeEmpty())
+
+		err = SaveConfig(&Configuration{CloudTarget: "https://198.51.100.3:443"})
+		Expect(err).To(BeNil())
+		_, err = os.Stat(path.Join(configDir, "ci", ".photon-config"))
+		Expect(err).To(BeNil())
+	})
+})
@@ -0,0 +1,170 @@
+// Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package configuration
+
+import (
+	"fmt"
+	"sort"
+)
+
+// A named set of target settings. The top level fields of Configuration always hold
+// the settings of the profile in use; the copy kept here is refreshed on every save.
+type Profile struct {
+	CloudTarget       string
+	Token             string
+	RefreshToken      string
+	Login             *LoginConfiguration `json:",omitempty"`
+	IgnoreCertificate bool
+	Tenant            *TenantConfiguration
+	Project           *ProjectConfiguration
+}
+
+// Name of the profile selected for this invocation only (global --profile flag).
+// When set, LoadConfig returns the settings of that profile and SaveConfig writes
+// changes back into it without switching the current profile in the config file.
+var ProfileName string
+
+// Returns the settings currently in use as a profile
+func (config *Configuration) CurrentSettings() *Profile {
+	return &Profile{
+		CloudTarget:       config.CloudTarget,
+		Token:             config.Token,
+		RefreshToken:      config.RefreshToken,
+		Login:             config.Login,
+		IgnoreCertificate: config.IgnoreCertificate,
+		Tenant:            config.Tenant,
+		Project:           config.Project,
+	}
+}
+
+// Replaces the settings currently in use with the ones of the given profile
+func (config *Configuration) applySettings(profile *Profile) {
+	config.CloudTarget = profile.CloudTarget
+	config.Token = profile.Token
+	config.RefreshToken = profile.RefreshToken
+	config.Login = profile.Login
+	config.IgnoreCertificate = profile.IgnoreCertificate
+	config.Tenant = profile.Tenant
+	config.Project = profile.Project
+}
+
+// Returns the named profile, or an error if it does not exist
+func (config *Configuration) GetProfile(name string) (*Profile, error) {
+	profile, ok := config.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("Profile '%s' does not exist", name)
+	}
+	return profile, nil
+}
+
+// Adds or replaces a named profile
+func (config *Configuration) SetProfile(name string, profile *Profile) {
+	if config.Profiles == nil {
+		config.Profiles = map[string]*Profile{}
+	}
+	config.Profiles[name] = profile
+	if config.CurrentProfile == name {
+		config.applySettings(profile)
+	}
+}
+
+// Switches the settings in use to the named profile. The settings of the
+// previous profile, if any, are kept under its name.
+func (config *Configuration) UseProfile(name string) error {
+	profile, err := config.GetProfile(name)
+	if err != nil {
+		return err
+	}
+
+	if len(config.CurrentProfile) != 0 {
+		config.Profiles[config.CurrentProfile] = config.CurrentSettings()
+	}
+	config.CurrentProfile = name
+	config.applySettings(profile)
+	return nil
+}
+
+// Removes the named profile. Deleting the current profile keeps its settings in
+// use but no longer associates them with a name.
+func (config *Configuration) DeleteProfile(name string) error {
+	_, err := config.GetProfile(name)
+	if err != nil {
+		return err
+	}
+
+	delete(config.Profiles, name)
+	if len(config.Profiles) == 0 {
+		config.Profiles = nil
+	}
+	if config.CurrentProfile == name {
+		config.CurrentProfile = ""
+	}
+	return nil
+}
+
+// Returns the names of all profiles in alphabetical order
+func (config *Configuration) ProfileNames() []string {
+	names := []string{}
+	for name := range config.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Selects the profile given by ProfileName on a freshly loaded configuration
+func selectProfile(config *Configuration) error {
+	if len(ProfileName) == 0 || ProfileName == config.CurrentProfile {
+		return nil
+	}
+
+	profile, err := config.GetProfile(ProfileName)
+	if err != nil {
+		return err
+	}
+	config.applySettings(profile)
+	return nil
+}
+
+// Prepares a configuration for writing: the settings in use are stored in the profile
+// they belong to, and the profile selected by ProfileName does not become current.
+func syncProfile(config *Configuration, path string) (*Configuration, error) {
+	if len(ProfileName) == 0 || ProfileName == config.CurrentProfile {
+		if len(config.CurrentProfile) != 0 {
+			config.SetProfile(config.CurrentProfile, config.CurrentSettings())
+		}
+		return config, nil
+	}
+
+	// Keep what is on disk as the settings in use and only update the selected profile
+	saved := &Configuration{}
+	if isFileExist(path) {
+		var err error
+		saved, err = readConfigFromFile(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// Profiles may have been added or removed by the caller as well
+	profiles := map[string]*Profile{}
+	for name, profile := range config.Profiles {
+		profiles[name] = profile
+	}
+	profiles[ProfileName] = config.CurrentSettings()
+	if current, ok := profiles[saved.CurrentProfile]; ok {
+		saved.applySettings(current)
+	}
+	saved.Profiles = profiles
+	// Settings shared by all profiles
+	saved.CertPins = config.CertPins
+	saved.AuthServers = config.AuthServers
+	saved.CredentialStore = config.CredentialStore
+	return saved, nil
+}
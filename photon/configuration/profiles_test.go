@@ -0,0 +1,147 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package configuration_test
+
+import (
+	"io/ioutil"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/vmware/photon-controller-cli/photon/configuration"
+)
+
+var _ = Describe("Profiles", func() {
+	var (
+		staging    *Profile
+		production *Profile
+	)
+
+	BeforeEach(func() {
+		var err error
+		UserConfigDir, err = ioutil.TempDir("", "config-test-")
+		Expect(err).To(BeNil())
+
+		staging = &Profile{
+			CloudTarget: "http://staging:9000",
+			Token:       "staging-token",
+			Tenant:      &TenantConfiguration{Name: "staging-tenant", ID: "1"},
+		}
+		production = &Profile{
+			CloudTarget:       "https://production:443",
+			Token:             "production-token",
+			IgnoreCertificate: true,
+		}
+
+		config := &Configuration{}
+		config.SetProfile("staging", staging)
+		config.SetProfile("production", production)
+		err = config.UseProfile("staging")
+		Expect(err).To(BeNil())
+		err = SaveConfig(config)
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		ProfileName = ""
+		err := RemoveConfigFile()
+		err2 := os.Remove(UserConfigDir)
+		Expect(err).To(BeNil())
+		Expect(err2).To(BeNil())
+	})
+
+	Describe("UseProfile", func() {
+		It("switches the settings in use", func() {
+			config, err := LoadConfig()
+			Expect(err).To(BeNil())
+			Expect(config.CurrentProfile).To(Equal("staging"))
+			Expect(config.CloudTarget).To(Equal(staging.CloudTarget))
+
+			err = config.UseProfile("production")
+			Expect(err).To(BeNil())
+			Expect(config.CloudTarget).To(Equal(production.CloudTarget))
+			Expect(config.Token).To(Equal(production.Token))
+			Expect(config.IgnoreCertificate).To(BeTrue())
+			Expect(config.Tenant).To(BeNil())
+		})
+
+		It("keeps changes made to the previous profile", func() {
+			config, err := LoadConfig()
+			Expect(err).To(BeNil())
+			config.Project = &ProjectConfiguration{Name: "staging-project", ID: "2"}
+			err = SaveConfig(config)
+			Expect(err).To(BeNil())
+
+			config, err = LoadConfig()
+			Expect(err).To(BeNil())
+			err = config.UseProfile("production")
+			Expect(err).To(BeNil())
+			err = config.UseProfile("staging")
+			Expect(err).To(BeNil())
+			Expect(config.Project).To(BeEquivalentTo(&ProjectConfiguration{Name: "staging-project", ID: "2"}))
+		})
+
+		It("fails for unknown profiles", func() {
+			config, err := LoadConfig()
+			Expect(err).To(BeNil())
+			err = config.UseProfile("unknown")
+			Expect(err).To(MatchError("Profile 'unknown' does not exist"))
+		})
+	})
+
+	Describe("ProfileName", func() {
+		It("selects a profile for loading only", func() {
+			ProfileName = "production"
+			config, err := LoadConfig()
+			Expect(err).To(BeNil())
+			Expect(config.CloudTarget).To(Equal(production.CloudTarget))
+
+			ProfileName = ""
+			config, err = LoadConfig()
+			Expect(err).To(BeNil())
+			Expect(config.CurrentProfile).To(Equal("staging"))
+			Expect(config.CloudTarget).To(Equal(staging.CloudTarget))
+		})
+
+		It("saves changes into the selected profile", func() {
+			ProfileName = "production"
+			config, err := LoadConfig()
+			Expect(err).To(BeNil())
+			config.Token = "new-production-token"
+			err = SaveConfig(config)
+			Expect(err).To(BeNil())
+
+			ProfileName = ""
+			config, err = LoadConfig()
+			Expect(err).To(BeNil())
+			Expect(config.Token).To(Equal(staging.Token))
+			Expect(config.Profiles["production"].Token).To(Equal("new-production-token"))
+		})
+
+		It("fails for unknown profiles", func() {
+			ProfileName = "unknown"
+			config, err := LoadConfig()
+			Expect(err).To(MatchError("Profile 'unknown' does not exist"))
+			Expect(config).To(BeEquivalentTo(&Configuration{}))
+		})
+	})
+
+	Describe("DeleteProfile", func() {
+		It("removes the profile and keeps the settings in use", func() {
+			config, err := LoadConfig()
+			Expect(err).To(BeNil())
+			err = config.DeleteProfile("staging")
+			Expect(err).To(BeNil())
+			Expect(config.CurrentProfile).To(BeEmpty())
+			Expect(config.CloudTarget).To(Equal(staging.CloudTarget))
+			Expect(config.ProfileNames()).To(Equal([]string{"production"}))
+		})
+	})
+})
@@ -52,3 +52,8 @@ func ChangeConfigFileContents(content string) error {
 
 	return nil
 }
+
+// Exposes the key derivation of the encrypted credentials file to the tests
+func PBKDF2SHA256(password, salt []byte, iterations, keyLength int) []byte {
+	return pbkdf2SHA256(password, salt, iterations, keyLength)
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/urfave/cli"
 	"github.com/vmware/photon-controller-cli/photon/client"
 	"github.com/vmware/photon-controller-cli/photon/command"
+	"github.com/vmware/photon-controller-cli/photon/configuration"
 	"github.com/vmware/photon-controller-cli/photon/utils"
 )
 
@@ -46,14 +47,51 @@ func BuildApp() *cli.App {
 			Name:  "log-file, l",
 			Usage: "write logging information into a logfile at the specified path",
 		},
+		cli.StringFlag{
+			Name:  "log-level",
+			Usage: "log messages of this level and above: debug, info, warn or error (default info)",
+		},
+		cli.BoolFlag{
+			Name:  "trace-http",
+			Usage: "log every API request with its status, latency and bodies, secrets redacted",
+		},
+		cli.IntFlag{
+			Name:  "log-max-size",
+			Value: 10,
+			Usage: "rotate the log file when it reaches this size in MB, 0 to never rotate",
+		},
 		cli.StringFlag{
 			Name:  "output, o",
-			Usage: "select output format",
+			Usage: "select output format: json, yaml, csv, tsv, jsonpath=<template>, go-template=<template> or custom-columns=<spec>",
 		},
 		cli.BoolFlag{
 			Name:  "detail, d",
 			Usage: "print the current target, user, tenant and project",
 		},
+		cli.StringFlag{
+			Name:  "profile",
+			Usage: "use the named target profile for this command only",
+		},
+		cli.StringFlag{
+			Name:  "target",
+			Usage: "use this API endpoint for this command only, overrides " + configuration.TargetEnv,
+		},
+		cli.StringFlag{
+			Name:  "token",
+			Usage: "use this access token for this command only, overrides " + configuration.TokenEnv,
+		},
+		cli.BoolFlag{
+			Name:  "async",
+			Usage: "print the task of a command right after submitting it instead of waiting for it",
+		},
+		cli.DurationFlag{
+			Name:  "task-timeout",
+			Usage: "how long to wait for a task to complete, e.g. 45m (default 30m)",
+		},
+		cli.DurationFlag{
+			Name:  "poll-interval",
+			Usage: "delay between the first polls of a task, doubled on each poll (default 500ms)",
+		},
 	}
 	app.Commands = []cli.Command{
 		command.GetAuthCommand(),
@@ -73,20 +111,49 @@ func BuildApp() *cli.App {
 		command.GetSubnetsCommand(),
 		command.GetZonesCommand(),
 		command.GetInfrastructureCommand(),
+		command.GetApplyCommand(),
+		command.GetExportCommand(),
+		command.GetWaitCommand(),
 	}
 	app.Before = func(c *cli.Context) error {
-		logFile := c.GlobalString("log-file")
-		if logFile != "" {
-			return client.InitializeLogging(logFile)
+		configuration.ProfileName = c.GlobalString("profile")
+		configuration.TargetOverride = c.GlobalString("target")
+		configuration.TokenOverride = c.GlobalString("token")
+		command.TaskTimeout = c.GlobalDuration("task-timeout")
+		command.PollInterval = c.GlobalDuration("poll-interval")
+		command.SetAuditCommandLine(c.App, os.Args)
+		if command.TaskTimeout < 0 || command.PollInterval < 0 {
+			return fmt.Errorf("--task-timeout and --poll-interval must be positive durations")
+		}
+		err := initializeLogging(c)
+		if err != nil {
+			return err
 		}
 		return utils.ValidateArgs(c)
 	}
 	app.After = func(c *cli.Context) error {
-		logFile := c.GlobalString("log-file")
-		if logFile != "" {
-			return client.CleanupLogging()
-		}
-		return nil
+		return client.CleanupLogging()
 	}
 	return app
 }
+
+// Logs to the --log-file, or to stderr when only --log-level or --trace-http is given
+func initializeLogging(c *cli.Context) error {
+	logFile := c.GlobalString("log-file")
+	if logFile == "" && !c.GlobalIsSet("log-level") && !c.GlobalBool("trace-http") {
+		return nil
+	}
+	if c.GlobalIsSet("log-level") {
+		level, err := client.ParseLogLevel(c.GlobalString("log-level"))
+		if err != nil {
+			return err
+		}
+		client.Level = level
+	}
+	if c.GlobalInt("log-max-size") < 0 {
+		return fmt.Errorf("--log-max-size must not be negative")
+	}
+	client.LogMaxSize = int64(c.GlobalInt("log-max-size")) * 1024 * 1024
+	client.TraceHTTP = c.GlobalBool("trace-http")
+	return client.InitializeLogging(logFile)
+}
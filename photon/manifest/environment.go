@@ -0,0 +1,183 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package manifest
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
+)
+
+// The logical configuration of a deployment: tenants with their projects, flavors, zones
+// and hosts. Fields left out of the document are not managed, e.g. a tenant without 'quota'
+// keeps whatever quota it has.
+type Environment struct {
+	Tenants []Tenant `yaml:"tenants,omitempty"`
+	Flavors []Flavor `yaml:"flavors,omitempty"`
+	Zones   []Zone   `yaml:"zones,omitempty"`
+	Hosts   []Host   `yaml:"hosts,omitempty"`
+}
+
+type Tenant struct {
+	Name           string        `yaml:"name"`
+	SecurityGroups []string      `yaml:"security_groups,omitempty"`
+	Quota          Quota         `yaml:"quota,omitempty"`
+	Iam            []PolicyEntry `yaml:"iam,omitempty"`
+	Projects       []Project     `yaml:"projects,omitempty"`
+}
+
+type Project struct {
+	Name           string        `yaml:"name"`
+	SecurityGroups []string      `yaml:"security_groups,omitempty"`
+	Quota          Quota         `yaml:"quota,omitempty"`
+	Iam            []PolicyEntry `yaml:"iam,omitempty"`
+}
+
+// Quota limits by key, e.g. vm.count
+type Quota map[string]QuotaLimit
+
+type QuotaLimit struct {
+	Limit float64 `yaml:"limit"`
+	Unit  string  `yaml:"unit"`
+}
+
+type PolicyEntry struct {
+	Principal string   `yaml:"principal"`
+	Roles     []string `yaml:"roles"`
+}
+
+type Flavor struct {
+	Name string     `yaml:"name"`
+	Kind string     `yaml:"kind"`
+	Cost []CostItem `yaml:"cost"`
+}
+
+type CostItem struct {
+	Key   string  `yaml:"key"`
+	Value float64 `yaml:"value"`
+	Unit  string  `yaml:"unit"`
+}
+
+type Zone struct {
+	Name string `yaml:"name"`
+}
+
+// A host of the deployment. Hosts are added with 'system add-hosts', which needs
+// credentials, so they are only recorded here.
+type Host struct {
+	Address          string            `yaml:"address"`
+	AvailabilityZone string            `yaml:"availability_zone,omitempty"`
+	Tags             []string          `yaml:"usage_tags,omitempty"`
+	Metadata         map[string]string `yaml:"metadata,omitempty"`
+}
+
+func LoadEnvironment(file string) (res *Environment, err error) {
+	buf, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	res = &Environment{}
+	err = yaml.Unmarshal(buf, res)
+	if err != nil {
+		return nil, err
+	}
+	err = res.validate()
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+func SaveEnvironment(env *Environment, file string) error {
+	buf, err := env.toYaml()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, buf, 0644)
+}
+
+func WriteEnvironment(env *Environment, w io.Writer) error {
+	buf, err := env.toYaml()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+func (env *Environment) toYaml() ([]byte, error) {
+	buf, err := yaml.Marshal(env)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte("---\n"), buf...), nil
+}
+
+// Checks that every entity has a name and that names are unique where the API requires it
+func (env *Environment) validate() error {
+	tenants := map[string]bool{}
+	for _, tenant := range env.Tenants {
+		if len(tenant.Name) == 0 {
+			return fmt.Errorf("Every tenant needs a name")
+		}
+		if tenants[tenant.Name] {
+			return fmt.Errorf("Tenant '%s' is listed more than once", tenant.Name)
+		}
+		tenants[tenant.Name] = true
+
+		projects := map[string]bool{}
+		for _, project := range tenant.Projects {
+			if len(project.Name) == 0 {
+				return fmt.Errorf("Every project of tenant '%s' needs a name", tenant.Name)
+			}
+			if projects[project.Name] {
+				return fmt.Errorf("Project '%s' is listed more than once for tenant '%s'", project.Name, tenant.Name)
+			}
+			projects[project.Name] = true
+		}
+	}
+
+	flavors := map[string]bool{}
+	for _, flavor := range env.Flavors {
+		if len(flavor.Name) == 0 || len(flavor.Kind) == 0 {
+			return fmt.Errorf("Every flavor needs a name and a kind")
+		}
+		key := flavor.Kind + "/" + flavor.Name
+		if flavors[key] {
+			return fmt.Errorf("Flavor '%s' of kind '%s' is listed more than once", flavor.Name, flavor.Kind)
+		}
+		flavors[key] = true
+	}
+
+	zones := map[string]bool{}
+	for _, zone := range env.Zones {
+		if len(zone.Name) == 0 {
+			return fmt.Errorf("Every zone needs a name")
+		}
+		if zones[zone.Name] {
+			return fmt.Errorf("Zone '%s' is listed more than once", zone.Name)
+		}
+		zones[zone.Name] = true
+	}
+
+	hosts := map[string]bool{}
+	for _, host := range env.Hosts {
+		if len(host.Address) == 0 {
+			return fmt.Errorf("Every host needs an address")
+		}
+		if hosts[host.Address] {
+			return fmt.Errorf("Host '%s' is listed more than once", host.Address)
+		}
+		hosts[host.Address] = true
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package manifest_test
+
+import (
+	. "github.com/vmware/photon-controller-cli/photon/manifest"
+
+	"io/ioutil"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Environment", func() {
+	Describe("LoadEnvironment", func() {
+		var (
+			file        *os.File
+			fileContent string
+		)
+
+		JustBeforeEach(func() {
+			var err error
+			file, err = ioutil.TempFile("", "environment_")
+			if err != nil {
+				Fail("Could not create temporary test file.")
+			}
+
+			_, err = file.WriteString(fileContent)
+			if err != nil {
+				Fail("Could not write test file " + file.Name())
+			}
+
+			_ = file.Close()
+		})
+
+		AfterEach(func() {
+			if file != nil {
+				_ = os.Remove(file.Name())
+				file = nil
+			}
+		})
+
+		Context("when the manifest is valid", func() {
+			BeforeEach(func() {
+				fileContent = `---
+zones:
+- name: zone1
+flavors:
+- name: small
+  kind: vm
+  cost:
+  - {key: vm.cpu, value: 1, unit: COUNT}
+tenants:
+- name: cloud-dev
+  security_groups: [admins]
+  quota:
+    vm.count: {limit: 100, unit: COUNT}
+  projects:
+  - name: web
+    iam:
+    - principal: dev@example.com
+      roles: [contributor]
+`
+			})
+
+			It("loads successfully", func() {
+				env, err := LoadEnvironment(file.Name())
+				Expect(err).To(BeNil())
+
+				Expect(env.Zones).To(BeEquivalentTo([]Zone{{Name: "zone1"}}))
+				Expect(env.Flavors).To(BeEquivalentTo([]Flavor{
+					{Name: "small", Kind: "vm", Cost: []CostItem{{Key: "vm.cpu", Value: 1, Unit: "COUNT"}}}}))
+				Expect(env.Tenants).To(HaveLen(1))
+
+				tenant := env.Tenants[0]
+				Expect(tenant.SecurityGroups).To(BeEquivalentTo([]string{"admins"}))
+				Expect(tenant.Quota).To(BeEquivalentTo(Quota{"vm.count": {Limit: 100, Unit: "COUNT"}}))
+				Expect(tenant.Iam).To(BeNil())
+				Expect(tenant.Projects).To(BeEquivalentTo([]Project{
+					{Name: "web", Iam: []PolicyEntry{{Principal: "dev@example.com", Roles: []string{"contributor"}}}}}))
+			})
+		})
+
+		Context("when a project is listed twice", func() {
+			BeforeEach(func() {
+				fileContent = `---
+tenants:
+- name: cloud-dev
+  projects:
+  - name: web
+  - name: web
+`
+			})
+
+			It("returns an error", func() {
+				env, err := LoadEnvironment(file.Name())
+				Expect(err).To(MatchError("Project 'web' is listed more than once for tenant 'cloud-dev'"))
+				Expect(env).To(BeNil())
+			})
+		})
+
+		Context("when a flavor has no kind", func() {
+			BeforeEach(func() {
+				fileContent = `---
+flavors:
+- name: small
+`
+			})
+
+			It("returns an error", func() {
+				_, err := LoadEnvironment(file.Name())
+				Expect(err).To(MatchError("Every flavor needs a name and a kind"))
+			})
+		})
+	})
+})
@@ -0,0 +1,90 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package manifest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// A Kubernetes or Harbor service to create, with the fields of the API's ServiceCreateSpec
+// and the tenant and project it belongs to. The file is YAML or JSON, e.g.
+//
+//	tenant: cloud-dev
+//	project: web
+//	name: k8s
+//	type: KUBERNETES
+//	workerCount: 3
+//	extendedProperties:
+//	  container_network: 10.2.0.0/16
+//	  number_of_masters: 1
+//	  number_of_etcds: 1
+type Service struct {
+	Tenant             string            `yaml:"tenant,omitempty"`
+	Project            string            `yaml:"project,omitempty"`
+	Name               string            `yaml:"name,omitempty"`
+	Type               string            `yaml:"type,omitempty"`
+	VMFlavor           string            `yaml:"vmFlavor,omitempty"`
+	MasterVmFlavor     string            `yaml:"masterVmFlavor,omitempty"`
+	WorkerVmFlavor     string            `yaml:"workerVmFlavor,omitempty"`
+	DiskFlavor         string            `yaml:"diskFlavor,omitempty"`
+	SubnetId           string            `yaml:"subnetId,omitempty"`
+	ImageID            string            `yaml:"imageId,omitempty"`
+	WorkerCount        int               `yaml:"workerCount,omitempty"`
+	BatchSizeWorker    int               `yaml:"workerBatchExpansionSize,omitempty"`
+	ExtendedProperties map[string]string `yaml:"extendedProperties,omitempty"`
+}
+
+var serviceFields = []string{"tenant", "project", "name", "type", "vmFlavor", "masterVmFlavor", "workerVmFlavor",
+	"diskFlavor", "subnetId", "imageId", "workerCount", "workerBatchExpansionSize", "extendedProperties"}
+
+func LoadService(file string) (res *Service, err error) {
+	buf, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	// Catch misspelled fields, which would otherwise be silently left out of the service
+	fields := map[string]interface{}{}
+	err = yaml.Unmarshal(buf, &fields)
+	if err != nil {
+		return nil, err
+	}
+	unknown := []string{}
+	for field := range fields {
+		if !isServiceField(field) {
+			unknown = append(unknown, field)
+		}
+	}
+	if len(unknown) != 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("Unknown fields in service spec %s: %s", file, strings.Join(unknown, ", "))
+	}
+
+	res = &Service{}
+	err = yaml.Unmarshal(buf, res)
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+func isServiceField(name string) bool {
+	for _, field := range serviceFields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
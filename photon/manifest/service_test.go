@@ -0,0 +1,105 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package manifest_test
+
+import (
+	. "github.com/vmware/photon-controller-cli/photon/manifest"
+
+	"io/ioutil"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Service", func() {
+	Describe("LoadService", func() {
+		var (
+			file        *os.File
+			fileContent string
+		)
+
+		JustBeforeEach(func() {
+			var err error
+			file, err = ioutil.TempFile("", "service_")
+			if err != nil {
+				Fail("Could not create temporary test file.")
+			}
+
+			_, err = file.WriteString(fileContent)
+			if err != nil {
+				Fail("Could not write test file " + file.Name())
+			}
+
+			_ = file.Close()
+		})
+
+		AfterEach(func() {
+			if file != nil {
+				_ = os.Remove(file.Name())
+				file = nil
+			}
+		})
+
+		Context("when the spec is YAML", func() {
+			BeforeEach(func() {
+				fileContent = `---
+tenant: cloud-dev
+project: web
+name: k8s
+type: KUBERNETES
+workerCount: 3
+workerBatchExpansionSize: 2
+extendedProperties:
+  container_network: 10.2.0.0/16
+  number_of_masters: 1
+`
+			})
+
+			It("loads successfully", func() {
+				service, err := LoadService(file.Name())
+				Expect(err).To(BeNil())
+				Expect(*service).To(BeEquivalentTo(Service{Tenant: "cloud-dev", Project: "web", Name: "k8s",
+					Type: "KUBERNETES", WorkerCount: 3, BatchSizeWorker: 2, ExtendedProperties: map[string]string{
+						"container_network": "10.2.0.0/16", "number_of_masters": "1"}}))
+			})
+		})
+
+		Context("when the spec is JSON", func() {
+			BeforeEach(func() {
+				fileContent = `{"name": "registry", "type": "HARBOR", "extendedProperties": {"master_ip": "192.0.2.20"}}`
+			})
+
+			It("loads successfully", func() {
+				service, err := LoadService(file.Name())
+				Expect(err).To(BeNil())
+				Expect(service.Type).To(Equal("HARBOR"))
+				Expect(service.ExtendedProperties).To(HaveKeyWithValue("master_ip", "192.0.2.20"))
+			})
+		})
+
+		Context("when a field is misspelled", func() {
+			BeforeEach(func() {
+				fileContent = `---
+name: k8s
+workerCounts: 3
+`
+			})
+
+			It("returns an error", func() {
+				service, err := LoadService(file.Name())
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Unknown fields in service spec"))
+				Expect(err.Error()).To(ContainSubstring("workerCounts"))
+				Expect(service).To(BeNil())
+			})
+		})
+	})
+})
@@ -0,0 +1,366 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package mocks
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vmware/photon-controller-go-sdk/photon"
+)
+
+// FakeAPI is a stateful, in-memory implementation of the Photon Controller API for
+// tenants, projects, VMs, disks, images, flavors, hosts, tasks and quotas, so that
+// the CLI and scripts using it can run without a deployment. Authentication is not
+// enforced: any token, or none, is accepted.
+//
+// Operations return tasks that are QUEUED, then STARTED, then COMPLETED or ERROR
+// over TaskDuration. Their effect, e.g. a VM being created or started, shows once
+// they complete.
+type FakeAPI struct {
+	TaskDuration time.Duration
+
+	mutex    sync.Mutex
+	tenants  map[string]*photon.Tenant
+	projects map[string]*fakeProject
+	vms      map[string]*fakeVM
+	disks    map[string]*fakeDisk
+	images   map[string]*photon.Image
+	flavors  map[string]*photon.Flavor
+	hosts    map[string]*photon.Host
+	tasks    map[string]*fakeTask
+	// IDs of the entities and tasks in the order they were created, to list them in that order
+	order []string
+}
+
+type fakeProject struct {
+	photon.ProjectCompact
+	tenantID string
+}
+
+type fakeVM struct {
+	photon.VM
+	projectID string
+}
+
+type fakeDisk struct {
+	photon.PersistentDisk
+	projectID string
+}
+
+type fakeTask struct {
+	photon.Task
+	// Applies the operation once the task is done; an error fails the task
+	complete func() *photon.ApiError
+	done     bool
+}
+
+// A FakeAPI served on a local test server, Close it when finished
+type FakeController struct {
+	*httptest.Server
+	API *FakeAPI
+}
+
+// Starts a fake Photon Controller on a local port, its endpoint is the URL of the server.
+// Tasks complete on the first poll.
+func NewFakeController() *FakeController {
+	api := NewFakeAPI()
+	return &FakeController{Server: httptest.NewServer(api), API: api}
+}
+
+func NewFakeAPI() *FakeAPI {
+	return &FakeAPI{
+		tenants:  map[string]*photon.Tenant{},
+		projects: map[string]*fakeProject{},
+		vms:      map[string]*fakeVM{},
+		disks:    map[string]*fakeDisk{},
+		images:   map[string]*photon.Image{},
+		flavors:  map[string]*photon.Flavor{},
+		hosts:    map[string]*photon.Host{},
+		tasks:    map[string]*fakeTask{},
+	}
+}
+
+// An error response of the fake API
+type fakeError struct {
+	status int
+	photon.ApiError
+}
+
+func newFakeError(status int, code string, format string, args ...interface{}) *fakeError {
+	return &fakeError{status, photon.ApiError{Code: code, Message: fmt.Sprintf(format, args...)}}
+}
+
+func notFound(kind string, id string) *fakeError {
+	return newFakeError(http.StatusNotFound, strings.Title(kind)+"NotFound", "%s %s not found", kind, id)
+}
+
+func (api *FakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+	api.advanceTasks()
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1"), "/")
+	result, status, err := api.route(r, strings.Split(path, "/"))
+	if err != nil {
+		writeJSON(w, err.status, err.ApiError)
+		return
+	}
+	writeJSON(w, status, result)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func (api *FakeAPI) route(r *http.Request, path []string) (interface{}, int, *fakeError) {
+	var id, action string
+	if len(path) > 1 {
+		id = path[1]
+	}
+	if len(path) > 2 {
+		action = strings.Join(path[2:], "/")
+	}
+
+	switch path[0] {
+	case "info":
+		return &photon.Info{BaseVersion: "1.2.0", FullVersion: "1.2.0-fake", NetworkType: "SOFTWARE_DEFINED"}, http.StatusOK, nil
+	case "system":
+		if id == "auth" {
+			return &photon.AuthInfo{}, http.StatusOK, nil
+		}
+	case "tasks":
+		return api.routeTasks(r, id)
+	case "tenants":
+		return api.routeTenants(r, id, action)
+	case "projects":
+		return api.routeProjects(r, id, action)
+	case "vms":
+		return api.routeVMs(r, id, action)
+	case "disks":
+		return api.routeDisks(r, id, action)
+	case "images":
+		return api.routeImages(r, id, action)
+	case "flavors":
+		return api.routeFlavors(r, id, action)
+	case "infrastructure":
+		if id == "hosts" {
+			var hostID string
+			if len(path) > 2 {
+				hostID = path[2]
+				action = strings.Join(path[3:], "/")
+			}
+			return api.routeHosts(r, hostID, action)
+		}
+	}
+	return nil, 0, unsupported(r)
+}
+
+func unsupported(r *http.Request) *fakeError {
+	return newFakeError(http.StatusNotImplemented, "NotImplemented",
+		"%s %s is not supported by the fake Photon Controller", r.Method, r.URL.Path)
+}
+
+// Returns a list the way the API does, as a single page
+func list(items interface{}) (interface{}, int, *fakeError) {
+	return map[string]interface{}{"items": items}, http.StatusOK, nil
+}
+
+func decodeBody(r *http.Request, value interface{}) *fakeError {
+	err := json.NewDecoder(r.Body).Decode(value)
+	if err != nil {
+		return newFakeError(http.StatusBadRequest, "InvalidJson", "Invalid request body: %s", err)
+	}
+	return nil
+}
+
+// Generates a random version 4 UUID
+func newID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+func milliseconds(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// Queues a task for an operation on an entity; complete is called when it is done
+func (api *FakeAPI) submit(operation string, kind string, entityID string,
+	complete func() *photon.ApiError) (interface{}, int, *fakeError) {
+
+	task := &fakeTask{
+		Task: photon.Task{
+			ID:         newID(),
+			Operation:  operation,
+			State:      "QUEUED",
+			QueuedTime: milliseconds(time.Now()),
+			Entity:     photon.Entity{ID: entityID, Kind: kind},
+		},
+		complete: complete,
+	}
+	task.SelfLink = "/v1/tasks/" + task.ID
+	task.Steps = []photon.Step{{ID: newID(), Operation: operation, State: "QUEUED", QueuedTime: task.QueuedTime}}
+	api.tasks[task.ID] = task
+	api.order = append(api.order, task.ID)
+	return &task.Task, http.StatusCreated, nil
+}
+
+// Moves the tasks along according to their age, completing them in the order they were
+// submitted
+func (api *FakeAPI) advanceTasks() {
+	now := time.Now()
+	for _, id := range api.order {
+		task, ok := api.tasks[id]
+		if !ok || task.done {
+			continue
+		}
+		age := now.Sub(time.Unix(0, task.QueuedTime*int64(time.Millisecond)))
+		if age < api.TaskDuration/3 {
+			continue
+		}
+		if task.StartedTime == 0 {
+			task.State = "STARTED"
+			task.StartedTime = milliseconds(now)
+			task.Steps[0].State = task.State
+			task.Steps[0].StartedTime = task.StartedTime
+		}
+		if age < api.TaskDuration {
+			continue
+		}
+
+		task.done = true
+		task.EndTime = milliseconds(now)
+		task.State = "COMPLETED"
+		if task.complete != nil {
+			if err := task.complete(); err != nil {
+				task.State = "ERROR"
+				task.Steps[0].Errors = []photon.ApiError{*err}
+			}
+		}
+		task.Steps[0].State = task.State
+		task.Steps[0].EndTime = task.EndTime
+	}
+}
+
+func (api *FakeAPI) routeTasks(r *http.Request, id string) (interface{}, int, *fakeError) {
+	if r.Method != "GET" {
+		return nil, 0, unsupported(r)
+	}
+	if len(id) != 0 {
+		task, ok := api.tasks[id]
+		if !ok {
+			return nil, 0, notFound("task", id)
+		}
+		return &task.Task, http.StatusOK, nil
+	}
+	query := r.URL.Query()
+	return api.listTasks(query.Get("entityId"), query.Get("entityKind"), query.Get("state"))
+}
+
+func (api *FakeAPI) listTasks(entityID string, entityKind string, state string) (interface{}, int, *fakeError) {
+	tasks := []photon.Task{}
+	for _, id := range api.order {
+		task, ok := api.tasks[id]
+		if !ok || (len(entityID) != 0 && task.Entity.ID != entityID) ||
+			(len(entityKind) != 0 && task.Entity.Kind != entityKind) ||
+			(len(state) != 0 && !strings.EqualFold(task.State, state)) {
+			continue
+		}
+		tasks = append(tasks, task.Task)
+	}
+	return list(tasks)
+}
+
+// Usage of a quota by a cost, reported as a QuotaError when it goes beyond a limit
+func chargeQuota(quota *photon.Quota, cost []photon.QuotaLineItem, sign float64) *photon.ApiError {
+	if quota.QuotaLineItems == nil {
+		return nil
+	}
+	if sign > 0 {
+		for _, item := range cost {
+			line, ok := quota.QuotaLineItems[item.Key]
+			if ok && line.Usage+item.Value > line.Limit {
+				return &photon.ApiError{Code: "QuotaError", Message: fmt.Sprintf(
+					"Not enough quota: current usage %g %s, limit %g %s, requested %g %s of %s",
+					line.Usage, line.Unit, line.Limit, line.Unit, item.Value, item.Unit, item.Key)}
+			}
+		}
+	}
+	for _, item := range cost {
+		if line, ok := quota.QuotaLineItems[item.Key]; ok {
+			line.Usage += sign * item.Value
+			quota.QuotaLineItems[item.Key] = line
+		}
+	}
+	return nil
+}
+
+// Serves GET, PUT (set), PATCH (update) and DELETE (exclude) on the quota of a tenant or project
+func (api *FakeAPI) routeQuota(r *http.Request, kind string, id string, quota *photon.Quota) (interface{}, int, *fakeError) {
+	if r.Method == "GET" {
+		return quota, http.StatusOK, nil
+	}
+	spec := photon.QuotaSpec{}
+	if err := decodeBody(r, &spec); err != nil {
+		return nil, 0, err
+	}
+	var operation string
+	switch r.Method {
+	case "PUT":
+		operation = "SET_QUOTA"
+	case "PATCH":
+		operation = "UPDATE_QUOTA"
+	case "DELETE":
+		operation = "EXCLUDE_QUOTA"
+	default:
+		return nil, 0, unsupported(r)
+	}
+	return api.submit(operation, kind, id, func() *photon.ApiError {
+		items := map[string]photon.QuotaStatusLineItem{}
+		if r.Method != "PUT" {
+			for key, line := range quota.QuotaLineItems {
+				items[key] = line
+			}
+		}
+		for key, line := range spec {
+			if r.Method == "DELETE" {
+				delete(items, key)
+				continue
+			}
+			// Usage is kept, the limit may go below it as on a real deployment
+			line.Usage = quota.QuotaLineItems[key].Usage
+			items[key] = line
+		}
+		quota.QuotaLineItems = items
+		return nil
+	})
+}
+
+// Returns the IDs of the map keys in creation order
+func (api *FakeAPI) ordered(has func(id string) bool) []string {
+	ids := []string{}
+	for _, id := range api.order {
+		if has(id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,837 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package mocks
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/vmware/photon-controller-go-sdk/photon"
+)
+
+// Handlers of the resources of the fake API. They run with the lock of the API held,
+// and so do the completions of the tasks they submit.
+
+func (api *FakeAPI) routeTenants(r *http.Request, id string, action string) (interface{}, int, *fakeError) {
+	if len(id) == 0 {
+		switch r.Method {
+		case "GET":
+			tenants := []photon.Tenant{}
+			for _, tenantID := range api.ordered(func(id string) bool { return api.tenants[id] != nil }) {
+				tenant := api.tenant(tenantID)
+				if name := r.URL.Query().Get("name"); len(name) == 0 || tenant.Name == name {
+					tenants = append(tenants, *tenant)
+				}
+			}
+			return list(tenants)
+		case "POST":
+			return api.createTenant(r)
+		}
+		return nil, 0, unsupported(r)
+	}
+
+	tenant, ok := api.tenants[id]
+	if !ok {
+		return nil, 0, notFound("tenant", id)
+	}
+	switch {
+	case action == "" && r.Method == "GET":
+		return api.tenant(id), http.StatusOK, nil
+	case action == "" && r.Method == "DELETE":
+		return api.submit("DELETE_TENANT", "tenant", id, func() *photon.ApiError {
+			if len(api.tenant(id).Projects) != 0 {
+				return &photon.ApiError{Code: "ContainerNotEmpty", Message: "Tenant " + id + " still has projects"}
+			}
+			delete(api.tenants, id)
+			return nil
+		})
+	case action == "quota":
+		return api.routeQuota(r, "tenant", id, &tenant.ResourceQuota)
+	case action == "projects" && r.Method == "GET":
+		projects := []photon.ProjectCompact{}
+		for _, projectID := range api.ordered(func(pid string) bool { return api.projects[pid] != nil }) {
+			project := api.projects[projectID]
+			if name := r.URL.Query().Get("name"); project.tenantID == id && (len(name) == 0 || project.Name == name) {
+				projects = append(projects, project.ProjectCompact)
+			}
+		}
+		return list(projects)
+	case action == "projects" && r.Method == "POST":
+		return api.createProject(r, tenant)
+	case action == "tasks" && r.Method == "GET":
+		return api.listTasks(id, "", r.URL.Query().Get("state"))
+	case action == "set_security_groups" && r.Method == "POST":
+		return api.setSecurityGroups(r, "tenant", id, &tenant.SecurityGroups)
+	}
+	return nil, 0, unsupported(r)
+}
+
+// Returns the tenant with its projects
+func (api *FakeAPI) tenant(id string) *photon.Tenant {
+	tenant := api.tenants[id]
+	tenant.Projects = []photon.BaseCompact{}
+	for _, projectID := range api.ordered(func(id string) bool { return api.projects[id] != nil }) {
+		if project := api.projects[projectID]; project.tenantID == id {
+			tenant.Projects = append(tenant.Projects, photon.BaseCompact{Name: project.Name, ID: project.ID})
+		}
+	}
+	return tenant
+}
+
+func (api *FakeAPI) createTenant(r *http.Request) (interface{}, int, *fakeError) {
+	spec := photon.TenantCreateSpec{}
+	if err := decodeBody(r, &spec); err != nil {
+		return nil, 0, err
+	}
+	if len(spec.Name) == 0 {
+		return nil, 0, newFakeError(http.StatusBadRequest, "InvalidEntity", "Tenant name is required")
+	}
+	for _, tenant := range api.tenants {
+		if tenant.Name == spec.Name {
+			return nil, 0, newFakeError(http.StatusBadRequest, "NameTaken", "Tenant name '%s' is taken", spec.Name)
+		}
+	}
+
+	id := newID()
+	return api.submit("CREATE_TENANT", "tenant", id, func() *photon.ApiError {
+		quota := spec.ResourceQuota
+		for key, line := range quota.QuotaLineItems {
+			line.Usage = 0
+			quota.QuotaLineItems[key] = line
+		}
+		api.tenants[id] = &photon.Tenant{
+			ID:             id,
+			Name:           spec.Name,
+			Kind:           "tenant",
+			SelfLink:       "/v1/tenants/" + id,
+			SecurityGroups: securityGroups(spec.SecurityGroups),
+			ResourceQuota:  quota,
+		}
+		api.order = append(api.order, id)
+		return nil
+	})
+}
+
+func securityGroups(names []string) []photon.SecurityGroup {
+	groups := []photon.SecurityGroup{}
+	for _, name := range names {
+		groups = append(groups, photon.SecurityGroup{Name: name})
+	}
+	return groups
+}
+
+func (api *FakeAPI) setSecurityGroups(r *http.Request, kind string, id string,
+	groups *[]photon.SecurityGroup) (interface{}, int, *fakeError) {
+
+	spec := photon.SecurityGroupsSpec{}
+	if err := decodeBody(r, &spec); err != nil {
+		return nil, 0, err
+	}
+	return api.submit("SET_"+strings.ToUpper(kind)+"_SECURITY_GROUPS", kind, id, func() *photon.ApiError {
+		*groups = securityGroups(spec.Items)
+		return nil
+	})
+}
+
+// The quota a project allocates from its tenant: the limits of the project
+func projectAllocation(quota photon.Quota) []photon.QuotaLineItem {
+	var cost []photon.QuotaLineItem
+	for key, line := range quota.QuotaLineItems {
+		cost = append(cost, photon.QuotaLineItem{Key: key, Value: line.Limit, Unit: line.Unit})
+	}
+	return cost
+}
+
+func (api *FakeAPI) createProject(r *http.Request, tenant *photon.Tenant) (interface{}, int, *fakeError) {
+	spec := photon.ProjectCreateSpec{}
+	if err := decodeBody(r, &spec); err != nil {
+		return nil, 0, err
+	}
+	if len(spec.Name) == 0 {
+		return nil, 0, newFakeError(http.StatusBadRequest, "InvalidEntity", "Project name is required")
+	}
+	for _, project := range api.projects {
+		if project.tenantID == tenant.ID && project.Name == spec.Name {
+			return nil, 0, newFakeError(http.StatusBadRequest, "NameTaken", "Project name '%s' is taken", spec.Name)
+		}
+	}
+
+	id := newID()
+	return api.submit("CREATE_PROJECT", "project", id, func() *photon.ApiError {
+		quota := spec.ResourceQuota
+		for key, line := range quota.QuotaLineItems {
+			line.Usage = 0
+			quota.QuotaLineItems[key] = line
+		}
+		if err := chargeQuota(&tenant.ResourceQuota, projectAllocation(quota), 1); err != nil {
+			return err
+		}
+		api.projects[id] = &fakeProject{
+			ProjectCompact: photon.ProjectCompact{
+				ID:             id,
+				Name:           spec.Name,
+				Kind:           "project",
+				SelfLink:       "/v1/projects/" + id,
+				Tags:           []string{},
+				SecurityGroups: securityGroups(spec.SecurityGroups),
+				ResourceQuota:  quota,
+			},
+			tenantID: tenant.ID,
+		}
+		api.order = append(api.order, id)
+		return nil
+	})
+}
+
+func (api *FakeAPI) routeProjects(r *http.Request, id string, action string) (interface{}, int, *fakeError) {
+	project, ok := api.projects[id]
+	if !ok {
+		return nil, 0, notFound("project", id)
+	}
+	switch {
+	case action == "" && r.Method == "GET":
+		return &project.ProjectCompact, http.StatusOK, nil
+	case action == "" && r.Method == "DELETE":
+		return api.submit("DELETE_PROJECT", "project", id, func() *photon.ApiError {
+			for _, vm := range api.vms {
+				if vm.projectID == id {
+					return &photon.ApiError{Code: "ContainerNotEmpty", Message: "Project " + id + " still has VMs"}
+				}
+			}
+			for _, disk := range api.disks {
+				if disk.projectID == id {
+					return &photon.ApiError{Code: "ContainerNotEmpty", Message: "Project " + id + " still has disks"}
+				}
+			}
+			if tenant, ok := api.tenants[project.tenantID]; ok {
+				_ = chargeQuota(&tenant.ResourceQuota, projectAllocation(project.ResourceQuota), -1)
+			}
+			delete(api.projects, id)
+			return nil
+		})
+	case action == "quota":
+		return api.routeQuota(r, "project", id, &project.ResourceQuota)
+	case action == "vms" && r.Method == "GET":
+		vms := []photon.VM{}
+		for _, vmID := range api.ordered(func(id string) bool { return api.vms[id] != nil }) {
+			vm := api.vms[vmID]
+			if name := r.URL.Query().Get("name"); vm.projectID == id && (len(name) == 0 || vm.Name == name) {
+				vms = append(vms, vm.VM)
+			}
+		}
+		return list(vms)
+	case action == "vms" && r.Method == "POST":
+		return api.createVM(r, project)
+	case action == "disks" && r.Method == "GET":
+		disks := []photon.PersistentDisk{}
+		for _, diskID := range api.ordered(func(id string) bool { return api.disks[id] != nil }) {
+			disk := api.disks[diskID]
+			if name := r.URL.Query().Get("name"); disk.projectID == id && (len(name) == 0 || disk.Name == name) {
+				disks = append(disks, disk.PersistentDisk)
+			}
+		}
+		return list(disks)
+	case action == "disks" && r.Method == "POST":
+		return api.createDisk(r, project)
+	case action == "images" && r.Method == "POST":
+		return api.createImage(r, photon.ImageScope{Kind: "project", ID: id})
+	case action == "tasks" && r.Method == "GET":
+		return api.listTasks(id, "", r.URL.Query().Get("state"))
+	case action == "set_security_groups" && r.Method == "POST":
+		return api.setSecurityGroups(r, "project", id, &project.SecurityGroups)
+	}
+	return nil, 0, unsupported(r)
+}
+
+// Finds a flavor by name and kind
+func (api *FakeAPI) flavor(name string, kind string) (*photon.Flavor, *fakeError) {
+	for _, flavor := range api.flavors {
+		if flavor.Name == name && flavor.Kind == kind {
+			return flavor, nil
+		}
+	}
+	return nil, newFakeError(http.StatusBadRequest, "InvalidFlavor", "No %s flavor named '%s'", kind, name)
+}
+
+func (api *FakeAPI) createVM(r *http.Request, project *fakeProject) (interface{}, int, *fakeError) {
+	spec := photon.VmCreateSpec{}
+	if err := decodeBody(r, &spec); err != nil {
+		return nil, 0, err
+	}
+	if len(spec.Name) == 0 {
+		return nil, 0, newFakeError(http.StatusBadRequest, "InvalidEntity", "VM name is required")
+	}
+	flavor, err := api.flavor(spec.Flavor, "vm")
+	if err != nil {
+		return nil, 0, err
+	}
+	image, ok := api.images[spec.SourceImageID]
+	if !ok {
+		return nil, 0, notFound("image", spec.SourceImageID)
+	}
+	if image.State != "READY" {
+		return nil, 0, newFakeError(http.StatusBadRequest, "InvalidImageState",
+			"Image %s is %s, not READY", image.ID, image.State)
+	}
+
+	cost := append([]photon.QuotaLineItem{}, flavor.Cost...)
+	disks := []photon.AttachedDisk{}
+	for _, disk := range spec.AttachedDisks {
+		diskFlavor, err := api.flavor(disk.Flavor, "ephemeral-disk")
+		if err != nil {
+			return nil, 0, err
+		}
+		cost = append(cost, diskFlavor.Cost...)
+		disk.ID = newID()
+		disk.Kind = "ephemeral-disk"
+		disk.State = "ATTACHED"
+		disks = append(disks, disk)
+	}
+
+	vm := &fakeVM{
+		VM: photon.VM{
+			ID:            newID(),
+			Name:          spec.Name,
+			Kind:          "vm",
+			State:         "CREATING",
+			Flavor:        spec.Flavor,
+			SourceImageID: spec.SourceImageID,
+			AttachedDisks: disks,
+			Tags:          spec.Tags,
+			Cost:          cost,
+		},
+		projectID: project.ID,
+	}
+	vm.SelfLink = "/v1/vms/" + vm.ID
+	api.vms[vm.ID] = vm
+	api.order = append(api.order, vm.ID)
+	return api.submit("CREATE_VM", "vm", vm.ID, func() *photon.ApiError {
+		if err := chargeQuota(&project.ResourceQuota, cost, 1); err != nil {
+			vm.State = "ERROR"
+			return err
+		}
+		vm.State = "STOPPED"
+		vm.Host = api.placeVM()
+		return nil
+	})
+}
+
+// Picks the ready host with the fewest VMs; VMs have no host when there are none
+func (api *FakeAPI) placeVM() string {
+	var address string
+	least := -1
+	for _, hostID := range api.ordered(func(id string) bool { return api.hosts[id] != nil }) {
+		host := api.hosts[hostID]
+		if host.State != "READY" {
+			continue
+		}
+		if count := api.vmsOnHost(host.Address); least < 0 || count < least {
+			address, least = host.Address, count
+		}
+	}
+	return address
+}
+
+func (api *FakeAPI) vmsOnHost(address string) int {
+	count := 0
+	for _, vm := range api.vms {
+		if vm.Host == address {
+			count++
+		}
+	}
+	return count
+}
+
+// VM operations and the states they move VMs from and to
+var vmTransitions = map[string]struct {
+	operation string
+	from      []string
+	to        string
+}{
+	"start":   {"START_VM", []string{"STOPPED"}, "STARTED"},
+	"stop":    {"STOP_VM", []string{"STARTED", "SUSPENDED"}, "STOPPED"},
+	"restart": {"RESTART_VM", []string{"STARTED"}, "STARTED"},
+	"suspend": {"SUSPEND_VM", []string{"STARTED"}, "SUSPENDED"},
+	"resume":  {"RESUME_VM", []string{"SUSPENDED"}, "STARTED"},
+}
+
+func invalidVMState(vm *fakeVM, operation string) *photon.ApiError {
+	return &photon.ApiError{Code: "InvalidVmState",
+		Message: fmt.Sprintf("%s not allowed for VM %s in state %s", operation, vm.ID, vm.State)}
+}
+
+func (api *FakeAPI) routeVMs(r *http.Request, id string, action string) (interface{}, int, *fakeError) {
+	vm, ok := api.vms[id]
+	if !ok {
+		return nil, 0, notFound("vm", id)
+	}
+	if transition, ok := vmTransitions[action]; ok && r.Method == "POST" {
+		return api.submit(transition.operation, "vm", id, func() *photon.ApiError {
+			for _, state := range transition.from {
+				if vm.State == state {
+					vm.State = transition.to
+					return nil
+				}
+			}
+			return invalidVMState(vm, transition.operation)
+		})
+	}
+
+	switch {
+	case action == "" && r.Method == "GET":
+		return &vm.VM, http.StatusOK, nil
+	case action == "" && r.Method == "DELETE":
+		return api.submit("DELETE_VM", "vm", id, func() *photon.ApiError {
+			if vm.State != "STOPPED" && vm.State != "ERROR" {
+				return invalidVMState(vm, "DELETE_VM")
+			}
+			for _, disk := range api.disks {
+				if len(disk.VMs) != 0 && disk.VMs[0] == id {
+					disk.VMs = []string{}
+					disk.State = "DETACHED"
+				}
+			}
+			if vm.State != "ERROR" {
+				_ = chargeQuota(&api.projects[vm.projectID].ResourceQuota, vm.Cost, -1)
+			}
+			delete(api.vms, id)
+			return nil
+		})
+	case action == "tasks" && r.Method == "GET":
+		return api.listTasks(id, "", r.URL.Query().Get("state"))
+	case action == "subnets" && r.Method == "GET":
+		return api.getVMNetworks(vm)
+	case action == "set_metadata" && r.Method == "POST":
+		metadata := photon.VmMetadata{}
+		if err := decodeBody(r, &metadata); err != nil {
+			return nil, 0, err
+		}
+		return api.submit("SET_METADATA", "vm", id, func() *photon.ApiError {
+			vm.Metadata = metadata.Metadata
+			return nil
+		})
+	case action == "tags" && r.Method == "POST":
+		tag := photon.VmTag{}
+		if err := decodeBody(r, &tag); err != nil {
+			return nil, 0, err
+		}
+		return api.submit("ADD_TAG", "vm", id, func() *photon.ApiError {
+			vm.Tags = append(vm.Tags, tag.Tag)
+			return nil
+		})
+	case (action == "attach_disk" || action == "detach_disk") && r.Method == "POST":
+		op := photon.VmDiskOperation{}
+		if err := decodeBody(r, &op); err != nil {
+			return nil, 0, err
+		}
+		disk, ok := api.disks[op.DiskID]
+		if !ok {
+			return nil, 0, notFound("disk", op.DiskID)
+		}
+		if action == "attach_disk" {
+			return api.submit("ATTACH_DISK", "vm", id, func() *photon.ApiError {
+				return attachDisk(vm, disk)
+			})
+		}
+		return api.submit("DETACH_DISK", "vm", id, func() *photon.ApiError {
+			return detachDisk(vm, disk)
+		})
+	}
+	return nil, 0, unsupported(r)
+}
+
+// The network connections of a VM come back in the resource properties of a task
+func (api *FakeAPI) getVMNetworks(vm *fakeVM) (interface{}, int, *fakeError) {
+	var task *photon.Task
+	result, status, err := api.submit("GET_NETWORKS", "vm", vm.ID, func() *photon.ApiError {
+		connection := map[string]interface{}{
+			"network":     "default",
+			"macAddress":  "00:50:56:" + vm.ID[0:2] + ":" + vm.ID[2:4] + ":" + vm.ID[4:6],
+			"isConnected": vm.State == "STARTED",
+		}
+		if vm.State == "STARTED" {
+			connection["ipAddress"] = fmt.Sprintf("192.168.%d.%d", vm.ID[6]%16, 2+vm.ID[7]%250)
+			connection["netmask"] = "255.255.0.0"
+		}
+		task.ResourceProperties = map[string]interface{}{"networkConnections": []interface{}{connection}}
+		return nil
+	})
+	task = result.(*photon.Task)
+	return result, status, err
+}
+
+func attachDisk(vm *fakeVM, disk *fakeDisk) *photon.ApiError {
+	if disk.State != "DETACHED" {
+		return &photon.ApiError{Code: "InvalidDiskState",
+			Message: fmt.Sprintf("Disk %s is %s, not DETACHED", disk.ID, disk.State)}
+	}
+	if vm.projectID != disk.projectID {
+		return &photon.ApiError{Code: "InvalidEntity",
+			Message: fmt.Sprintf("Disk %s and VM %s are in different projects", disk.ID, vm.ID)}
+	}
+	disk.State = "ATTACHED"
+	disk.VMs = []string{vm.ID}
+	vm.AttachedDisks = append(vm.AttachedDisks, photon.AttachedDisk{
+		ID:         disk.ID,
+		Name:       disk.Name,
+		Kind:       disk.Kind,
+		Flavor:     disk.Flavor,
+		CapacityGB: disk.CapacityGB,
+		State:      disk.State,
+	})
+	return nil
+}
+
+func detachDisk(vm *fakeVM, disk *fakeDisk) *photon.ApiError {
+	for i, attached := range vm.AttachedDisks {
+		if attached.ID == disk.ID {
+			vm.AttachedDisks = append(vm.AttachedDisks[:i], vm.AttachedDisks[i+1:]...)
+			disk.State = "DETACHED"
+			disk.VMs = []string{}
+			return nil
+		}
+	}
+	return &photon.ApiError{Code: "InvalidEntity",
+		Message: fmt.Sprintf("Disk %s is not attached to VM %s", disk.ID, vm.ID)}
+}
+
+func (api *FakeAPI) createDisk(r *http.Request, project *fakeProject) (interface{}, int, *fakeError) {
+	spec := photon.DiskCreateSpec{}
+	if err := decodeBody(r, &spec); err != nil {
+		return nil, 0, err
+	}
+	if len(spec.Name) == 0 || spec.CapacityGB <= 0 {
+		return nil, 0, newFakeError(http.StatusBadRequest, "InvalidEntity", "Disk name and capacity are required")
+	}
+	flavor, err := api.flavor(spec.Flavor, "persistent-disk")
+	if err != nil {
+		return nil, 0, err
+	}
+	cost := append([]photon.QuotaLineItem{}, flavor.Cost...)
+	cost = append(cost, photon.QuotaLineItem{Key: "persistent-disk.capacity", Value: float64(spec.CapacityGB), Unit: "GB"})
+
+	disk := &fakeDisk{
+		PersistentDisk: photon.PersistentDisk{
+			ID:         newID(),
+			Name:       spec.Name,
+			Kind:       "persistent-disk",
+			Flavor:     spec.Flavor,
+			CapacityGB: spec.CapacityGB,
+			State:      "CREATING",
+			VMs:        []string{},
+			Tags:       spec.Tags,
+			Cost:       cost,
+		},
+		projectID: project.ID,
+	}
+	disk.SelfLink = "/v1/disks/" + disk.ID
+	api.disks[disk.ID] = disk
+	api.order = append(api.order, disk.ID)
+	return api.submit("CREATE_DISK", "persistent-disk", disk.ID, func() *photon.ApiError {
+		if err := chargeQuota(&project.ResourceQuota, cost, 1); err != nil {
+			disk.State = "ERROR"
+			return err
+		}
+		disk.State = "DETACHED"
+		return nil
+	})
+}
+
+func (api *FakeAPI) routeDisks(r *http.Request, id string, action string) (interface{}, int, *fakeError) {
+	disk, ok := api.disks[id]
+	if !ok {
+		return nil, 0, notFound("disk", id)
+	}
+	switch {
+	case action == "" && r.Method == "GET":
+		return &disk.PersistentDisk, http.StatusOK, nil
+	case action == "" && r.Method == "DELETE":
+		return api.submit("DELETE_DISK", "persistent-disk", id, func() *photon.ApiError {
+			if disk.State == "ATTACHED" {
+				return &photon.ApiError{Code: "InvalidDiskState",
+					Message: fmt.Sprintf("Disk %s is attached to VM %s", id, disk.VMs[0])}
+			}
+			if disk.State != "ERROR" {
+				_ = chargeQuota(&api.projects[disk.projectID].ResourceQuota, disk.Cost, -1)
+			}
+			delete(api.disks, id)
+			return nil
+		})
+	case action == "tasks" && r.Method == "GET":
+		return api.listTasks(id, "", r.URL.Query().Get("state"))
+	}
+	return nil, 0, unsupported(r)
+}
+
+// Reads an image uploaded as multipart form data, keeping only its name and size
+func (api *FakeAPI) createImage(r *http.Request, scope photon.ImageScope) (interface{}, int, *fakeError) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, 0, newFakeError(http.StatusBadRequest, "InvalidEntity", "Expecting a multipart upload: %s", err)
+	}
+	image := &photon.Image{
+		ID:              newID(),
+		Kind:            "image",
+		State:           "CREATING",
+		Scope:           scope,
+		ReplicationType: "EAGER",
+		Tags:            []string{},
+		Settings:        []photon.ImageSetting{},
+	}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, newFakeError(http.StatusBadRequest, "InvalidEntity", "Invalid upload: %s", err)
+		}
+		switch part.FormName() {
+		case "file":
+			image.Name = part.FileName()
+			image.Size, err = io.Copy(ioutil.Discard, part)
+		case "ImageReplication":
+			var value []byte
+			value, err = ioutil.ReadAll(part)
+			if len(value) != 0 {
+				image.ReplicationType = string(value)
+			}
+		}
+		if err != nil {
+			return nil, 0, newFakeError(http.StatusBadRequest, "InvalidEntity", "Invalid upload: %s", err)
+		}
+	}
+	if len(image.Name) == 0 {
+		return nil, 0, newFakeError(http.StatusBadRequest, "InvalidEntity", "Image file is required")
+	}
+
+	image.SelfLink = "/v1/images/" + image.ID
+	api.images[image.ID] = image
+	api.order = append(api.order, image.ID)
+	return api.submit("CREATE_IMAGE", "image", image.ID, func() *photon.ApiError {
+		image.State = "READY"
+		image.SeedingProgress = "100.0%"
+		image.ReplicationProgress = "100.0%"
+		return nil
+	})
+}
+
+func (api *FakeAPI) routeImages(r *http.Request, id string, action string) (interface{}, int, *fakeError) {
+	if len(id) == 0 {
+		switch r.Method {
+		case "GET":
+			images := []photon.Image{}
+			for _, imageID := range api.ordered(func(id string) bool { return api.images[id] != nil }) {
+				image := api.images[imageID]
+				if name := r.URL.Query().Get("name"); len(name) == 0 || image.Name == name {
+					images = append(images, *image)
+				}
+			}
+			return list(images)
+		case "POST":
+			return api.createImage(r, photon.ImageScope{Kind: "infrastructure"})
+		}
+		return nil, 0, unsupported(r)
+	}
+
+	image, ok := api.images[id]
+	if !ok {
+		return nil, 0, notFound("image", id)
+	}
+	switch {
+	case action == "" && r.Method == "GET":
+		return image, http.StatusOK, nil
+	case action == "" && r.Method == "DELETE":
+		return api.submit("DELETE_IMAGE", "image", id, func() *photon.ApiError {
+			delete(api.images, id)
+			return nil
+		})
+	case action == "tasks" && r.Method == "GET":
+		return api.listTasks(id, "", r.URL.Query().Get("state"))
+	}
+	return nil, 0, unsupported(r)
+}
+
+var flavorKinds = map[string]bool{"vm": true, "ephemeral-disk": true, "persistent-disk": true}
+
+func (api *FakeAPI) routeFlavors(r *http.Request, id string, action string) (interface{}, int, *fakeError) {
+	if len(id) == 0 {
+		switch r.Method {
+		case "GET":
+			flavors := []photon.Flavor{}
+			query := r.URL.Query()
+			for _, flavorID := range api.ordered(func(id string) bool { return api.flavors[id] != nil }) {
+				flavor := api.flavors[flavorID]
+				if (len(query.Get("name")) == 0 || flavor.Name == query.Get("name")) &&
+					(len(query.Get("kind")) == 0 || flavor.Kind == query.Get("kind")) {
+					flavors = append(flavors, *flavor)
+				}
+			}
+			return list(flavors)
+		case "POST":
+			return api.createFlavor(r)
+		}
+		return nil, 0, unsupported(r)
+	}
+
+	flavor, ok := api.flavors[id]
+	if !ok {
+		return nil, 0, notFound("flavor", id)
+	}
+	switch {
+	case action == "" && r.Method == "GET":
+		return flavor, http.StatusOK, nil
+	case action == "" && r.Method == "DELETE":
+		return api.submit("DELETE_FLAVOR", "flavor", id, func() *photon.ApiError {
+			delete(api.flavors, id)
+			return nil
+		})
+	case action == "tasks" && r.Method == "GET":
+		return api.listTasks(id, "", r.URL.Query().Get("state"))
+	}
+	return nil, 0, unsupported(r)
+}
+
+func (api *FakeAPI) createFlavor(r *http.Request) (interface{}, int, *fakeError) {
+	spec := photon.FlavorCreateSpec{}
+	if err := decodeBody(r, &spec); err != nil {
+		return nil, 0, err
+	}
+	if len(spec.Name) == 0 || !flavorKinds[spec.Kind] {
+		return nil, 0, newFakeError(http.StatusBadRequest, "InvalidEntity",
+			"Flavor name is required and kind must be vm, ephemeral-disk or persistent-disk")
+	}
+	if _, err := api.flavor(spec.Name, spec.Kind); err == nil {
+		return nil, 0, newFakeError(http.StatusBadRequest, "NameTaken", "Flavor name '%s' is taken", spec.Name)
+	}
+
+	id := newID()
+	return api.submit("CREATE_FLAVOR", "flavor", id, func() *photon.ApiError {
+		api.flavors[id] = &photon.Flavor{
+			ID:       id,
+			Name:     spec.Name,
+			Kind:     spec.Kind,
+			Cost:     spec.Cost,
+			State:    "READY",
+			Tags:     []string{},
+			SelfLink: "/v1/flavors/" + id,
+		}
+		api.order = append(api.order, id)
+		return nil
+	})
+}
+
+// Host operations and the states they move hosts from and to
+var hostTransitions = map[string]struct {
+	operation string
+	from      string
+	to        string
+}{
+	"enter-maintenance": {"ENTER_MAINTENANCE_MODE", "READY", "MAINTENANCE"},
+	"exit-maintenance":  {"EXIT_MAINTENANCE_MODE", "MAINTENANCE", "READY"},
+	"suspend":           {"SUSPEND_HOST", "READY", "SUSPENDED"},
+	"resume":            {"RESUME_HOST", "SUSPENDED", "READY"},
+}
+
+func (api *FakeAPI) routeHosts(r *http.Request, id string, action string) (interface{}, int, *fakeError) {
+	if len(id) == 0 {
+		switch r.Method {
+		case "GET":
+			hosts := []photon.Host{}
+			for _, hostID := range api.ordered(func(id string) bool { return api.hosts[id] != nil }) {
+				hosts = append(hosts, *api.hosts[hostID])
+			}
+			return list(hosts)
+		case "POST":
+			return api.createHost(r)
+		}
+		return nil, 0, unsupported(r)
+	}
+
+	host, ok := api.hosts[id]
+	if !ok {
+		return nil, 0, notFound("host", id)
+	}
+	if transition, ok := hostTransitions[action]; ok && r.Method == "POST" {
+		return api.submit(transition.operation, "host", id, func() *photon.ApiError {
+			if host.State != transition.from {
+				return &photon.ApiError{Code: "InvalidHostState", Message: fmt.Sprintf(
+					"%s not allowed for host %s in state %s", transition.operation, id, host.State)}
+			}
+			if transition.to == "MAINTENANCE" && api.vmsOnHost(host.Address) != 0 {
+				return &photon.ApiError{Code: "HostHasVms", Message: "Host " + id + " still has VMs"}
+			}
+			host.State = transition.to
+			return nil
+		})
+	}
+
+	switch {
+	case action == "" && r.Method == "GET":
+		return host, http.StatusOK, nil
+	case action == "" && r.Method == "DELETE":
+		return api.submit("DELETE_HOST", "host", id, func() *photon.ApiError {
+			if api.vmsOnHost(host.Address) != 0 {
+				return &photon.ApiError{Code: "HostHasVms", Message: "Host " + id + " still has VMs"}
+			}
+			delete(api.hosts, id)
+			return nil
+		})
+	case action == "vms" && r.Method == "GET":
+		vms := []photon.VM{}
+		for _, vmID := range api.ordered(func(id string) bool { return api.vms[id] != nil }) {
+			if vm := api.vms[vmID]; vm.Host == host.Address {
+				vms = append(vms, vm.VM)
+			}
+		}
+		return list(vms)
+	case action == "tasks" && r.Method == "GET":
+		return api.listTasks(id, "", r.URL.Query().Get("state"))
+	}
+	return nil, 0, unsupported(r)
+}
+
+func (api *FakeAPI) createHost(r *http.Request) (interface{}, int, *fakeError) {
+	spec := photon.HostCreateSpec{}
+	if err := decodeBody(r, &spec); err != nil {
+		return nil, 0, err
+	}
+	if len(spec.Address) == 0 {
+		return nil, 0, newFakeError(http.StatusBadRequest, "InvalidEntity", "Host address is required")
+	}
+	for _, host := range api.hosts {
+		if host.Address == spec.Address {
+			return nil, 0, newFakeError(http.StatusBadRequest, "HostExists", "Host %s already exists", spec.Address)
+		}
+	}
+
+	// The password is not returned by the API
+	host := &photon.Host{
+		ID:         newID(),
+		Address:    spec.Address,
+		Username:   spec.Username,
+		Kind:       "host",
+		Zone:       spec.Zone,
+		Tags:       spec.Tags,
+		Metadata:   spec.Metadata,
+		State:      "CREATING",
+		EsxVersion: "6.0.0",
+	}
+	host.SelfLink = "/v1/infrastructure/hosts/" + host.ID
+	api.hosts[host.ID] = host
+	api.order = append(api.order, host.ID)
+	return api.submit("CREATE_HOST", "host", host.ID, func() *photon.ApiError {
+		host.State = "READY"
+		return nil
+	})
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package mocks
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vmware/photon-controller-go-sdk/photon"
+)
+
+func TestFakeController(t *testing.T) {
+	server := NewFakeController()
+	defer server.Close()
+	api := photon.NewClient(server.URL, nil, nil)
+
+	wait := func(task *photon.Task, err error) *photon.Task {
+		if err != nil {
+			t.Fatal("Not expecting error submitting task: ", err)
+		}
+		task, err = api.Tasks.Wait(task.ID)
+		if err != nil {
+			t.Fatal("Not expecting task error: ", err)
+		}
+		return task
+	}
+
+	wait(api.Flavors.Create(&photon.FlavorCreateSpec{Name: "small", Kind: "vm",
+		Cost: []photon.QuotaLineItem{{Key: "vm.cpu", Value: 2, Unit: "COUNT"}}}))
+	wait(api.Flavors.Create(&photon.FlavorCreateSpec{Name: "disk", Kind: "ephemeral-disk"}))
+	image := wait(api.Images.Create(strings.NewReader("ova"), "photon-os", nil))
+
+	tenant := wait(api.Tenants.Create(&photon.TenantCreateSpec{Name: "tenant1", ResourceQuota: photon.Quota{
+		QuotaLineItems: map[string]photon.QuotaStatusLineItem{"vm.cpu": {Limit: 10, Unit: "COUNT"}}}}))
+	project := wait(api.Tenants.CreateProject(tenant.Entity.ID, &photon.ProjectCreateSpec{Name: "project1",
+		ResourceQuota: photon.Quota{QuotaLineItems: map[string]photon.QuotaStatusLineItem{"vm.cpu": {Limit: 3, Unit: "COUNT"}}}}))
+
+	tenantQuota, err := api.Tenants.GetQuota(tenant.Entity.ID)
+	if err != nil || tenantQuota.QuotaLineItems["vm.cpu"].Usage != 3 {
+		t.Errorf("Expecting the project to use 3 CPUs of the tenant quota, got %+v, %v", tenantQuota, err)
+	}
+
+	vmSpec := &photon.VmCreateSpec{Name: "vm-1", Flavor: "small", SourceImageID: image.Entity.ID,
+		AttachedDisks: []photon.AttachedDisk{{Name: "boot", Flavor: "disk", BootDisk: true, CapacityGB: 1}}}
+	vm := wait(api.Projects.CreateVM(project.Entity.ID, vmSpec))
+	wait(api.VMs.Start(vm.Entity.ID))
+	started, err := api.VMs.Get(vm.Entity.ID)
+	if err != nil || started.State != "STARTED" {
+		t.Errorf("Expecting the VM to be started, got %+v, %v", started, err)
+	}
+
+	// A started VM cannot be deleted
+	task, err := api.VMs.Delete(vm.Entity.ID)
+	if err == nil {
+		_, err = api.Tasks.Wait(task.ID)
+	}
+	if _, ok := err.(photon.TaskError); !ok {
+		t.Errorf("Expecting the deletion of a started VM to fail, got %v", err)
+	}
+
+	// A second VM goes beyond the quota of the project
+	vmSpec.Name = "vm-2"
+	task, err = api.Projects.CreateVM(project.Entity.ID, vmSpec)
+	if err == nil {
+		task, err = api.Tasks.Wait(task.ID)
+	}
+	if err == nil || task.Steps[0].Errors[0].Code != "QuotaError" {
+		t.Errorf("Expecting a quota error creating a second VM, got %v", err)
+	}
+
+	wait(api.VMs.Stop(vm.Entity.ID))
+	wait(api.VMs.Delete(vm.Entity.ID))
+	projectQuota, err := api.Projects.GetQuota(project.Entity.ID)
+	if err != nil || projectQuota.QuotaLineItems["vm.cpu"].Usage != 0 {
+		t.Errorf("Expecting the quota to be released, got %+v, %v", projectQuota, err)
+	}
+	vms, err := api.Projects.GetVMs(project.Entity.ID, nil)
+	if err != nil || len(vms.Items) != 1 || vms.Items[0].State != "ERROR" {
+		t.Errorf("Expecting only the VM that failed to be created, got %+v, %v", vms, err)
+	}
+}
+
+func TestFakeTaskStates(t *testing.T) {
+	server := NewFakeController()
+	defer server.Close()
+	server.API.TaskDuration = 300 * time.Millisecond
+	api := photon.NewClient(server.URL, nil, nil)
+
+	task, err := api.Tenants.Create(&photon.TenantCreateSpec{Name: "tenant1"})
+	if err != nil || task.State != "QUEUED" {
+		t.Fatalf("Expecting a queued task, got %+v, %v", task, err)
+	}
+	time.Sleep(150 * time.Millisecond)
+	task, err = api.Tasks.Get(task.ID)
+	if err != nil || task.State != "STARTED" {
+		t.Errorf("Expecting the task to be started, got %+v, %v", task, err)
+	}
+	tenants, err := api.Tenants.GetAll()
+	if err != nil || len(tenants.Items) != 0 {
+		t.Errorf("Expecting no tenant until the task completes, got %+v, %v", tenants, err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	task, err = api.Tasks.Get(task.ID)
+	if err != nil || task.State != "COMPLETED" {
+		t.Errorf("Expecting the task to be completed, got %+v, %v", task, err)
+	}
+	tenant, err := api.Tenants.Get(task.Entity.ID)
+	if err != nil || tenant.Name != "tenant1" {
+		t.Errorf("Expecting tenant1 to be created, got %+v, %v", tenant, err)
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+// photon-fake-server serves an in-memory Photon Controller API for developing scripts
+// and running CI against the CLI without a deployment:
+//
+//	photon-fake-server -listen localhost:9080 -task-duration 2s &
+//	photon target set -c http://localhost:9080
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/vmware/photon-controller-cli/photon/mocks"
+)
+
+func main() {
+	listen := flag.String("listen", "localhost:9080", "address to listen on")
+	taskDuration := flag.Duration("task-duration", 0, "how long tasks take to complete, e.g. 2s")
+	flag.Parse()
+
+	api := mocks.NewFakeAPI()
+	api.TaskDuration = *taskDuration
+	log.Printf("Fake Photon Controller listening on http://%s", *listen)
+	log.Fatal(http.ListenAndServe(*listen, api))
+}
@@ -13,10 +13,16 @@ package utils
  * These utilities format output in a variety of ways.
  *
  * The goal is to have multiple methods of output so that it's easy to script the CLI
- * in whatever way a user wants. Currently we just implement JSON output, but we plan
- * to implement a subset of the JSONPath spec so that we can implement:
- * - output just a single value (e.g. ID) from an object
- * - output a list of objects as a table with the columns specified by the user
+ * in whatever way a user wants. We support:
+ * - json: the whole object as JSON
+ * - jsonpath=<template>: selected values using a subset of the JSONPath spec, e.g.
+ *   output just a single value (e.g. ID) from an object with --output 'jsonpath={.id}'
+ * - go-template=<template>: a Go text/template applied to the JSON form of the object
+ * - custom-columns=<spec>: a list of objects as a table with the columns specified by
+ *   the user, e.g. --output custom-columns=NAME:.name,STATE:.state
+ * - yaml: the whole object as YAML
+ * - csv and tsv: one row per object, nested fields are flattened into dotted column
+ *   names such as attachedDisks.0.name
  *
  * In order to make life easier for callers, they pass us the CLI context and we examine
  * the arguments in here. Note that the arguments are global arguments (they occur before
@@ -25,23 +31,38 @@ package utils
 
 import (
 	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
 
 	"github.com/urfave/cli"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	jsonPathPrefix      = "jsonpath="
+	goTemplatePrefix    = "go-template="
+	customColumnsPrefix = "custom-columns="
 )
 
 // Called by main to validate the output arguments
-// Currently it only validates the --output argument. Eventually when we support
-// json path, it will validate that as well.
+// It validates the --output argument, including the templates and column specs it may contain.
 func ValidateArgs(c *cli.Context) error {
 	if c.GlobalBool("non-interactive") == true && c.GlobalString("output") != "" {
 		return fmt.Errorf("--non-interactive and --output are mutually exclusive")
 	}
-	if c.GlobalString("output") != "" && c.GlobalString("output") != "json" {
-		return fmt.Errorf("output type must be 'json'")
+	if c.GlobalString("output") != "" {
+		err := validateOutputType(c.GlobalString("output"))
+		if err != nil {
+			return err
+		}
 	}
 	if c.GlobalBool("detail") == true && c.GlobalString("output") != "" {
 		return fmt.Errorf("--detail and --output are mutually exclusive")
@@ -57,13 +78,42 @@ func NeedsFormatting(c *cli.Context) bool {
 	return c.GlobalString("output") != ""
 }
 
+// Checks that the output type is known and that its template or column spec parses
+func validateOutputType(outputType string) error {
+	var err error
+	switch {
+	case outputType == "json", outputType == "yaml", outputType == "csv", outputType == "tsv":
+	case strings.HasPrefix(outputType, jsonPathPrefix):
+		_, err = parseJsonPathTemplate(strings.TrimPrefix(outputType, jsonPathPrefix))
+	case strings.HasPrefix(outputType, goTemplatePrefix):
+		_, err = template.New("output").Parse(strings.TrimPrefix(outputType, goTemplatePrefix))
+	case strings.HasPrefix(outputType, customColumnsPrefix):
+		_, err = parseCustomColumns(strings.TrimPrefix(outputType, customColumnsPrefix))
+	default:
+		err = fmt.Errorf("output type must be 'json', 'yaml', 'csv', 'tsv', 'jsonpath=<template>', " +
+			"'go-template=<template>' or 'custom-columns=<spec>'")
+	}
+	return err
+}
+
 // Outputs the given object (image, list of images, VM, etc...) as specified by the user
-// Currently we only support JSON output, but we will support more formats later.
 func FormatObject(o interface{}, w io.Writer, c *cli.Context) {
 	outputType := c.GlobalString("output")
-	switch outputType {
-	case "json":
+	switch {
+	case outputType == "json":
 		formatObjectJson(o, w)
+	case outputType == "yaml":
+		formatObjectYaml(o, w)
+	case outputType == "csv":
+		formatObjectDelimited(o, w, ',')
+	case outputType == "tsv":
+		formatObjectDelimited(o, w, '\t')
+	case strings.HasPrefix(outputType, jsonPathPrefix):
+		formatObjectJsonPath(o, w, strings.TrimPrefix(outputType, jsonPathPrefix))
+	case strings.HasPrefix(outputType, goTemplatePrefix):
+		formatObjectGoTemplate(o, w, strings.TrimPrefix(outputType, goTemplatePrefix))
+	case strings.HasPrefix(outputType, customColumnsPrefix):
+		formatObjectCustomColumns(o, w, strings.TrimPrefix(outputType, customColumnsPrefix))
 	default:
 		fmt.Fprintf(w, "Unknown output type: '%s'", outputType)
 	}
@@ -98,3 +148,259 @@ func formatObjectJson(o interface{}, w io.Writer) {
 	}
 	fmt.Fprintf(w, "%s\n", string(prettyJSON.Bytes()))
 }
+
+// Output the values selected by a JSONPath template
+func formatObjectJsonPath(o interface{}, w io.Writer, jsonPath string) {
+	parsed, err := parseJsonPathTemplate(jsonPath)
+	if err != nil {
+		fmt.Fprintf(w, "%s", err)
+		return
+	}
+
+	data, err := toJsonData(o)
+	if err != nil {
+		fmt.Fprintf(w, "Cannot convert output to JSON: %s", err)
+		return
+	}
+
+	output, err := parsed.execute(data)
+	if err != nil {
+		fmt.Fprintf(w, "Cannot format JSONPath output: %s", err)
+		return
+	}
+	fmt.Fprintf(w, "%s\n", output)
+}
+
+// Output an object through a Go template. The template sees the JSON form of the object,
+// so it uses the same field names as the JSON output, e.g. {{.name}}
+func formatObjectGoTemplate(o interface{}, w io.Writer, goTemplate string) {
+	parsed, err := template.New("output").Parse(goTemplate)
+	if err != nil {
+		fmt.Fprintf(w, "Cannot parse template: %s", err)
+		return
+	}
+
+	data, err := toJsonData(o)
+	if err != nil {
+		fmt.Fprintf(w, "Cannot convert output to JSON: %s", err)
+		return
+	}
+
+	var output bytes.Buffer
+	err = parsed.Execute(&output, data)
+	if err != nil {
+		fmt.Fprintf(w, "Cannot format template output: %s", err)
+		return
+	}
+	fmt.Fprintf(w, "%s\n", output.String())
+}
+
+type customColumn struct {
+	header     string
+	expression *jsonPathExpression
+}
+
+// Parses a column spec such as "NAME:.name,STATE:.state"
+func parseCustomColumns(spec string) ([]customColumn, error) {
+	columns := []customColumn{}
+	for _, column := range strings.Split(spec, ",") {
+		parts := strings.SplitN(column, ":", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+			return nil, fmt.Errorf("Custom column '%s' must have the form <HEADER>:<JSONPath expression>", column)
+		}
+		path := strings.TrimSuffix(strings.TrimPrefix(parts[1], "{"), "}")
+		expression, err := parseJsonPathExpression(path)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, customColumn{header: parts[0], expression: expression})
+	}
+	return columns, nil
+}
+
+// Output an object, or a list of objects, as a table with one row per object
+func formatObjectCustomColumns(o interface{}, w io.Writer, spec string) {
+	columns, err := parseCustomColumns(spec)
+	if err != nil {
+		fmt.Fprintf(w, "%s", err)
+		return
+	}
+
+	data, err := toJsonData(o)
+	if err != nil {
+		fmt.Fprintf(w, "Cannot convert output to JSON: %s", err)
+		return
+	}
+	rows, ok := data.([]interface{})
+	if !ok {
+		rows = []interface{}{data}
+	}
+
+	tw := new(tabwriter.Writer)
+	tw.Init(w, 4, 4, 2, ' ', 0)
+	headers := []string{}
+	for _, column := range columns {
+		headers = append(headers, column.header)
+	}
+	fmt.Fprintf(tw, "%s\n", strings.Join(headers, "\t"))
+	for _, row := range rows {
+		cells := []string{}
+		for _, column := range columns {
+			cell, err := jsonPathValuesToString(column.expression.evaluate(row), ",")
+			if err != nil {
+				fmt.Fprintf(w, "Cannot format custom columns output: %s", err)
+				return
+			}
+			if len(cell) == 0 {
+				cell = "<none>"
+			}
+			cells = append(cells, cell)
+		}
+		fmt.Fprintf(tw, "%s\n", strings.Join(cells, "\t"))
+	}
+	err = tw.Flush()
+	if err != nil {
+		fmt.Fprintf(w, "Cannot format custom columns output: %s", err)
+	}
+}
+
+// Output an object as YAML, with the same field names as the JSON output
+func formatObjectYaml(o interface{}, w io.Writer) {
+	data, err := toJsonData(o)
+	if err != nil {
+		fmt.Fprintf(w, "Cannot convert output to JSON: %s", err)
+		return
+	}
+
+	yamlBytes, err := yaml.Marshal(withNativeNumbers(data))
+	if err != nil {
+		fmt.Fprintf(w, "Cannot convert output to YAML: %s", err)
+		return
+	}
+	fmt.Fprintf(w, "%s", string(yamlBytes))
+}
+
+// Replaces the json.Number values of decoded JSON data by int64 or float64
+func withNativeNumbers(data interface{}) interface{} {
+	switch typed := data.(type) {
+	case json.Number:
+		if i, err := typed.Int64(); err == nil {
+			return i
+		}
+		if f, err := typed.Float64(); err == nil {
+			return f
+		}
+		return typed.String()
+	case map[string]interface{}:
+		for key, value := range typed {
+			typed[key] = withNativeNumbers(value)
+		}
+	case []interface{}:
+		for i, value := range typed {
+			typed[i] = withNativeNumbers(value)
+		}
+	}
+	return data
+}
+
+// Output an object, or a list of objects, as CSV or TSV with one row per object.
+// The columns are the union of the flattened fields of all objects, in alphabetical order.
+func formatObjectDelimited(o interface{}, w io.Writer, delimiter rune) {
+	data, err := toJsonData(o)
+	if err != nil {
+		fmt.Fprintf(w, "Cannot convert output to JSON: %s", err)
+		return
+	}
+	rows, ok := data.([]interface{})
+	if !ok {
+		rows = []interface{}{data}
+	}
+
+	flattenedRows := []map[string]string{}
+	columnSet := map[string]bool{}
+	for _, row := range rows {
+		flattened := map[string]string{}
+		flattenJsonData("", row, flattened)
+		for column := range flattened {
+			columnSet[column] = true
+		}
+		flattenedRows = append(flattenedRows, flattened)
+	}
+	columns := []string{}
+	for column := range columnSet {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	if len(columns) == 0 {
+		return
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Comma = delimiter
+	err = writer.Write(columns)
+	for _, flattened := range flattenedRows {
+		if err != nil {
+			break
+		}
+		record := []string{}
+		for _, column := range columns {
+			record = append(record, flattened[column])
+		}
+		err = writer.Write(record)
+	}
+	writer.Flush()
+	if err == nil {
+		err = writer.Error()
+	}
+	if err != nil {
+		fmt.Fprintf(w, "Cannot format delimited output: %s", err)
+	}
+}
+
+// Flattens decoded JSON data into dotted names. Objects and lists of objects add a level
+// to the name, lists of plain values are kept in one column separated by ';'.
+func flattenJsonData(prefix string, data interface{}, flattened map[string]string) {
+	join := func(name string) string {
+		if len(prefix) == 0 {
+			return name
+		}
+		return prefix + "." + name
+	}
+
+	switch typed := data.(type) {
+	case map[string]interface{}:
+		for key, value := range typed {
+			flattenJsonData(join(key), value, flattened)
+		}
+	case []interface{}:
+		if isPlainList(typed) {
+			values, _ := jsonPathValuesToString(typed, ";")
+			if len(prefix) == 0 {
+				prefix = "value"
+			}
+			flattened[prefix] = values
+			return
+		}
+		for i, value := range typed {
+			flattenJsonData(join(strconv.Itoa(i)), value, flattened)
+		}
+	default:
+		value, _ := jsonPathValuesToString([]interface{}{typed}, "")
+		if len(prefix) == 0 {
+			prefix = "value"
+		}
+		flattened[prefix] = value
+	}
+}
+
+// Tells if a list holds no objects or lists
+func isPlainList(list []interface{}) bool {
+	for _, value := range list {
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,164 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package utils
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+type testDisk struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+type testVM struct {
+	ID    string     `json:"id"`
+	Name  string     `json:"name"`
+	Disks []testDisk `json:"attachedDisks"`
+	Cost  []float64  `json:"cost"`
+}
+
+var testVMs = []testVM{
+	{
+		ID:    "vm-1",
+		Name:  "first",
+		Disks: []testDisk{{ID: "disk-1", Name: "boot", State: "ATTACHED"}},
+		Cost:  []float64{1, 2.5},
+	},
+	{
+		ID:    "vm-2",
+		Name:  "second",
+		Disks: []testDisk{{ID: "disk-2", Name: "boot"}, {ID: "disk-3", Name: "data"}},
+	},
+}
+
+func newOutputContext(t *testing.T, output string) *cli.Context {
+	globalSet := flag.NewFlagSet("global", 0)
+	globalSet.String("output", "", "doc")
+	err := globalSet.Parse([]string{"--output", output})
+	if err != nil {
+		t.Error("Not expecting arguments parsing to fail")
+	}
+	globalCtx := cli.NewContext(nil, globalSet, nil)
+	return cli.NewContext(nil, flag.NewFlagSet("test", 0), globalCtx)
+}
+
+func formatForTest(t *testing.T, o interface{}, output string) string {
+	c := newOutputContext(t, output)
+	err := ValidateArgs(c)
+	if err != nil {
+		t.Errorf("Not expecting error validating output '%s': %s", output, err)
+	}
+	var buf bytes.Buffer
+	FormatObjects(o, &buf, c)
+	return buf.String()
+}
+
+func TestValidateOutputType(t *testing.T) {
+	invalid := []string{
+		"xml",
+		"jsonpath={.id",
+		"jsonpath={.items[}",
+		"go-template={{.id",
+		"custom-columns=NAME",
+		"custom-columns=NAME:.name,:.id",
+	}
+	for _, output := range invalid {
+		err := ValidateArgs(newOutputContext(t, output))
+		if err == nil {
+			t.Errorf("Expecting error validating output '%s'", output)
+		}
+	}
+}
+
+func TestFormatJsonPath(t *testing.T) {
+	cases := map[string]string{
+		"jsonpath={[0].id}":                             "vm-1\n",
+		"jsonpath={[*].name}":                           "first second\n",
+		"jsonpath={[-1].attachedDisks[*].id}":           "disk-2 disk-3\n",
+		"jsonpath={$[0].cost}":                          "[1,2.5]\n",
+		"jsonpath={[0].cost[1]}":                        "2.5\n",
+		"jsonpath=id={[0]['id']}{\"\\t\"}{[0].nothing}": "id=vm-1\t\n",
+	}
+	for output, expected := range cases {
+		actual := formatForTest(t, testVMs, output)
+		if actual != expected {
+			t.Errorf("Output '%s': expected %q, got %q", output, expected, actual)
+		}
+	}
+}
+
+func TestFormatGoTemplate(t *testing.T) {
+	actual := formatForTest(t, testVMs, "go-template={{range .}}{{.id}}:{{len .attachedDisks}} {{end}}")
+	expected := "vm-1:1 vm-2:2 \n"
+	if actual != expected {
+		t.Errorf("Expected %q, got %q", expected, actual)
+	}
+}
+
+func TestFormatCustomColumns(t *testing.T) {
+	actual := formatForTest(t, testVMs, "custom-columns=ID:.id,DISKS:{.attachedDisks[*].name},STATE:.attachedDisks[0].state")
+	expected := "ID    DISKS      STATE\n" +
+		"vm-1  boot       ATTACHED\n" +
+		"vm-2  boot,data  <none>\n"
+	if actual != expected {
+		t.Errorf("Expected %q, got %q", expected, actual)
+	}
+
+	// A single object is printed as a table with one row
+	actual = formatForTest(t, testVMs[0], "custom-columns=NAME:.name")
+	expected = "NAME\nfirst\n"
+	if actual != expected {
+		t.Errorf("Expected %q, got %q", expected, actual)
+	}
+}
+
+func TestFormatYaml(t *testing.T) {
+	actual := formatForTest(t, testVMs[0], "yaml")
+	expected := "attachedDisks:\n" +
+		"- id: disk-1\n" +
+		"  name: boot\n" +
+		"  state: ATTACHED\n" +
+		"cost:\n" +
+		"- 1\n" +
+		"- 2.5\n" +
+		"id: vm-1\n" +
+		"name: first\n"
+	if actual != expected {
+		t.Errorf("Expected %q, got %q", expected, actual)
+	}
+}
+
+func TestFormatDelimited(t *testing.T) {
+	actual := formatForTest(t, testVMs, "csv")
+	expected := "attachedDisks.0.id,attachedDisks.0.name,attachedDisks.0.state," +
+		"attachedDisks.1.id,attachedDisks.1.name,attachedDisks.1.state,cost,id,name\n" +
+		"disk-1,boot,ATTACHED,,,,1;2.5,vm-1,first\n" +
+		"disk-2,boot,,disk-3,data,,,vm-2,second\n"
+	if actual != expected {
+		t.Errorf("Expected %q, got %q", expected, actual)
+	}
+
+	actual = formatForTest(t, map[string]interface{}{"name": "a b", "quota": map[string]int{"vm.count": 2}}, "tsv")
+	expected = "name\tquota.vm.count\na b\t2\n"
+	if actual != expected {
+		t.Errorf("Expected %q, got %q", expected, actual)
+	}
+
+	actual = formatForTest(t, []testVM{}, "csv")
+	if actual != "" {
+		t.Errorf("Expected no output for an empty list, got %q", actual)
+	}
+}
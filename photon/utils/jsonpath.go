@@ -0,0 +1,248 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Apache License, Version 2.0 (the "License").
+// You may not use this product except in compliance with the License.
+//
+// This product may include a number of subcomponents with separate copyright notices and
+// license terms. Your use of these subcomponents is subject to the terms and conditions
+// of the subcomponent's license, as noted in the LICENSE file.
+
+package utils
+
+/**
+ * A small subset of JSONPath, used by the jsonpath and custom-columns output formats.
+ *
+ * A template is plain text with expressions in curly braces, e.g. "{.id}\t{.name}\n".
+ * Expressions are evaluated against the JSON form of the object, so field names are the
+ * ones seen in '--output json'. Supported in an expression:
+ * - an optional leading '$' for the root object
+ * - .field to select a field of an object
+ * - [n] to select an element of a list, negative numbers count from the end
+ * - [*] or .* to select all elements of a list or all values of an object
+ * - "text" as a whole expression, to print a quoted string such as "\n"
+ */
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type jsonPathStepKind int
+
+const (
+	jsonPathField jsonPathStepKind = iota
+	jsonPathIndex
+	jsonPathWildcard
+)
+
+type jsonPathStep struct {
+	kind  jsonPathStepKind
+	field string
+	index int
+}
+
+// A parsed expression, either a literal or a list of steps
+type jsonPathExpression struct {
+	literal   string
+	isLiteral bool
+	steps     []jsonPathStep
+}
+
+// A parsed template: plain text alternating with expressions
+type jsonPathTemplate struct {
+	parts []jsonPathExpression
+}
+
+// Parses a template such as "{.id} {.name}"
+func parseJsonPathTemplate(template string) (*jsonPathTemplate, error) {
+	parsed := &jsonPathTemplate{}
+	rest := template
+	for len(rest) != 0 {
+		start := strings.Index(rest, "{")
+		if start == -1 {
+			parsed.parts = append(parsed.parts, jsonPathExpression{literal: rest, isLiteral: true})
+			break
+		}
+		if start > 0 {
+			parsed.parts = append(parsed.parts, jsonPathExpression{literal: rest[:start], isLiteral: true})
+		}
+		end := strings.Index(rest[start:], "}")
+		if end == -1 {
+			return nil, fmt.Errorf("Unclosed expression in JSONPath template '%s'", template)
+		}
+		expression, err := parseJsonPathExpression(rest[start+1 : start+end])
+		if err != nil {
+			return nil, err
+		}
+		parsed.parts = append(parsed.parts, *expression)
+		rest = rest[start+end+1:]
+	}
+	return parsed, nil
+}
+
+// Parses a single expression such as ".items[0].name" or "\"\\n\""
+func parseJsonPathExpression(expression string) (*jsonPathExpression, error) {
+	expression = strings.TrimSpace(expression)
+	if strings.HasPrefix(expression, "\"") {
+		literal, err := strconv.Unquote(expression)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid string in JSONPath expression '%s'", expression)
+		}
+		return &jsonPathExpression{literal: literal, isLiteral: true}, nil
+	}
+
+	parsed := &jsonPathExpression{}
+	rest := strings.TrimPrefix(expression, "$")
+	for len(rest) != 0 {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			field := rest[:end]
+			rest = rest[end:]
+			if len(field) == 0 {
+				return nil, fmt.Errorf("Missing field name in JSONPath expression '%s'", expression)
+			}
+			if field == "*" {
+				parsed.steps = append(parsed.steps, jsonPathStep{kind: jsonPathWildcard})
+			} else {
+				parsed.steps = append(parsed.steps, jsonPathStep{kind: jsonPathField, field: field})
+			}
+		case '[':
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("Unclosed '[' in JSONPath expression '%s'", expression)
+			}
+			subscript := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+			if subscript == "*" {
+				parsed.steps = append(parsed.steps, jsonPathStep{kind: jsonPathWildcard})
+				continue
+			}
+			if unquoted, err := strconv.Unquote(strings.Replace(subscript, "'", "\"", -1)); err == nil {
+				parsed.steps = append(parsed.steps, jsonPathStep{kind: jsonPathField, field: unquoted})
+				continue
+			}
+			index, err := strconv.Atoi(subscript)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid subscript '%s' in JSONPath expression '%s'", subscript, expression)
+			}
+			parsed.steps = append(parsed.steps, jsonPathStep{kind: jsonPathIndex, index: index})
+		default:
+			return nil, fmt.Errorf("Unexpected '%c' in JSONPath expression '%s'", rest[0], expression)
+		}
+	}
+	return parsed, nil
+}
+
+// Returns all values selected by the expression, in order
+func (expression *jsonPathExpression) evaluate(data interface{}) []interface{} {
+	if expression.isLiteral {
+		return []interface{}{expression.literal}
+	}
+
+	current := []interface{}{data}
+	for _, step := range expression.steps {
+		next := []interface{}{}
+		for _, value := range current {
+			switch step.kind {
+			case jsonPathField:
+				if object, ok := value.(map[string]interface{}); ok {
+					if fieldValue, ok := object[step.field]; ok {
+						next = append(next, fieldValue)
+					}
+				}
+			case jsonPathIndex:
+				if list, ok := value.([]interface{}); ok {
+					index := step.index
+					if index < 0 {
+						index += len(list)
+					}
+					if index >= 0 && index < len(list) {
+						next = append(next, list[index])
+					}
+				}
+			case jsonPathWildcard:
+				switch typed := value.(type) {
+				case []interface{}:
+					next = append(next, typed...)
+				case map[string]interface{}:
+					keys := []string{}
+					for key := range typed {
+						keys = append(keys, key)
+					}
+					sort.Strings(keys)
+					for _, key := range keys {
+						next = append(next, typed[key])
+					}
+				}
+			}
+		}
+		current = next
+	}
+	return current
+}
+
+// Writes the template with each expression replaced by the values it selects
+func (template *jsonPathTemplate) execute(data interface{}) (string, error) {
+	var buffer bytes.Buffer
+	for _, part := range template.parts {
+		if part.isLiteral {
+			buffer.WriteString(part.literal)
+			continue
+		}
+		values, err := jsonPathValuesToString(part.evaluate(data), " ")
+		if err != nil {
+			return "", err
+		}
+		buffer.WriteString(values)
+	}
+	return buffer.String(), nil
+}
+
+// Formats selected values: strings and numbers as is, objects and lists as JSON
+func jsonPathValuesToString(values []interface{}, separator string) (string, error) {
+	strs := []string{}
+	for _, value := range values {
+		switch typed := value.(type) {
+		case string:
+			strs = append(strs, typed)
+		case json.Number:
+			strs = append(strs, typed.String())
+		case nil:
+			strs = append(strs, "")
+		case bool:
+			strs = append(strs, strconv.FormatBool(typed))
+		default:
+			jsonBytes, err := json.Marshal(typed)
+			if err != nil {
+				return "", err
+			}
+			strs = append(strs, string(jsonBytes))
+		}
+	}
+	return strings.Join(strs, separator), nil
+}
+
+// Converts an object to the generic form seen by JSON, so that expressions use JSON field names
+func toJsonData(o interface{}) (interface{}, error) {
+	jsonBytes, err := json.Marshal(o)
+	if err != nil {
+		return nil, err
+	}
+	var data interface{}
+	decoder := json.NewDecoder(bytes.NewReader(jsonBytes))
+	decoder.UseNumber()
+	err = decoder.Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}